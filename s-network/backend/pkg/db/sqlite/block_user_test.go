@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestBlockUserRemovesRelationships(t *testing.T) {
+	db := newTestDB(t)
+	blocker := createTestUser(t, db, "blocker")
+	blocked := createTestUser(t, db, "blocked")
+	other := createTestUser(t, db, "other")
+
+	if err := db.FollowUser(blocker, blocked); err != nil {
+		t.Fatalf("Failed to follow: %v", err)
+	}
+	if err := db.FollowUser(blocker, other); err != nil {
+		t.Fatalf("Failed to follow other user: %v", err)
+	}
+	if _, err := db.CreateFollowRequest(int64(blocked), int64(blocker)); err != nil {
+		t.Fatalf("Failed to create follow request: %v", err)
+	}
+	if err := db.AddCloseFriend(blocker, blocked); err != nil {
+		t.Fatalf("Failed to add close friend: %v", err)
+	}
+	if err := db.AddCloseFriend(blocked, blocker); err != nil {
+		t.Fatalf("Failed to add close friend: %v", err)
+	}
+
+	if err := db.BlockUser(int64(blocker), int64(blocked)); err != nil {
+		t.Fatalf("BlockUser returned error: %v", err)
+	}
+
+	if n := countRows(t, db, `SELECT COUNT(*) FROM blocked_users WHERE blocker_id = ? AND blocked_id = ?`, blocker, blocked); n != 1 {
+		t.Errorf("Got %d block rows, want 1", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM followers WHERE follower_id IN (?1, ?2) AND following_id IN (?1, ?2)`, blocker, blocked); n != 0 {
+		t.Errorf("Got %d follows between the users after blocking, want 0", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM follow_requests WHERE requester_id IN (?1, ?2) AND requested_id IN (?1, ?2)`, blocker, blocked); n != 0 {
+		t.Errorf("Got %d follow requests between the users after blocking, want 0", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM close_friends WHERE user_id IN (?1, ?2) AND friend_id IN (?1, ?2)`, blocker, blocked); n != 0 {
+		t.Errorf("Got %d close friend entries between the users after blocking, want 0", n)
+	}
+
+	following, err := db.IsFollowing(blocker, other)
+	if err != nil {
+		t.Fatalf("Failed to check follow: %v", err)
+	}
+	if !following {
+		t.Error("Blocking removed an unrelated follow")
+	}
+}
+
+func TestBlockUserWithoutFollowRequestsTable(t *testing.T) {
+	db := newTestDB(t)
+	blocker := createTestUser(t, db, "blocker")
+	blocked := createTestUser(t, db, "blocked")
+
+	if _, err := db.Exec(`DROP TABLE IF EXISTS follow_requests`); err != nil {
+		t.Fatalf("Failed to drop follow_requests: %v", err)
+	}
+
+	if err := db.BlockUser(int64(blocker), int64(blocked)); err != nil {
+		t.Fatalf("BlockUser returned error: %v", err)
+	}
+}
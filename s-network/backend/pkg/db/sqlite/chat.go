@@ -2,7 +2,9 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -30,15 +32,19 @@ type ChatParticipant struct {
 	UserID            int64     `json:"user_id"`
 	JoinedAt          time.Time `json:"joined_at"`
 	LastReadMessageID *int64    `json:"last_read_message_id"`
+	Muted             bool      `json:"muted"`
 }
 
 type ChatMessage struct {
-	ID             int64     `json:"id"`
-	ConversationID int64     `json:"conversation_id"`
-	SenderID       int64     `json:"sender_id"`
-	Content        string    `json:"content"`
-	IsDeleted      bool      `json:"is_deleted"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             int64      `json:"id"`
+	ConversationID int64      `json:"conversation_id"`
+	SenderID       int64      `json:"sender_id"`
+	Content        string     `json:"content"`
+	IsDeleted      bool       `json:"is_deleted"`
+	CreatedAt      time.Time  `json:"created_at"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+	// Status is the aggregate of sent, delivered or read across recipients, loaded on demand
+	Status string `json:"status,omitempty"`
 	// Nested structs for related data
 	Sender      *User             `json:"sender,omitempty"`
 	Attachments []*ChatAttachment `json:"attachments,omitempty"`
@@ -46,12 +52,14 @@ type ChatMessage struct {
 
 // GroupMessage represents a message in a group chat
 type GroupMessage struct {
-	ID        int64     `json:"id"`
-	GroupID   int64     `json:"group_id"`
-	SenderID  int64     `json:"sender_id"`
-	Content   string    `json:"content"`
-	IsDeleted bool      `json:"is_deleted"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             int64      `json:"id"`
+	GroupID        int64      `json:"group_id"`
+	SenderID       int64      `json:"sender_id"`
+	Content        string     `json:"content"`
+	IsDeleted      bool       `json:"is_deleted"`
+	IsAnnouncement bool       `json:"is_announcement"`
+	CreatedAt      time.Time  `json:"created_at"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
 	// Nested structs for related data
 	Sender      *User                     `json:"sender,omitempty"`
 	Attachments []*GroupMessageAttachment `json:"attachments,omitempty"`
@@ -148,7 +156,7 @@ func (db *DB) RemoveParticipant(conversationID, userID int64) error {
 
 // GetConversationParticipants retrieves all participants in a conversation
 func (db *DB) GetConversationParticipants(conversationID int64) ([]*ChatParticipant, error) {
-	query := `SELECT conversation_id, user_id, joined_at, last_read_message_id 
+	query := `SELECT conversation_id, user_id, joined_at, last_read_message_id, COALESCE(muted, 0) 
 	          FROM chat_participants 
 	          WHERE conversation_id = ?`
 
@@ -168,6 +176,7 @@ func (db *DB) GetConversationParticipants(conversationID int64) ([]*ChatParticip
 			&participant.UserID,
 			&participant.JoinedAt,
 			&lastReadID,
+			&participant.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -273,10 +282,11 @@ func (db *DB) CreateMessage(message *ChatMessage) (int64, error) {
 
 // GetMessage retrieves a message by its ID
 func (db *DB) GetMessage(id int64) (*ChatMessage, error) {
-	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at 
+	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at, edited_at 
 	          FROM chat_messages WHERE id = ?`
 
 	var message ChatMessage
+	var editedAt sql.NullTime
 	err := db.QueryRow(query, id).Scan(
 		&message.ID,
 		&message.ConversationID,
@@ -284,6 +294,7 @@ func (db *DB) GetMessage(id int64) (*ChatMessage, error) {
 		&message.Content,
 		&message.IsDeleted,
 		&message.CreatedAt,
+		&editedAt,
 	)
 
 	if err != nil {
@@ -293,12 +304,16 @@ func (db *DB) GetMessage(id int64) (*ChatMessage, error) {
 		return nil, err
 	}
 
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+
 	return &message, nil
 }
 
 // GetConversationMessages retrieves messages from a conversation with pagination
 func (db *DB) GetConversationMessages(conversationID int64, limit, offset int) ([]*ChatMessage, error) {
-	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at 
+	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at, edited_at 
 	          FROM chat_messages 
 	          WHERE conversation_id = ? 
 	          ORDER BY created_at ASC 
@@ -313,9 +328,79 @@ func (db *DB) GetConversationMessages(conversationID int64, limit, offset int) (
 	}
 	defer rows.Close()
 
+	messages, err := db.scanChatMessages(rows)
+	if err != nil {
+		log.Printf("❌ DB GetConversationMessages: %v", err)
+		return nil, err
+	}
+
+	log.Printf("✅ DB GetConversationMessages: Retrieved %d messages for conversation %d", len(messages), conversationID)
+
+	return messages, nil
+}
+
+// MessageCursor marks a position in a message history for paging back through it.
+// When BeforeID is set the messages older than that message are returned, which also
+// handles messages sharing a timestamp; otherwise messages created before Before are returned
+type MessageCursor struct {
+	Before   time.Time
+	BeforeID int64
+}
+
+// messageCursorCondition builds the filter for messages strictly older than the cursor
+func messageCursorCondition(table string, cursor MessageCursor) (string, []interface{}) {
+	if cursor.BeforeID > 0 {
+		createdAt := `(SELECT created_at FROM ` + table + ` WHERE id = ?)`
+		condition := `(created_at < ` + createdAt + ` OR (created_at = ` + createdAt + ` AND id < ?))`
+		return condition, []interface{}{cursor.BeforeID, cursor.BeforeID, cursor.BeforeID}
+	}
+
+	// created_at is stored as CURRENT_TIMESTAMP text, so compare in the same format
+	return `created_at < ?`, []interface{}{cursor.Before.UTC().Format("2006-01-02 15:04:05")}
+}
+
+// GetConversationMessagesBefore retrieves up to limit messages older than the cursor, newest first
+func (db *DB) GetConversationMessagesBefore(conversationID int64, cursor MessageCursor, limit int) ([]*ChatMessage, error) {
+	condition, args := messageCursorCondition("chat_messages", cursor)
+	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at, edited_at 
+	          FROM chat_messages 
+	          WHERE conversation_id = ? AND ` + condition + `
+	          ORDER BY created_at DESC, id DESC 
+	          LIMIT ?`
+
+	args = append([]interface{}{conversationID}, args...)
+	rows, err := db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanChatMessages(rows)
+}
+
+// GetSentMessages retrieves every message a user sent in direct or group conversations, oldest first.
+// Deleted messages are left out
+func (db *DB) GetSentMessages(senderID int64) ([]*ChatMessage, error) {
+	query := `SELECT id, conversation_id, sender_id, content, is_deleted, created_at, edited_at 
+	          FROM chat_messages 
+	          WHERE sender_id = ? AND is_deleted = 0
+	          ORDER BY created_at ASC, id ASC`
+
+	rows, err := db.Query(query, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent messages: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanChatMessages(rows)
+}
+
+// scanChatMessages reads chat message rows and loads their attachments
+func (db *DB) scanChatMessages(rows *sql.Rows) ([]*ChatMessage, error) {
 	var messages []*ChatMessage
 	for rows.Next() {
 		var message ChatMessage
+		var editedAt sql.NullTime
 		if err := rows.Scan(
 			&message.ID,
 			&message.ConversationID,
@@ -323,12 +408,14 @@ func (db *DB) GetConversationMessages(conversationID int64, limit, offset int) (
 			&message.Content,
 			&message.IsDeleted,
 			&message.CreatedAt,
+			&editedAt,
 		); err != nil {
-			log.Printf("❌ DB GetConversationMessages: Row scan failed - %v", err)
-			return nil, err
+			return nil, fmt.Errorf("row scan failed: %v", err)
 		}
 
-		log.Printf("🔍 DB GetConversationMessages: Found message %d from user %d", message.ID, message.SenderID)
+		if editedAt.Valid {
+			message.EditedAt = &editedAt.Time
+		}
 
 		// Fetch message attachments (optional - graceful degradation if table doesn't exist)
 		attachments, err := db.GetMessageAttachments(message.ID)
@@ -343,12 +430,9 @@ func (db *DB) GetConversationMessages(conversationID int64, limit, offset int) (
 	}
 
 	if err := rows.Err(); err != nil {
-		log.Printf("❌ DB GetConversationMessages: Rows error - %v", err)
-		return nil, err
+		return nil, fmt.Errorf("rows error: %v", err)
 	}
 
-	log.Printf("✅ DB GetConversationMessages: Retrieved %d messages for conversation %d", len(messages), conversationID)
-
 	return messages, nil
 }
 
@@ -362,16 +446,163 @@ func (db *DB) MarkMessageAsDeleted(id int64) error {
 	return err
 }
 
-// UpdateLastReadMessage updates the last read message for a participant
+// EditChatMessage updates the content of a message owned by the sender and stamps edited_at
+func (db *DB) EditChatMessage(messageID, senderID int64, content string) error {
+	query := `UPDATE chat_messages 
+	          SET content = ?, edited_at = CURRENT_TIMESTAMP 
+	          WHERE id = ? AND sender_id = ? AND is_deleted = FALSE`
+
+	result, err := db.Exec(query, content, messageID, senderID)
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("message not found")
+	}
+
+	return nil
+}
+
+// UpdateLastReadMessage updates the last read message for a participant.
+// The read position only moves forward, so loading older pages doesn't reset it
 func (db *DB) UpdateLastReadMessage(conversationID, userID, messageID int64) error {
 	query := `UPDATE chat_participants 
-	          SET last_read_message_id = ? 
+	          SET last_read_message_id = MAX(COALESCE(last_read_message_id, 0), ?) 
 	          WHERE conversation_id = ? AND user_id = ?`
 
 	_, err := db.Exec(query, messageID, conversationID, userID)
 	return err
 }
 
+// SetConversationMuted stores whether a participant has muted a conversation.
+// Messages still arrive in muted conversations but don't count towards unread badges
+func (db *DB) SetConversationMuted(conversationID, userID int64, muted bool) error {
+	query := `UPDATE chat_participants SET muted = ? WHERE conversation_id = ? AND user_id = ?`
+
+	result, err := db.Exec(query, muted, conversationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation mute setting: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("participant not found")
+	}
+
+	return nil
+}
+
+// Message statuses, from least to most advanced
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+)
+
+// messageStatusRank orders statuses in SQL so an update never moves a message backwards
+const messageStatusRank = `CASE %s WHEN 'read' THEN 2 WHEN 'delivered' THEN 1 ELSE 0 END`
+
+// UpsertMessageStatus records a recipient's status for a direct message.
+// A message that was already read is not marked as delivered again
+func (db *DB) UpsertMessageStatus(messageID, userID int64, status string) error {
+	query := `INSERT INTO message_status (message_id, user_id, status, updated_at)
+	          VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	          ON CONFLICT(message_id, user_id) DO UPDATE SET status = excluded.status, updated_at = CURRENT_TIMESTAMP
+	          WHERE ` + fmt.Sprintf(messageStatusRank, "excluded.status") + ` > ` + fmt.Sprintf(messageStatusRank, "message_status.status")
+
+	if _, err := db.Exec(query, messageID, userID, status); err != nil {
+		return fmt.Errorf("failed to update message status: %v", err)
+	}
+
+	return nil
+}
+
+// MarkMessagesRead marks every message the user received in a conversation up to and
+// including messageID as read
+func (db *DB) MarkMessagesRead(conversationID, userID, messageID int64) error {
+	query := `INSERT INTO message_status (message_id, user_id, status, updated_at)
+	          SELECT id, ?, 'read', CURRENT_TIMESTAMP FROM chat_messages
+	          WHERE conversation_id = ? AND sender_id != ? AND id <= ?
+	          ON CONFLICT(message_id, user_id) DO UPDATE SET status = 'read', updated_at = CURRENT_TIMESTAMP
+	          WHERE message_status.status != 'read'`
+
+	if _, err := db.Exec(query, userID, conversationID, userID, messageID); err != nil {
+		return fmt.Errorf("failed to mark messages as read: %v", err)
+	}
+
+	return nil
+}
+
+// MarkMessagesDelivered marks the given messages as delivered to the user, leaving out
+// the user's own messages and any the user already read
+func (db *DB) MarkMessagesDelivered(userID int64, messageIDs []int64) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(messageIDs)), ", ")
+	query := `INSERT INTO message_status (message_id, user_id, status, updated_at)
+	          SELECT id, ?, 'delivered', CURRENT_TIMESTAMP FROM chat_messages
+	          WHERE sender_id != ? AND id IN (` + placeholders + `)
+	          ON CONFLICT(message_id, user_id) DO UPDATE SET status = 'delivered', updated_at = CURRENT_TIMESTAMP
+	          WHERE message_status.status = 'sent'`
+
+	args := []interface{}{userID, userID}
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to mark messages as delivered: %v", err)
+	}
+
+	return nil
+}
+
+// GetMessageStatus returns the aggregate status of a direct message across its recipients:
+// read once every recipient read it, delivered once it reached every recipient, otherwise sent
+func (db *DB) GetMessageStatus(messageID int64) (string, error) {
+	query := `SELECT COUNT(*),
+	                 COALESCE(SUM(CASE WHEN ms.status = 'read' THEN 1 ELSE 0 END), 0),
+	                 COALESCE(SUM(CASE WHEN ms.status IN ('delivered', 'read') THEN 1 ELSE 0 END), 0)
+	          FROM chat_messages m
+	          JOIN chat_participants cp ON cp.conversation_id = m.conversation_id AND cp.user_id != m.sender_id
+	          LEFT JOIN message_status ms ON ms.message_id = m.id AND ms.user_id = cp.user_id
+	          WHERE m.id = ?`
+
+	var recipients, read, delivered int
+	if err := db.QueryRow(query, messageID).Scan(&recipients, &read, &delivered); err != nil {
+		return "", fmt.Errorf("failed to get message status: %v", err)
+	}
+
+	switch {
+	case recipients > 0 && read == recipients:
+		return MessageStatusRead, nil
+	case recipients > 0 && delivered == recipients:
+		return MessageStatusDelivered, nil
+	default:
+		return MessageStatusSent, nil
+	}
+}
+
+// GetLatestMessageID returns the ID of the newest message in a conversation, or 0 if there are none
+func (db *DB) GetLatestMessageID(conversationID int64) (int64, error) {
+	var messageID int64
+	query := `SELECT COALESCE(MAX(id), 0) FROM chat_messages WHERE conversation_id = ?`
+	err := db.QueryRow(query, conversationID).Scan(&messageID)
+	return messageID, err
+}
+
 // AddAttachment adds an attachment to a message
 func (db *DB) AddAttachment(attachment *ChatAttachment) (int64, error) {
 	query := `INSERT INTO chat_attachments (message_id, file_url, file_type, file_name, file_size) 
@@ -434,10 +665,12 @@ func (db *DB) GetUnreadMessageCount(conversationID, userID int64) (int, error) {
 	          JOIN chat_participants p ON m.conversation_id = p.conversation_id
 	          WHERE m.conversation_id = ? 
 	          AND p.user_id = ?
+	          AND m.sender_id != ?
+	          AND m.is_deleted = FALSE
 	          AND (p.last_read_message_id IS NULL OR m.id > p.last_read_message_id)`
 
 	var count int
-	err := db.QueryRow(query, conversationID, userID).Scan(&count)
+	err := db.QueryRow(query, conversationID, userID, userID).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -445,7 +678,84 @@ func (db *DB) GetUnreadMessageCount(conversationID, userID int64) (int, error) {
 	return count, nil
 }
 
-// GetOrCreateDirectConversation gets an existing direct conversation between two users or creates a new one
+// ConversationUnreadCount is the number of unread messages in one of a user's conversations
+type ConversationUnreadCount struct {
+	ConversationID int64 `json:"conversation_id"`
+	UnreadCount    int   `json:"unread_count"`
+	Muted          bool  `json:"muted"`
+}
+
+// GetUnreadMessageSummary returns the unread message count of every conversation the user
+// has unread messages in, using the same rules as GetUnreadMessageCount, along with the total.
+// Muted conversations are listed but left out of the total.
+// Conversations with the most recent unread message come first
+func (db *DB) GetUnreadMessageSummary(userID int64) ([]*ConversationUnreadCount, int, error) {
+	query := `SELECT m.conversation_id, COUNT(*), COALESCE(p.muted, 0) FROM chat_messages m
+	          JOIN chat_participants p ON m.conversation_id = p.conversation_id AND p.user_id = ?
+	          WHERE m.sender_id != ?
+	          AND m.is_deleted = FALSE
+	          AND (p.last_read_message_id IS NULL OR m.id > p.last_read_message_id)
+	          GROUP BY m.conversation_id
+	          ORDER BY MAX(m.id) DESC`
+
+	rows, err := db.Query(query, userID, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get unread message summary: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []*ConversationUnreadCount{}
+	total := 0
+	for rows.Next() {
+		var count ConversationUnreadCount
+		if err := rows.Scan(&count.ConversationID, &count.UnreadCount, &count.Muted); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan unread count: %v", err)
+		}
+		if !count.Muted {
+			total += count.UnreadCount
+		}
+		counts = append(counts, &count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return counts, total, nil
+}
+
+// Direct message policies decide which follow relationship two users need
+// before they can start or continue a direct conversation
+const (
+	DirectMessagePolicyOneWay = "one_way" // Either user follows the other
+	DirectMessagePolicyMutual = "mutual"  // Both users follow each other
+)
+
+// DirectMessagePolicy is the policy applied to direct conversations. Group conversations are exempt
+var DirectMessagePolicy = DirectMessagePolicyOneWay
+
+// CanDirectMessage checks whether the follow relationship between two users
+// satisfies DirectMessagePolicy
+func (db *DB) CanDirectMessage(senderID, recipientID int64) (bool, error) {
+	senderFollows, err := db.IsFollowing(int(senderID), int(recipientID))
+	if err != nil {
+		return false, err
+	}
+
+	recipientFollows, err := db.IsFollowing(int(recipientID), int(senderID))
+	if err != nil {
+		return false, err
+	}
+
+	if DirectMessagePolicy == DirectMessagePolicyMutual {
+		return senderFollows && recipientFollows, nil
+	}
+	return senderFollows || recipientFollows, nil
+}
+
+// GetOrCreateDirectConversation gets an existing direct conversation between two users or creates a new one.
+// A new conversation is only created when CanDirectMessage allows it, otherwise
+// a "direct messages not allowed" error is returned
 func (db *DB) GetOrCreateDirectConversation(user1ID, user2ID int64) (int64, error) {
 	log.Printf("🔍 DB GetOrCreateDirectConversation: Looking for conversation between users %d and %d", user1ID, user2ID)
 
@@ -475,6 +785,14 @@ func (db *DB) GetOrCreateDirectConversation(user1ID, user2ID int64) (int64, erro
 
 	log.Printf("🔍 DB GetOrCreateDirectConversation: No existing conversation found, creating new one")
 
+	allowed, err := db.CanDirectMessage(user1ID, user2ID)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, fmt.Errorf("direct messages not allowed")
+	}
+
 	// Conversation doesn't exist, create a new one
 	tx, err := db.Begin()
 	if err != nil {
@@ -532,10 +850,10 @@ func (db *DB) GetOrCreateDirectConversation(user1ID, user2ID int64) (int64, erro
 
 // CreateGroupMessage adds a new message to a group chat
 func (db *DB) CreateGroupMessage(message *GroupMessage) (int64, error) {
-	query := `INSERT INTO group_messages (group_id, sender_id, content) 
-	          VALUES (?, ?, ?)`
+	query := `INSERT INTO group_messages (group_id, sender_id, content, is_announcement) 
+	          VALUES (?, ?, ?, ?)`
 
-	result, err := db.Exec(query, message.GroupID, message.SenderID, message.Content)
+	result, err := db.Exec(query, message.GroupID, message.SenderID, message.Content, message.IsAnnouncement)
 	if err != nil {
 		return 0, err
 	}
@@ -545,17 +863,20 @@ func (db *DB) CreateGroupMessage(message *GroupMessage) (int64, error) {
 
 // GetGroupMessage retrieves a group message by its ID
 func (db *DB) GetGroupMessage(id int64) (*GroupMessage, error) {
-	query := `SELECT id, group_id, sender_id, content, is_deleted, created_at 
+	query := `SELECT id, group_id, sender_id, content, is_deleted, COALESCE(is_announcement, 0), created_at, edited_at 
 	          FROM group_messages WHERE id = ?`
 
 	var message GroupMessage
+	var editedAt sql.NullTime
 	err := db.QueryRow(query, id).Scan(
 		&message.ID,
 		&message.GroupID,
 		&message.SenderID,
 		&message.Content,
 		&message.IsDeleted,
+		&message.IsAnnouncement,
 		&message.CreatedAt,
+		&editedAt,
 	)
 
 	if err != nil {
@@ -565,14 +886,19 @@ func (db *DB) GetGroupMessage(id int64) (*GroupMessage, error) {
 		return nil, err
 	}
 
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+
 	return &message, nil
 }
 
-// GetGroupMessages retrieves messages from a group with pagination
+// GetGroupMessages retrieves messages from a group with pagination.
+// Deleted messages are included so clients can render a tombstone in their place
 func (db *DB) GetGroupMessages(groupID int64, limit, offset int) ([]*GroupMessage, error) {
-	query := `SELECT id, group_id, sender_id, content, is_deleted, created_at 
+	query := `SELECT id, group_id, sender_id, content, is_deleted, COALESCE(is_announcement, 0), created_at, edited_at 
 	          FROM group_messages 
-	          WHERE group_id = ? AND is_deleted = FALSE
+	          WHERE group_id = ?
 	          ORDER BY created_at ASC 
 	          LIMIT ? OFFSET ?`
 
@@ -582,20 +908,68 @@ func (db *DB) GetGroupMessages(groupID int64, limit, offset int) ([]*GroupMessag
 	}
 	defer rows.Close()
 
+	return db.scanGroupMessages(rows)
+}
+
+// GetGroupMessagesBefore retrieves up to limit group messages older than the cursor, newest first
+func (db *DB) GetGroupMessagesBefore(groupID int64, cursor MessageCursor, limit int) ([]*GroupMessage, error) {
+	condition, args := messageCursorCondition("group_messages", cursor)
+	query := `SELECT id, group_id, sender_id, content, is_deleted, COALESCE(is_announcement, 0), created_at, edited_at 
+	          FROM group_messages 
+	          WHERE group_id = ? AND ` + condition + `
+	          ORDER BY created_at DESC, id DESC 
+	          LIMIT ?`
+
+	args = append([]interface{}{groupID}, args...)
+	rows, err := db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group messages: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanGroupMessages(rows)
+}
+
+// GetSentGroupMessages retrieves every group message a user sent, oldest first.
+// Deleted messages are left out
+func (db *DB) GetSentGroupMessages(senderID int64) ([]*GroupMessage, error) {
+	query := `SELECT id, group_id, sender_id, content, is_deleted, COALESCE(is_announcement, 0), created_at, edited_at 
+	          FROM group_messages 
+	          WHERE sender_id = ? AND is_deleted = 0
+	          ORDER BY created_at ASC, id ASC`
+
+	rows, err := db.Query(query, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent group messages: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanGroupMessages(rows)
+}
+
+// scanGroupMessages reads group message rows and loads their attachments
+func (db *DB) scanGroupMessages(rows *sql.Rows) ([]*GroupMessage, error) {
 	var messages []*GroupMessage
 	for rows.Next() {
 		var message GroupMessage
+		var editedAt sql.NullTime
 		if err := rows.Scan(
 			&message.ID,
 			&message.GroupID,
 			&message.SenderID,
 			&message.Content,
 			&message.IsDeleted,
+			&message.IsAnnouncement,
 			&message.CreatedAt,
+			&editedAt,
 		); err != nil {
 			return nil, err
 		}
 
+		if editedAt.Valid {
+			message.EditedAt = &editedAt.Time
+		}
+
 		// Fetch message attachments (optional - graceful degradation if table doesn't exist)
 		attachments, err := db.GetGroupMessageAttachments(message.ID)
 		if err != nil {
@@ -625,6 +999,29 @@ func (db *DB) MarkGroupMessageAsDeleted(id int64) error {
 	return err
 }
 
+// EditGroupMessage updates the content of a group message owned by the sender and stamps edited_at
+func (db *DB) EditGroupMessage(messageID, senderID int64, content string) error {
+	query := `UPDATE group_messages 
+	          SET content = ?, edited_at = CURRENT_TIMESTAMP 
+	          WHERE id = ? AND sender_id = ? AND is_deleted = FALSE`
+
+	result, err := db.Exec(query, content, messageID, senderID)
+	if err != nil {
+		return fmt.Errorf("failed to edit group message: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("message not found")
+	}
+
+	return nil
+}
+
 // AddGroupMessageAttachment adds an attachment to a group message
 func (db *DB) AddGroupMessageAttachment(attachment *GroupMessageAttachment) (int64, error) {
 	query := `INSERT INTO group_message_attachments (message_id, file_url, file_type, file_name, file_size) 
@@ -683,20 +1080,23 @@ func (db *DB) GetGroupMessageAttachments(messageID int64) ([]*GroupMessageAttach
 
 // GetLatestGroupMessage gets the most recent message from a group
 func (db *DB) GetLatestGroupMessage(groupID int64) (*GroupMessage, error) {
-	query := `SELECT id, group_id, sender_id, content, is_deleted, created_at 
+	query := `SELECT id, group_id, sender_id, content, is_deleted, COALESCE(is_announcement, 0), created_at, edited_at 
 	          FROM group_messages 
 	          WHERE group_id = ? AND is_deleted = FALSE
 	          ORDER BY created_at DESC 
 	          LIMIT 1`
 
 	var message GroupMessage
+	var editedAt sql.NullTime
 	err := db.QueryRow(query, groupID).Scan(
 		&message.ID,
 		&message.GroupID,
 		&message.SenderID,
 		&message.Content,
 		&message.IsDeleted,
+		&message.IsAnnouncement,
 		&message.CreatedAt,
+		&editedAt,
 	)
 
 	if err != nil {
@@ -706,5 +1106,121 @@ func (db *DB) GetLatestGroupMessage(groupID int64) (*GroupMessage, error) {
 		return nil, err
 	}
 
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+
 	return &message, nil
 }
+
+// MessageSearchResult is a message matching a search, with its conversation and sender
+type MessageSearchResult struct {
+	ID              int64     `json:"id"`
+	ConversationID  int64     `json:"conversation_id"`
+	IsGroup         bool      `json:"is_group"`
+	GroupID         *int64    `json:"group_id,omitempty"`
+	SenderID        int64     `json:"sender_id"`
+	SenderFirstName string    `json:"sender_first_name"`
+	SenderLastName  string    `json:"sender_last_name"`
+	SenderAvatar    string    `json:"sender_avatar,omitempty"`
+	Content         string    `json:"content"`
+	Snippet         string    `json:"snippet"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SearchMessages finds non-deleted direct and group messages containing the query
+// in conversations the user participates in, newest first
+func (db *DB) SearchMessages(userID int64, query string, limit, offset int) ([]*MessageSearchResult, error) {
+	sqlQuery := `SELECT m.id, m.conversation_id, 0 AS is_group, NULL AS group_id, m.sender_id,
+	                    u.first_name, u.last_name, COALESCE(u.avatar, ''), m.content, m.created_at AS created_at
+	             FROM chat_messages m
+	             JOIN chat_participants p ON p.conversation_id = m.conversation_id AND p.user_id = ?1
+	             JOIN users u ON m.sender_id = u.id
+	             WHERE m.is_deleted = FALSE AND LOWER(m.content) LIKE ?2 ESCAPE '\'
+	             UNION ALL
+	             SELECT gm.id, c.id, 1, gm.group_id, gm.sender_id,
+	                    u.first_name, u.last_name, COALESCE(u.avatar, ''), gm.content, gm.created_at
+	             FROM group_messages gm
+	             JOIN chat_conversations c ON c.group_id = gm.group_id AND c.is_group = TRUE
+	             JOIN chat_participants p ON p.conversation_id = c.id AND p.user_id = ?1
+	             JOIN users u ON gm.sender_id = u.id
+	             WHERE gm.is_deleted = FALSE AND LOWER(gm.content) LIKE ?2 ESCAPE '\'
+	             ORDER BY created_at DESC
+	             LIMIT ?3 OFFSET ?4`
+
+	rows, err := db.Query(sqlQuery, userID, likePattern(query), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*MessageSearchResult
+	for rows.Next() {
+		var result MessageSearchResult
+		var groupID sql.NullInt64
+		if err := rows.Scan(
+			&result.ID,
+			&result.ConversationID,
+			&result.IsGroup,
+			&groupID,
+			&result.SenderID,
+			&result.SenderFirstName,
+			&result.SenderLastName,
+			&result.SenderAvatar,
+			&result.Content,
+			&result.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %v", err)
+		}
+
+		if groupID.Valid {
+			result.GroupID = &groupID.Int64
+		}
+		result.Snippet = messageSnippet(result.Content, query)
+
+		results = append(results, &result)
+	}
+
+	return results, rows.Err()
+}
+
+// messageSnippetRadius is how many characters of context are kept on each side of a match
+const messageSnippetRadius = 40
+
+// messageSnippet returns the part of content around the first match of query
+func messageSnippet(content, query string) string {
+	runes := []rune(content)
+	lowerRunes := []rune(strings.ToLower(content))
+	queryRunes := []rune(strings.ToLower(query))
+
+	// Find the match position in runes so multi-byte characters are not split
+	start := -1
+	for i := 0; i+len(queryRunes) <= len(lowerRunes); i++ {
+		if string(lowerRunes[i:i+len(queryRunes)]) == string(queryRunes) {
+			start = i
+			break
+		}
+	}
+	if start < 0 || len(lowerRunes) != len(runes) {
+		start = 0
+	}
+
+	from := start - messageSnippetRadius
+	if from < 0 {
+		from = 0
+	}
+	to := start + len(queryRunes) + messageSnippetRadius
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	snippet := string(runes[from:to])
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(runes) {
+		snippet += "..."
+	}
+
+	return snippet
+}
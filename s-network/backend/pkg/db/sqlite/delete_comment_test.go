@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestDeleteCommentRemovesVotesAndReports(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	other := createTestUser(t, db, "other")
+
+	postID, err := db.CreatePost(owner, "Title", "Content", "", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	commentID, err := db.AddComment(postID, int64(owner), "Comment", "")
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+
+	if err := db.Vote(other, commentID, "comment", 1); err != nil {
+		t.Fatalf("Failed to vote on comment: %v", err)
+	}
+	if _, err := db.CreateReport(int64(other), commentID, "comment", "spam"); err != nil {
+		t.Fatalf("Failed to report comment: %v", err)
+	}
+
+	if err := db.DeleteComment(commentID); err != nil {
+		t.Fatalf("DeleteComment returned error: %v", err)
+	}
+
+	for _, table := range []string{"votes", "reports"} {
+		query := `SELECT COUNT(*) FROM ` + table + ` WHERE content_type = 'comment' AND content_id = ?`
+		if count := countRows(t, db, query, commentID); count != 0 {
+			t.Errorf("Got %d %s on the deleted comment, want 0", count, table)
+		}
+	}
+}
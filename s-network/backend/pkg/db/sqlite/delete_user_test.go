@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func countRows(t *testing.T, db *DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(query, args...).Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	return count
+}
+
+func TestDeleteUserRemovesContentAndCreatedGroups(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	other := createTestUser(t, db, "other")
+
+	postID, err := db.CreatePost(owner, "Title", "Content", "", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if err := db.Vote(other, postID, "post", 1); err != nil {
+		t.Fatalf("Failed to vote: %v", err)
+	}
+	if _, err := db.CreateReport(int64(other), postID, "post", "spam"); err != nil {
+		t.Fatalf("Failed to report post: %v", err)
+	}
+
+	groupID, err := db.CreateGroup(&Group{Name: "Owned", CreatorID: int64(owner), Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	groupPostID, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: int64(other), Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed to create group post: %v", err)
+	}
+	if err := db.Vote(owner, groupPostID, "group_post", 1); err != nil {
+		t.Fatalf("Failed to vote on group post: %v", err)
+	}
+
+	if _, err := db.CreateFollowRequest(int64(other), int64(owner)); err != nil {
+		t.Fatalf("Failed to create follow request: %v", err)
+	}
+
+	if err := db.DeleteUser(int64(owner)); err != nil {
+		t.Fatalf("DeleteUser returned error: %v", err)
+	}
+
+	checks := []struct {
+		desc  string
+		query string
+		arg   int64
+	}{
+		{"users", `SELECT COUNT(*) FROM users WHERE id = ?`, int64(owner)},
+		{"posts", `SELECT COUNT(*) FROM posts WHERE id = ?`, postID},
+		{"votes on posts", `SELECT COUNT(*) FROM votes WHERE content_type = 'post' AND content_id = ?`, postID},
+		{"reports on posts", `SELECT COUNT(*) FROM reports WHERE content_type = 'post' AND content_id = ?`, postID},
+		{"groups", `SELECT COUNT(*) FROM groups WHERE id = ?`, groupID},
+		{"group posts", `SELECT COUNT(*) FROM group_posts WHERE group_id = ?`, groupID},
+		{"votes on group posts", `SELECT COUNT(*) FROM votes WHERE content_type = 'group_post' AND content_id = ?`, groupPostID},
+		{"follow requests", `SELECT COUNT(*) FROM follow_requests WHERE requested_id = ?`, int64(owner)},
+	}
+	for _, check := range checks {
+		if count := countRows(t, db, check.query, check.arg); count != 0 {
+			t.Errorf("Got %d %s left after deleting the user, want 0", count, check.desc)
+		}
+	}
+}
+
+func TestDeleteUserRollsBackOnFailure(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+
+	if _, err := db.CreatePost(owner, "Title", "Content", "", "public", nil); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	// A missing table makes one of the deletions fail
+	if _, err := db.Exec(`DROP TABLE bookmarks`); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+
+	if err := db.DeleteUser(int64(owner)); err == nil {
+		t.Fatal("DeleteUser succeeded although a deletion failed")
+	}
+
+	if count := countRows(t, db, `SELECT COUNT(*) FROM users WHERE id = ?`, owner); count != 1 {
+		t.Errorf("Got %d users after the failed deletion, want 1", count)
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE user_id = ?`, owner); count != 1 {
+		t.Errorf("Got %d posts after the failed deletion, want 1", count)
+	}
+}
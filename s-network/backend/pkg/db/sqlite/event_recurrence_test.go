@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthlyOccurrencesClampToMonthEnd(t *testing.T) {
+	event := &GroupEvent{
+		EventDate:  time.Date(2027, time.January, 31, 18, 30, 0, 0, time.UTC),
+		Recurrence: "monthly",
+	}
+
+	want := []string{"2027-01-31", "2027-02-28", "2027-03-31", "2027-04-30"}
+	dates := event.OccurrenceDates(time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC))
+	if len(dates) != len(want) {
+		t.Fatalf("Got %d occurrences, want %d", len(dates), len(want))
+	}
+
+	for i, date := range dates {
+		if got := date.Format("2006-01-02"); got != want[i] {
+			t.Errorf("Occurrence %d is %s, want %s", i, got, want[i])
+		}
+		if date.Hour() != 18 || date.Minute() != 30 {
+			t.Errorf("Occurrence %d starts at %s, want 18:30", i, date.Format("15:04"))
+		}
+	}
+}
+
+func TestOccurrenceDatesStopAtEarlierOfHorizonAndEnd(t *testing.T) {
+	start := time.Date(2027, time.January, 1, 9, 0, 0, 0, time.UTC)
+	end := start.AddDate(MaxRecurrenceYears, 0, 0)
+	event := &GroupEvent{EventDate: start, Recurrence: "daily", RecurrenceEnd: &end}
+
+	// A far-off recurrence end must not extend past the horizon
+	if dates := event.OccurrenceDates(start.AddDate(0, 0, 10)); len(dates) != 10 {
+		t.Errorf("Got %d occurrences before the horizon, want 10", len(dates))
+	}
+
+	// An earlier recurrence end still cuts the series short, inclusive of the end day
+	end = start.AddDate(0, 0, 2)
+	if dates := event.OccurrenceDates(start.AddDate(0, 0, 10)); len(dates) != 3 {
+		t.Errorf("Got %d occurrences before the recurrence end, want 3", len(dates))
+	}
+}
+
+func TestWeeklyOccurrencesKeepLocalTimeAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	// 20:00 in New York is already the next day in UTC
+	start := time.Date(2027, time.October, 28, 20, 0, 0, 0, newYork)
+	event := &GroupEvent{EventDate: start.UTC(), Recurrence: "weekly", Timezone: "America/New_York"}
+
+	dates := event.OccurrenceDates(start.AddDate(0, 0, 15))
+	if len(dates) != 3 {
+		t.Fatalf("Got %d occurrences, want 3", len(dates))
+	}
+
+	want := []string{"2027-10-28", "2027-11-04", "2027-11-11"}
+	for i, date := range dates {
+		local := date.In(newYork)
+		if local.Hour() != 20 {
+			t.Errorf("Occurrence %d starts at %s local time, want 20:00", i, local.Format("15:04"))
+		}
+		if got := event.localDay(date); got != want[i] {
+			t.Errorf("Occurrence %d is on %s, want %s", i, got, want[i])
+		}
+	}
+
+	if !event.OccursOn("2027-11-04") {
+		t.Error("Event should occur on its local day 2027-11-04")
+	}
+	if event.OccursOn("2027-11-05") {
+		t.Error("Event should not occur on the UTC day 2027-11-05")
+	}
+
+	occurrence := *event
+	occurrence.EventDate = dates[1]
+	if got := occurrence.occurrenceKey(); got != "2027-11-04" {
+		t.Errorf("Occurrence key is %s, want 2027-11-04", got)
+	}
+}
+
+func TestNextOccurrenceSkipsExceptions(t *testing.T) {
+	start := time.Date(2027, time.January, 1, 9, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 4)
+	event := &GroupEvent{EventDate: start, Recurrence: "daily", RecurrenceEnd: &end}
+	exceptions := map[string]bool{"2027-01-03": true}
+
+	// The first occurrence is in the past and the next one was removed
+	next, ok := event.NextOccurrence(start.AddDate(0, 0, 1).Add(time.Hour), exceptions)
+	if !ok || next.Format("2006-01-02") != "2027-01-04" {
+		t.Errorf("Next occurrence is %s, want 2027-01-04", next.Format("2006-01-02"))
+	}
+
+	// After the series ended the last remaining occurrence is used
+	exceptions["2027-01-05"] = true
+	last, ok := event.NextOccurrence(end.AddDate(0, 0, 1), exceptions)
+	if !ok || last.Format("2006-01-02") != "2027-01-04" {
+		t.Errorf("Last occurrence is %s, want 2027-01-04", last.Format("2006-01-02"))
+	}
+}
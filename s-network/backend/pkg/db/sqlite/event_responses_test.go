@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+// createTestEvent creates a group owned by the user with a single one-off event
+func createTestEvent(t *testing.T, db *DB, creatorID int64) int64 {
+	t.Helper()
+
+	groupID, err := db.CreateGroup(&Group{Name: "Event group", CreatorID: creatorID, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	eventID, err := db.CreateGroupEvent(&GroupEvent{
+		GroupID:   groupID,
+		CreatorID: creatorID,
+		Title:     "Meetup",
+		EventDate: time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event: %v", err)
+	}
+
+	return eventID
+}
+
+func TestRespondToEventRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	userID := int64(createTestUser(t, db, "responder"))
+	eventID := createTestEvent(t, db, userID)
+
+	tests := []struct {
+		response               string
+		going, notGoing, maybe int
+	}{
+		{"going", 1, 0, 0},
+		{"maybe", 0, 0, 1},
+		{"not_going", 0, 1, 0},
+		{"going", 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if err := db.RespondToEvent(eventID, userID, tt.response, ""); err != nil {
+			t.Fatalf("RespondToEvent(%q) returned error: %v", tt.response, err)
+		}
+
+		if got := db.GetUserEventResponse(eventID, userID, ""); got != tt.response {
+			t.Errorf("After responding %q, stored response is %q", tt.response, got)
+		}
+
+		going, notGoing, maybe := db.GetEventResponseCounts(eventID, "")
+		if going != tt.going || notGoing != tt.notGoing || maybe != tt.maybe {
+			t.Errorf("After responding %q, got counts %d/%d/%d, want %d/%d/%d",
+				tt.response, going, notGoing, maybe, tt.going, tt.notGoing, tt.maybe)
+		}
+	}
+
+	if err := db.RespondToEvent(eventID, userID, "remove", ""); err != nil {
+		t.Fatalf("RespondToEvent(remove) returned error: %v", err)
+	}
+	if got := db.GetUserEventResponse(eventID, userID, ""); got != "" {
+		t.Errorf("After removing, stored response is %q, want none", got)
+	}
+	if going, notGoing, maybe := db.GetEventResponseCounts(eventID, ""); going+notGoing+maybe != 0 {
+		t.Errorf("After removing, got counts %d/%d/%d, want none", going, notGoing, maybe)
+	}
+
+	// Removing again is a no-op
+	if err := db.RespondToEvent(eventID, userID, "remove", ""); err != nil {
+		t.Errorf("Removing a missing response returned error: %v", err)
+	}
+}
+
+func TestRespondToEventRejectsUnknownResponse(t *testing.T) {
+	db := newTestDB(t)
+	userID := int64(createTestUser(t, db, "responder"))
+	eventID := createTestEvent(t, db, userID)
+
+	if err := db.RespondToEvent(eventID, userID, "interested", ""); err == nil {
+		t.Fatal("RespondToEvent accepted an unknown response")
+	}
+
+	if got := db.GetUserEventResponse(eventID, userID, ""); got != "" {
+		t.Errorf("Unknown response stored as %q", got)
+	}
+}
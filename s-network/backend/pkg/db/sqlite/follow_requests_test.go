@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestAutoApproveFollowRequestsOnPublicTransition(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	if err := db.UpdateUser(owner, map[string]interface{}{"is_public": false}); err != nil {
+		t.Fatalf("Failed to make profile private: %v", err)
+	}
+
+	for _, requester := range []int{alice, bob} {
+		if _, err := db.CreateFollowRequest(int64(requester), int64(owner)); err != nil {
+			t.Fatalf("Failed to create follow request for user %d: %v", requester, err)
+		}
+	}
+
+	if err := db.UpdateUser(owner, map[string]interface{}{"is_public": true}); err != nil {
+		t.Fatalf("Failed to make profile public: %v", err)
+	}
+
+	if err := db.AutoApproveFollowRequests(int64(owner)); err != nil {
+		t.Fatalf("AutoApproveFollowRequests returned error: %v", err)
+	}
+
+	requests, err := db.GetUserFollowRequests(int64(owner))
+	if err != nil {
+		t.Fatalf("Failed to get follow requests: %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Got %d pending follow requests after approval, want 0", len(requests))
+	}
+
+	for _, requester := range []int{alice, bob} {
+		following, err := db.IsFollowing(requester, owner)
+		if err != nil {
+			t.Fatalf("Failed to check follow for user %d: %v", requester, err)
+		}
+		if !following {
+			t.Errorf("User %d is not following the owner after approval", requester)
+		}
+
+		notifications, err := db.GetNotifications(int64(requester), 10, 0, false, "follow_accepted")
+		if err != nil {
+			t.Fatalf("Failed to get notifications for user %d: %v", requester, err)
+		}
+		if len(notifications) != 1 {
+			t.Fatalf("Got %d follow_accepted notifications for user %d, want 1", len(notifications), requester)
+		}
+		if notifications[0].SenderID != int64(owner) {
+			t.Errorf("Notification sender is %d, want %d", notifications[0].SenderID, owner)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestDeleteGroupPostCommentRemovesReplyVotesAndReports(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+
+	groupID, err := db.CreateGroup(&Group{Name: "Group", CreatorID: owner, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	postID, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: owner, Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed to create group post: %v", err)
+	}
+
+	commentID, err := db.CreateGroupPostComment(&GroupPostComment{PostID: postID, AuthorID: owner, Content: "Comment"})
+	if err != nil {
+		t.Fatalf("Failed to create comment: %v", err)
+	}
+	replyID, err := db.CreateGroupPostComment(&GroupPostComment{PostID: postID, AuthorID: owner, Content: "Reply", ParentCommentID: &commentID})
+	if err != nil {
+		t.Fatalf("Failed to create reply: %v", err)
+	}
+
+	if err := db.Vote(int(owner), replyID, "group_post_comment", 1); err != nil {
+		t.Fatalf("Failed to vote on reply: %v", err)
+	}
+	if _, err := db.CreateReport(owner, replyID, "group_post_comment", "spam"); err != nil {
+		t.Fatalf("Failed to report reply: %v", err)
+	}
+
+	if err := db.DeleteGroupPostComment(commentID); err != nil {
+		t.Fatalf("DeleteGroupPostComment returned error: %v", err)
+	}
+
+	for _, table := range []string{"votes", "reports"} {
+		query := `SELECT COUNT(*) FROM ` + table + ` WHERE content_type = 'group_post_comment' AND content_id = ?`
+		if count := countRows(t, db, query, replyID); count != 0 {
+			t.Errorf("Got %d %s on the deleted reply, want 0", count, table)
+		}
+	}
+}
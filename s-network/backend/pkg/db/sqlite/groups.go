@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 // Group represents a group in the system
@@ -18,6 +22,12 @@ type Group struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// AutoApproveRequests adds users who request to join right away instead of leaving a pending request
+	AutoApproveRequests bool `json:"auto_approve_requests"`
+
+	// MaxMembers caps the number of members, nil means unlimited
+	MaxMembers *int `json:"max_members"`
+
 	// Additional fields for API responses
 	MemberCount    int    `json:"member_count,omitempty"`
 	IsJoined       bool   `json:"is_joined,omitempty"`
@@ -25,6 +35,13 @@ type Group struct {
 	HasJoinRequest bool   `json:"has_join_request,omitempty"`
 	UserRole       string `json:"user_role,omitempty"`
 	CreatorName    string `json:"creator_name,omitempty"`
+
+	// Activity stats, filled in by the group list queries
+	PostCount      int        `json:"post_count"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+
+	// FollowingMemberCount is how many people the user follows are members, set by GetGroupsFromFollowings
+	FollowingMemberCount int `json:"following_member_count,omitempty"`
 }
 
 // GroupMember represents a group member
@@ -53,9 +70,10 @@ type GroupInvitation struct {
 	UpdatedAt time.Time `json:"updated_at"`
 
 	// Additional fields for API responses
-	GroupName   string `json:"group_name,omitempty"`
-	InviterName string `json:"inviter_name,omitempty"`
-	InviteeName string `json:"invitee_name,omitempty"`
+	GroupName     string `json:"group_name,omitempty"`
+	InviterName   string `json:"inviter_name,omitempty"`
+	InviteeName   string `json:"invitee_name,omitempty"`
+	InviteeAvatar string `json:"invitee_avatar,omitempty"`
 }
 
 // GroupJoinRequest represents a request to join a group
@@ -76,23 +94,31 @@ type GroupJoinRequest struct {
 
 // GroupPost represents a post in a group
 type GroupPost struct {
-	ID            int64     `json:"id"`
-	GroupID       int64     `json:"group_id"`
-	AuthorID      int64     `json:"author_id"`
-	Content       string    `json:"content"`
-	ImagePath     string    `json:"image_path"`
-	LikesCount    int       `json:"likes_count"`
-	CommentsCount int       `json:"comments_count"`
-	Upvotes       int       `json:"upvotes"`
-	Downvotes     int       `json:"downvotes"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int64      `json:"id"`
+	GroupID       int64      `json:"group_id"`
+	AuthorID      int64      `json:"author_id"`
+	Content       string     `json:"content"`
+	ImagePath     string     `json:"image_path"`
+	LikesCount    int        `json:"likes_count"`
+	CommentsCount int        `json:"comments_count"`
+	Upvotes       int        `json:"upvotes"`
+	Downvotes     int        `json:"downvotes"`
+	IsPinned      bool       `json:"is_pinned"`
+	PinnedAt      *time.Time `json:"pinned_at,omitempty"`
+	IsDraft       bool       `json:"is_draft"`
+	EventID       *int64     `json:"event_id,omitempty"` // Set for posts in an event's discussion
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 
 	// Additional fields for API responses
-	AuthorName   string `json:"author_name,omitempty"`
-	AuthorAvatar string `json:"author_avatar,omitempty"`
-	IsLiked      bool   `json:"is_liked,omitempty"`
-	UserVote     int    `json:"user_vote,omitempty"` // 1 for upvote, -1 for downvote, 0 for no vote
+	AuthorName     string         `json:"author_name,omitempty"`
+	AuthorAvatar   string         `json:"author_avatar,omitempty"`
+	IsLiked        bool           `json:"is_liked,omitempty"`
+	UserVote       int            `json:"user_vote,omitempty"` // 1 for upvote, -1 for downvote, 0 for no vote
+	Reactions      map[string]int `json:"reactions,omitempty"`
+	UserReaction   string         `json:"user_reaction,omitempty"`
+	UnreadComments int            `json:"unread_comments"`
+	ThumbnailURL   string         `json:"thumbnail_url,omitempty"` // Stored as thumbnail_path
 }
 
 // GroupPostComment represents a comment on a group post
@@ -107,10 +133,14 @@ type GroupPostComment struct {
 	Downvotes int       `json:"downvotes"`
 	CreatedAt time.Time `json:"created_at"`
 
+	// ParentCommentID is the top-level comment this one replies to, nil for top-level comments
+	ParentCommentID *int64 `json:"parent_comment_id"`
+
 	// Additional fields for API responses
-	AuthorName   string `json:"author_name,omitempty"`
-	AuthorAvatar string `json:"author_avatar,omitempty"`
-	UserVote     int    `json:"user_vote,omitempty"` // 1 for upvote, -1 for downvote, 0 for no vote
+	AuthorName   string  `json:"author_name,omitempty"`
+	AuthorAvatar string  `json:"author_avatar,omitempty"`
+	UserVote     int     `json:"user_vote,omitempty"` // 1 for upvote, -1 for downvote, 0 for no vote
+	Mentions     []int64 `json:"mentions,omitempty"`
 }
 
 // GroupEvent represents an event in a group
@@ -124,29 +154,64 @@ type GroupEvent struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// Recurrence is one of none, daily, weekly or monthly
+	Recurrence    string     `json:"recurrence"`
+	RecurrenceEnd *time.Time `json:"recurrence_end,omitempty"`
+
+	// EventDate is stored in UTC, Timezone is the IANA zone it was created in
+	Location string `json:"location"`
+	Timezone string `json:"timezone"`
+
+	// IsFeatured marks the one event the group highlights
+	IsFeatured bool `json:"is_featured"`
+
+	// location caches the loaded Timezone, see zone
+	location *time.Location
+
 	// Additional fields for API responses
-	CreatorName   string `json:"creator_name,omitempty"`
-	GoingCount    int    `json:"going_count,omitempty"`
-	NotGoingCount int    `json:"not_going_count,omitempty"`
-	UserResponse  string `json:"user_response,omitempty"`
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+	CreatorName    string `json:"creator_name,omitempty"`
+	GoingCount     int    `json:"going_count,omitempty"`
+	NotGoingCount  int    `json:"not_going_count,omitempty"`
+	MaybeCount     int    `json:"maybe_count,omitempty"`
+	UserResponse   string `json:"user_response,omitempty"`
+	GroupName      string `json:"group_name,omitempty"`
 }
 
 // GroupEventResponse represents a user's response to an event
 type GroupEventResponse struct {
-	ID        int64     `json:"id"`
-	EventID   int64     `json:"event_id"`
-	UserID    int64     `json:"user_id"`
-	Response  string    `json:"response"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             int64     `json:"id"`
+	EventID        int64     `json:"event_id"`
+	UserID         int64     `json:"user_id"`
+	OccurrenceDate string    `json:"occurrence_date,omitempty"`
+	Response       string    `json:"response"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// User details for API responses
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Avatar    string `json:"avatar,omitempty"`
+}
+
+// GroupActivity is a single entry in a group's activity feed
+type GroupActivity struct {
+	ActivityType string    `json:"activity_type"` // "post", "event" or "member_joined"
+	ID           int64     `json:"id"`            // post ID, event ID or the joined user's ID
+	ActorID      int64     `json:"actor_id"`
+	ActorName    string    `json:"actor_name"`
+	ActorAvatar  string    `json:"actor_avatar,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Content      string    `json:"content,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // CreateGroup creates a new group
 func (db *DB) CreateGroup(group *Group) (int64, error) {
-	query := `INSERT INTO groups (name, description, creator_id, avatar, privacy) 
-	          VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT INTO groups (name, description, creator_id, avatar, privacy, max_members) 
+	          VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := db.Exec(query, group.Name, group.Description, group.CreatorID, group.Avatar, group.Privacy)
+	result, err := db.Exec(query, group.Name, group.Description, group.CreatorID, group.Avatar, group.Privacy, group.MaxMembers)
 	if err != nil {
 		return 0, err
 	}
@@ -168,13 +233,16 @@ func (db *DB) CreateGroup(group *Group) (int64, error) {
 
 // GetGroup retrieves a group by ID
 func (db *DB) GetGroup(id int64) (*Group, error) {
-	query := `SELECT id, name, description, creator_id, avatar, privacy, created_at, updated_at 
+	query := `SELECT id, name, description, creator_id, avatar, privacy, created_at, updated_at,
+	                 COALESCE(auto_approve_requests, 0), max_members
 	          FROM groups WHERE id = ?`
 
 	var group Group
+	var maxMembers sql.NullInt64
 	err := db.QueryRow(query, id).Scan(
 		&group.ID, &group.Name, &group.Description, &group.CreatorID,
 		&group.Avatar, &group.Privacy, &group.CreatedAt, &group.UpdatedAt,
+		&group.AutoApproveRequests, &maxMembers,
 	)
 
 	if err != nil {
@@ -184,6 +252,11 @@ func (db *DB) GetGroup(id int64) (*Group, error) {
 		return nil, err
 	}
 
+	if maxMembers.Valid {
+		limit := int(maxMembers.Int64)
+		group.MaxMembers = &limit
+	}
+
 	return &group, nil
 }
 
@@ -192,7 +265,8 @@ func (db *DB) GetGroups(limit, offset int, userID *int64) ([]*Group, error) {
 	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
 	                 g.created_at, g.updated_at,
 	                 COUNT(gm.user_id) as member_count,
-	                 u.first_name || ' ' || u.last_name as creator_name
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 ` + groupActivityColumns + `
 	          FROM groups g
 	          LEFT JOIN group_members gm ON g.id = gm.group_id
 	          LEFT JOIN users u ON g.creator_id = u.id
@@ -213,14 +287,118 @@ func (db *DB) GetGroups(limit, offset int, userID *int64) ([]*Group, error) {
 	}
 	defer rows.Close()
 
+	return db.scanGroupList(rows, userID)
+}
+
+// PopularGroupsWindow is how far back member joins count towards a group's popularity
+const PopularGroupsWindow = "-30 days"
+
+// GetPopularGroups retrieves public groups ordered by how many members joined within
+// PopularGroupsWindow, then by total member count. Groups the user already belongs to
+// are excluded when userID is provided
+func (db *DB) GetPopularGroups(limit, offset int, userID *int64) ([]*Group, error) {
+	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
+	                 g.created_at, g.updated_at,
+	                 COUNT(gm.user_id) as member_count,
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 ` + groupActivityColumns + `
+	          FROM groups g
+	          LEFT JOIN group_members gm ON g.id = gm.group_id
+	          LEFT JOIN users u ON g.creator_id = u.id
+	          WHERE g.privacy = 'public'
+	          AND NOT EXISTS (SELECT 1 FROM group_members WHERE group_id = g.id AND user_id = ?)
+	          GROUP BY g.id
+	          ORDER BY SUM(CASE WHEN gm.joined_at >= datetime('now', ?) THEN 1 ELSE 0 END) DESC,
+	                   member_count DESC, g.created_at DESC
+	          LIMIT ? OFFSET ?`
+
+	var queryUserID int64 = -1
+	if userID != nil {
+		queryUserID = *userID
+	}
+
+	rows, err := db.Query(query, queryUserID, PopularGroupsWindow, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular groups: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanGroupList(rows, userID)
+}
+
+// likePattern builds a lowercase "contains" pattern for LIKE ... ESCAPE '\',
+// escaping wildcards in the query so they match literally
+func likePattern(query string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + escaper.Replace(strings.ToLower(query)) + "%"
+}
+
+// SearchGroups finds groups visible to the user whose name or description
+// contains the query (case-insensitive)
+func (db *DB) SearchGroups(query string, limit, offset int, userID *int64) ([]*Group, error) {
+	sqlQuery := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
+	                    g.created_at, g.updated_at,
+	                    COUNT(gm.user_id) as member_count,
+	                    u.first_name || ' ' || u.last_name as creator_name,
+	                    ` + groupActivityColumns + `
+	             FROM groups g
+	             LEFT JOIN group_members gm ON g.id = gm.group_id
+	             LEFT JOIN users u ON g.creator_id = u.id
+	             WHERE (g.privacy = 'public' OR g.creator_id = ? OR 
+	                    EXISTS(SELECT 1 FROM group_members WHERE group_id = g.id AND user_id = ?))
+	               AND (LOWER(g.name) LIKE ? ESCAPE '\' OR LOWER(COALESCE(g.description, '')) LIKE ? ESCAPE '\')
+	             GROUP BY g.id
+	             ORDER BY g.created_at DESC
+	             LIMIT ? OFFSET ?`
+
+	var queryUserID int64 = -1
+	if userID != nil {
+		queryUserID = *userID
+	}
+
+	pattern := likePattern(query)
+
+	rows, err := db.Query(sqlQuery, queryUserID, queryUserID, pattern, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return db.scanGroupList(rows, userID)
+}
+
+// groupActivityColumns selects a group's post count and the time of its latest post,
+// event or chat message. Queries scanned by scanGroupList end with these columns
+const groupActivityColumns = `(SELECT COUNT(*) FROM group_posts WHERE group_id = g.id AND COALESCE(is_draft, 0) = 0 AND COALESCE(is_deleted, 0) = 0) as post_count,
+	                 NULLIF(MAX(
+	                     COALESCE((SELECT MAX(created_at) FROM group_posts WHERE group_id = g.id AND COALESCE(is_draft, 0) = 0 AND COALESCE(is_deleted, 0) = 0), ''),
+	                     COALESCE((SELECT MAX(created_at) FROM group_messages WHERE group_id = g.id), ''),
+	                     COALESCE((SELECT MAX(created_at) FROM group_events WHERE group_id = g.id), '')
+	                 ), '') as last_activity_at`
+
+// parseSQLiteTime parses a timestamp computed in SQL, where the driver can't
+// convert it to time.Time because the value has no declared column type
+func parseSQLiteTime(value string) (time.Time, bool) {
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// scanGroupList scans group rows with member counts and fills in the user's
+// relationship with each group when userID is provided
+func (db *DB) scanGroupList(rows *sql.Rows, userID *int64) ([]*Group, error) {
 	var groups []*Group
 	for rows.Next() {
 		var group Group
-		var creatorName sql.NullString
+		var creatorName, lastActivityAt sql.NullString
 		if err := rows.Scan(
 			&group.ID, &group.Name, &group.Description, &group.CreatorID,
 			&group.Avatar, &group.Privacy, &group.CreatedAt, &group.UpdatedAt,
-			&group.MemberCount, &creatorName,
+			&group.MemberCount, &creatorName, &group.PostCount, &lastActivityAt,
 		); err != nil {
 			return nil, err
 		}
@@ -230,6 +408,10 @@ func (db *DB) GetGroups(limit, offset int, userID *int64) ([]*Group, error) {
 			group.CreatorName = creatorName.String
 		}
 
+		if lastActivity, ok := parseSQLiteTime(lastActivityAt.String); ok {
+			group.LastActivityAt = &lastActivity
+		}
+
 		// Check user's relationship with this group if userID provided
 		if userID != nil {
 			group.IsJoined = db.IsGroupMember(group.ID, *userID)
@@ -244,6 +426,14 @@ func (db *DB) GetGroups(limit, offset int, userID *int64) ([]*Group, error) {
 	return groups, rows.Err()
 }
 
+// CreatorHasGroupNamed checks if a user already created a group with the given name
+func (db *DB) CreatorHasGroupNamed(creatorID int64, name string) bool {
+	var count int
+	query := `SELECT COUNT(*) FROM groups WHERE creator_id = ? AND name = ?`
+	db.QueryRow(query, creatorID, name).Scan(&count)
+	return count > 0
+}
+
 // IsGroupMember checks if a user is a member of a group
 func (db *DB) IsGroupMember(groupID, userID int64) bool {
 	var count int
@@ -260,6 +450,35 @@ func (db *DB) GetUserRoleInGroup(groupID, userID int64) string {
 	return role
 }
 
+// IsGroupAdmin checks if a user is an admin of a group
+func (db *DB) IsGroupAdmin(groupID, userID int64) bool {
+	return db.GetUserRoleInGroup(groupID, userID) == "admin"
+}
+
+// UpdateGroupMemberRole changes a member's role in a group
+func (db *DB) UpdateGroupMemberRole(groupID, userID int64, role string) error {
+	if role != "admin" && role != "member" {
+		return fmt.Errorf("invalid role: %s", role)
+	}
+
+	query := `UPDATE group_members SET role = ? WHERE group_id = ? AND user_id = ?`
+	result, err := db.Exec(query, role, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update member role: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
 // AddGroupMember adds a user to a group
 func (db *DB) AddGroupMember(groupID, userID int64, role string) error {
 	query := `INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)`
@@ -274,6 +493,143 @@ func (db *DB) RemoveGroupMember(groupID, userID int64) error {
 	return err
 }
 
+// LeaveAllGroups removes a user from every group they didn't create, along with the
+// groups' chats. Groups the user created are left untouched since they need a new
+// owner or to be deleted; their IDs are returned as owned so the caller can say so
+func (db *DB) LeaveAllGroups(userID int64) (left []int64, owned []int64, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT g.id, g.creator_id = ? FROM group_members gm
+	                       JOIN groups g ON g.id = gm.group_id
+	                       WHERE gm.user_id = ?`, userID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user groups: %v", err)
+	}
+	for rows.Next() {
+		var groupID int64
+		var isCreator bool
+		if err := rows.Scan(&groupID, &isCreator); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan group: %v", err)
+		}
+		if isCreator {
+			owned = append(owned, groupID)
+		} else {
+			left = append(left, groupID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to get user groups: %v", err)
+	}
+
+	for _, groupID := range left {
+		if _, err := tx.Exec(`DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID); err != nil {
+			return nil, nil, fmt.Errorf("failed to leave group %d: %v", groupID, err)
+		}
+		_, err := tx.Exec(`DELETE FROM chat_participants WHERE user_id = ?
+		                   AND conversation_id IN (SELECT id FROM chat_conversations WHERE group_id = ?)`, userID, groupID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to leave chat of group %d: %v", groupID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return left, owned, nil
+}
+
+// BanGroupMember removes a user from a group and records a ban so they can't rejoin.
+// Pending invitations and join requests for the user are rejected as well
+func (db *DB) BanGroupMember(groupID, userID, bannedBy int64, reason string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO group_bans (group_id, user_id, banned_by, reason) VALUES (?, ?, ?, ?)
+	                  ON CONFLICT(group_id, user_id) DO UPDATE SET banned_by = excluded.banned_by, reason = excluded.reason`,
+		groupID, userID, bannedBy, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record ban: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %v", err)
+	}
+
+	_, err = tx.Exec(`UPDATE group_invitations SET status = 'rejected', updated_at = CURRENT_TIMESTAMP
+	                  WHERE group_id = ? AND invitee_id = ? AND status = 'pending'`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reject pending invitations: %v", err)
+	}
+
+	_, err = tx.Exec(`UPDATE group_join_requests SET status = 'rejected', updated_at = CURRENT_TIMESTAMP
+	                  WHERE group_id = ? AND user_id = ? AND status = 'pending'`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reject pending join requests: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// UnbanGroupMember lifts a ban so the user can join the group again
+func (db *DB) UnbanGroupMember(groupID, userID int64) error {
+	result, err := db.Exec(`DELETE FROM group_bans WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lift ban: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("ban not found")
+	}
+
+	return nil
+}
+
+// IsBannedFromGroup checks if a user is banned from a group
+func (db *DB) IsBannedFromGroup(groupID, userID int64) bool {
+	var count int
+	query := `SELECT COUNT(*) FROM group_bans WHERE group_id = ? AND user_id = ?`
+	db.QueryRow(query, groupID, userID).Scan(&count)
+	return count > 0
+}
+
+// SetGroupMuted stores whether a member has muted notifications from a group
+func (db *DB) SetGroupMuted(groupID, userID int64, muted bool) error {
+	query := `INSERT INTO group_member_settings (group_id, user_id, muted, updated_at) 
+	          VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	          ON CONFLICT(group_id, user_id) DO UPDATE SET muted = excluded.muted, updated_at = CURRENT_TIMESTAMP`
+
+	_, err := db.Exec(query, groupID, userID, muted)
+	if err != nil {
+		return fmt.Errorf("failed to update group notification settings: %v", err)
+	}
+
+	return nil
+}
+
+// IsGroupMuted checks if a member has muted notifications from a group
+func (db *DB) IsGroupMuted(groupID, userID int64) bool {
+	var muted bool
+	query := `SELECT COALESCE(muted, 0) FROM group_member_settings WHERE group_id = ? AND user_id = ?`
+	db.QueryRow(query, groupID, userID).Scan(&muted)
+	return muted
+}
+
 // GetGroupMembers retrieves all members of a group
 func (db *DB) GetGroupMembers(groupID int64) ([]*GroupMember, error) {
 	query := `SELECT gm.group_id, gm.user_id, gm.role, gm.joined_at,
@@ -305,6 +661,79 @@ func (db *DB) GetGroupMembers(groupID int64) ([]*GroupMember, error) {
 	return members, rows.Err()
 }
 
+// GetGroupMembersByRole retrieves the confirmed members of a group with the given role, creator first
+func (db *DB) GetGroupMembersByRole(groupID int64, role string) ([]*GroupMember, error) {
+	query := `SELECT gm.group_id, gm.user_id, gm.role, gm.joined_at,
+	                 u.first_name, u.last_name, u.avatar, u.email
+	          FROM group_members gm
+	          JOIN users u ON gm.user_id = u.id
+	          JOIN groups g ON gm.group_id = g.id
+	          WHERE gm.group_id = ? AND gm.role = ?
+	          ORDER BY 
+	            CASE WHEN gm.user_id = g.creator_id THEN 0 ELSE 1 END,
+	            gm.joined_at ASC`
+
+	rows, err := db.Query(query, groupID, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*GroupMember{}
+	for rows.Next() {
+		var member GroupMember
+		if err := rows.Scan(
+			&member.GroupID, &member.UserID, &member.Role, &member.JoinedAt,
+			&member.FirstName, &member.LastName, &member.Avatar, &member.Email,
+		); err != nil {
+			return nil, err
+		}
+		member.Status = "member"
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+// SearchGroupMembers finds confirmed members of a group whose first name, last name
+// or nickname contains the query (case-insensitive)
+func (db *DB) SearchGroupMembers(groupID int64, query string) ([]*GroupMember, error) {
+	sqlQuery := `SELECT gm.group_id, gm.user_id, gm.role, gm.joined_at,
+	                    u.first_name, u.last_name, u.avatar, u.email
+	             FROM group_members gm
+	             JOIN users u ON gm.user_id = u.id
+	             JOIN groups g ON gm.group_id = g.id
+	             WHERE gm.group_id = ?
+	               AND (LOWER(u.first_name) LIKE ? ESCAPE '\'
+	                    OR LOWER(u.last_name) LIKE ? ESCAPE '\'
+	                    OR LOWER(COALESCE(u.nickname, '')) LIKE ? ESCAPE '\')
+	             ORDER BY 
+	               CASE WHEN gm.user_id = g.creator_id THEN 0 ELSE 1 END,
+	               u.first_name, u.last_name`
+
+	pattern := likePattern(query)
+	rows, err := db.Query(sqlQuery, groupID, pattern, pattern, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search group members: %v", err)
+	}
+	defer rows.Close()
+
+	members := []*GroupMember{}
+	for rows.Next() {
+		var member GroupMember
+		if err := rows.Scan(
+			&member.GroupID, &member.UserID, &member.Role, &member.JoinedAt,
+			&member.FirstName, &member.LastName, &member.Avatar, &member.Email,
+		); err != nil {
+			return nil, err
+		}
+		member.Status = "member"
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
 // GetGroupMembersWithPending retrieves all members and pending invitations for a group
 func (db *DB) GetGroupMembersWithPending(groupID int64) ([]*GroupMember, error) {
 	// Get confirmed members with creator first
@@ -367,81 +796,256 @@ func (db *DB) GetGroupMembersWithPending(groupID int64) ([]*GroupMember, error)
 	return members, nil
 }
 
-// UpdateGroup updates an existing group
+// UpdateGroup updates an existing group and keeps its chat conversation name in sync
 func (db *DB) UpdateGroup(group *Group) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
 	query := `UPDATE groups 
-	          SET name = ?, description = ?, avatar = ?, privacy = ?, updated_at = CURRENT_TIMESTAMP 
+	          SET name = ?, description = ?, avatar = ?, privacy = ?, auto_approve_requests = ?, max_members = ?, updated_at = CURRENT_TIMESTAMP 
 	          WHERE id = ?`
 
-	_, err := db.Exec(query, group.Name, group.Description, group.Avatar, group.Privacy, group.ID)
-	return err
+	_, err = tx.Exec(query, group.Name, group.Description, group.Avatar, group.Privacy, group.AutoApproveRequests,
+		group.MaxMembers, group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update group: %v", err)
+	}
+
+	// Rename the linked group chat
+	_, err = tx.Exec(`UPDATE chat_conversations SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE group_id = ?`,
+		group.Name+" Chat", group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update group conversation: %v", err)
+	}
+
+	return tx.Commit()
 }
 
-// DeleteGroup removes a group from the database
-func (db *DB) DeleteGroup(id int64) error {
-	log.Printf("🗑️ Starting deletion of group %d", id)
-	
-	// Start a transaction to ensure all deletions happen atomically
-	tx, err := db.Begin()
+// UpdateGroupAvatar replaces only the avatar of a group
+func (db *DB) UpdateGroupAvatar(groupID int64, avatar string) error {
+	result, err := db.Exec(`UPDATE groups SET avatar = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, avatar, groupID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to update group avatar: %v", err)
 	}
-	defer tx.Rollback()
 
-	// Temporarily disable foreign keys to avoid constraint issues
-	_, err = tx.Exec("PRAGMA foreign_keys = OFF")
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to disable foreign keys: %v", err)
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("group not found")
 	}
 
-	// Delete in the order that avoids most foreign key issues
-	deletions := []struct {
-		query string
-		desc  string
-	}{
-		// 1. Delete notifications related to this group
-		{"DELETE FROM notifications WHERE type = 'group_invitation' AND reference_id = ?", "group notifications"},
-		
-		// 2. Delete group post comment votes (if table exists)
-		{"DELETE FROM group_post_comment_votes WHERE comment_id IN (SELECT id FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?))", "group post comment votes"},
-		
-		// 3. Delete group post comments
-		{"DELETE FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?)", "group post comments"},
-		
-		// 4. Delete group post likes/votes
-		{"DELETE FROM group_post_likes WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?)", "group post likes"},
-		
-		// 5. Delete group posts
+	return nil
+}
+
+// AutoApproveJoinRequests accepts all pending join requests for a group
+// This is used when a group changes from private to public or turns on auto-approval
+func (db *DB) AutoApproveJoinRequests(groupID int64) ([]int64, error) {
+	requests, err := db.GetGroupJoinRequests(groupID, "pending")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join requests: %v", err)
+	}
+
+	// Leave requests pending once the group reaches its member limit
+	var maxMembers sql.NullInt64
+	var memberCount int
+	err = db.QueryRow(`SELECT max_members, (SELECT COUNT(*) FROM group_members WHERE group_id = ?1) FROM groups WHERE id = ?1`,
+		groupID).Scan(&maxMembers, &memberCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group capacity: %v", err)
+	}
+	if maxMembers.Valid {
+		remaining := int(maxMembers.Int64) - memberCount
+		if remaining < 0 {
+			remaining = 0
+		}
+		// Requests are newest first, the oldest ones are approved first
+		if len(requests) > remaining {
+			requests = requests[len(requests)-remaining:]
+		}
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var approvedUserIDs []int64
+	for _, request := range requests {
+		_, err = tx.Exec(`UPDATE group_join_requests SET status = 'accepted', updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			request.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to accept join request: %v", err)
+		}
+
+		// Skip users who somehow became members already
+		_, err = tx.Exec(`INSERT OR IGNORE INTO group_members (group_id, user_id, role) VALUES (?, ?, 'member')`,
+			groupID, request.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add group member: %v", err)
+		}
+
+		approvedUserIDs = append(approvedUserIDs, request.UserID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return approvedUserIDs, nil
+}
+
+// TransferGroupOwnership makes newOwnerID the creator of a group and swaps the admin role
+func (db *DB) TransferGroupOwnership(groupID, oldOwnerID, newOwnerID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Update the group's creator
+	result, err := tx.Exec(`UPDATE groups SET creator_id = ?, updated_at = CURRENT_TIMESTAMP
+	                        WHERE id = ? AND creator_id = ?`, newOwnerID, groupID, oldOwnerID)
+	if err != nil {
+		return fmt.Errorf("failed to update group creator: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("group not found")
+	}
+
+	// Promote the new owner to admin
+	_, err = tx.Exec(`UPDATE group_members SET role = 'admin' WHERE group_id = ? AND user_id = ?`,
+		groupID, newOwnerID)
+	if err != nil {
+		return fmt.Errorf("failed to promote new owner: %v", err)
+	}
+
+	// Demote the previous owner to a regular member
+	_, err = tx.Exec(`UPDATE group_members SET role = 'member' WHERE group_id = ? AND user_id = ?`,
+		groupID, oldOwnerID)
+	if err != nil {
+		return fmt.Errorf("failed to demote previous owner: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteGroup removes a group from the database
+func (db *DB) DeleteGroup(id int64) error {
+	log.Printf("🗑️ Starting deletion of group %d", id)
+	
+	// Start a transaction to ensure all deletions happen atomically
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Temporarily disable foreign keys to avoid constraint issues
+	_, err = tx.Exec("PRAGMA foreign_keys = OFF")
+	if err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %v", err)
+	}
+
+	if err := deleteGroupTx(tx, id); err != nil {
+		return err
+	}
+
+	// Re-enable foreign keys
+	_, err = tx.Exec("PRAGMA foreign_keys = ON")
+	if err != nil {
+		log.Printf("⚠️ Warning: Failed to re-enable foreign keys: %v", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	log.Printf("✅ Successfully deleted group %d", id)
+	return nil
+}
+
+// deleteGroupTx removes a group and everything that belongs to it within an existing transaction
+func deleteGroupTx(tx *sql.Tx, id int64) error {
+	// Delete in the order that avoids most foreign key issues
+	deletions := []struct {
+		query string
+		desc  string
+	}{
+		// 1. Delete notifications related to this group
+		{"DELETE FROM notifications WHERE type = 'group_invitation' AND reference_id = ?", "group notifications"},
+		
+		// 2. Delete reports on group posts and comments
+		{"DELETE FROM reports WHERE (content_type = 'group_post' AND content_id IN (SELECT id FROM group_posts WHERE group_id = ?1)) OR (content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?1)))", "content reports"},
+		
+		// 3. Delete votes on group posts and their comments
+		{"DELETE FROM votes WHERE (content_type = 'group_post' AND content_id IN (SELECT id FROM group_posts WHERE group_id = ?1)) OR (content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?1)))", "group post votes"},
+		
+		// 4. Delete group post read markers
+		{"DELETE FROM group_post_reads WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?)", "group post read markers"},
+		
+		// 5. Delete group post comments
+		{"DELETE FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?)", "group post comments"},
+		
+		// 6. Delete group post likes/votes
+		{"DELETE FROM group_post_likes WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?)", "group post likes"},
+		
+		// 7. Delete group posts
 		{"DELETE FROM group_posts WHERE group_id = ?", "group posts"},
 		
-		// 6. Delete group event responses
+		// 8. Delete group event responses
 		{"DELETE FROM group_event_responses WHERE event_id IN (SELECT id FROM group_events WHERE group_id = ?)", "group event responses"},
 		
-		// 7. Delete group events
+		// 9. Delete skipped occurrences of recurring group events
+		{"DELETE FROM group_event_exceptions WHERE event_id IN (SELECT id FROM group_events WHERE group_id = ?)", "group event exceptions"},
+		
+		// 10. Delete group events
 		{"DELETE FROM group_events WHERE group_id = ?", "group events"},
 		
-		// 8. Delete group message attachments (if table exists)
+		// 11. Delete group message attachments
 		{"DELETE FROM group_message_attachments WHERE message_id IN (SELECT id FROM group_messages WHERE group_id = ?)", "group message attachments"},
 		
-		// 9. Delete group messages
+		// 12. Delete group messages
 		{"DELETE FROM group_messages WHERE group_id = ?", "group messages"},
 		
-		// 10. Delete chat messages in group conversations
+		// 13. Delete chat messages in group conversations
 		{"DELETE FROM chat_messages WHERE conversation_id IN (SELECT id FROM chat_conversations WHERE group_id = ?)", "chat messages"},
 		
-		// 11. Delete chat participants for this group
+		// 14. Delete chat participants for this group
 		{"DELETE FROM chat_participants WHERE conversation_id IN (SELECT id FROM chat_conversations WHERE group_id = ?)", "chat participants"},
 		
-		// 12. Delete group conversations
+		// 15. Delete group conversations
 		{"DELETE FROM chat_conversations WHERE group_id = ?", "group conversations"},
 		
-		// 13. Delete group invitations
+		// 16. Delete group invitations
 		{"DELETE FROM group_invitations WHERE group_id = ?", "group invitations"},
 		
-		// 14. Delete group join requests
+		// 17. Delete group join requests
 		{"DELETE FROM group_join_requests WHERE group_id = ?", "group join requests"},
 		
-		// 15. Delete group members
+		// 18. Delete group member settings
+		{"DELETE FROM group_member_settings WHERE group_id = ?", "group member settings"},
+		
+		// 19. Delete group bans
+		{"DELETE FROM group_bans WHERE group_id = ?", "group bans"},
+		
+		// 20. Delete group members
 		{"DELETE FROM group_members WHERE group_id = ?", "group members"},
 	}
 
@@ -449,12 +1053,10 @@ func (db *DB) DeleteGroup(id int64) error {
 	for _, deletion := range deletions {
 		result, err := tx.Exec(deletion.query, id)
 		if err != nil {
-			log.Printf("⚠️ Warning: Error deleting %s for group %d: %v", deletion.desc, id, err)
-			// Continue with other deletions even if some fail
-		} else {
-			if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
-				log.Printf("✅ Deleted %d rows from %s", rowsAffected, deletion.desc)
-			}
+			return fmt.Errorf("failed to delete %s for group %d: %v", deletion.desc, id, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+			log.Printf("✅ Deleted %d rows from %s", rowsAffected, deletion.desc)
 		}
 	}
 
@@ -474,30 +1076,41 @@ func (db *DB) DeleteGroup(id int64) error {
 		return fmt.Errorf("group with ID %d not found", id)
 	}
 
-	// Re-enable foreign keys
-	_, err = tx.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
-		log.Printf("⚠️ Warning: Failed to re-enable foreign keys: %v", err)
-	}
+	return nil
+}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+// GetUserGroups retrieves the groups a user is a member of, most recently active first.
+// Activity is the latest post, chat message or event in the group
+func (db *DB) GetUserGroups(userID int64, limit, offset int) ([]*Group, error) {
+	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
+	                 g.created_at, g.updated_at,
+	                 (SELECT COUNT(*) FROM group_members WHERE group_id = g.id) as member_count,
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 ` + groupActivityColumns + `
+	          FROM groups g
+	          JOIN group_members gm ON g.id = gm.group_id AND gm.user_id = ?
+	          LEFT JOIN users u ON g.creator_id = u.id
+	          ORDER BY MAX(COALESCE(last_activity_at, ''), g.created_at) DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	log.Printf("✅ Successfully deleted group %d", id)
-	return nil
+	return db.scanGroupList(rows, &userID)
 }
 
-// GetUserGroups retrieves all groups a user is a member of
-func (db *DB) GetUserGroups(userID int64) ([]*Group, error) {
-	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, g.created_at, g.updated_at 
-	          FROM groups g
-	          JOIN group_members gm ON g.id = gm.group_id
-	          WHERE gm.user_id = ?
-	          ORDER BY g.name`
+// GetPublicGroups retrieves all public groups
+func (db *DB) GetPublicGroups(limit, offset int) ([]*Group, error) {
+	query := `SELECT id, name, description, creator_id, avatar, privacy, created_at, updated_at 
+	          FROM groups 
+	          WHERE privacy = 'public' 
+	          ORDER BY name 
+	          LIMIT ? OFFSET ?`
 
-	rows, err := db.Query(query, userID)
+	rows, err := db.Query(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -528,43 +1141,86 @@ func (db *DB) GetUserGroups(userID int64) ([]*Group, error) {
 	return groups, nil
 }
 
-// GetPublicGroups retrieves all public groups
-func (db *DB) GetPublicGroups(limit, offset int) ([]*Group, error) {
-	query := `SELECT id, name, description, creator_id, avatar, privacy, created_at, updated_at 
-	          FROM groups 
-	          WHERE privacy = 'public' 
-	          ORDER BY name 
-	          LIMIT ? OFFSET ?`
-
-	rows, err := db.Query(query, limit, offset)
+// GetSuggestedGroups returns public groups the user hasn't joined or requested to join.
+// Groups with more of the user's followings as members come first, then larger groups
+func (db *DB) GetSuggestedGroups(userID int64, limit int) ([]*Group, error) {
+	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
+	                 g.created_at, g.updated_at,
+	                 (SELECT COUNT(*) FROM group_members WHERE group_id = g.id) as member_count,
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 ` + groupActivityColumns + `
+	          FROM groups g
+	          LEFT JOIN users u ON g.creator_id = u.id
+	          WHERE g.privacy = 'public'
+	          AND NOT EXISTS (SELECT 1 FROM group_members WHERE group_id = g.id AND user_id = ?1)
+	          AND NOT EXISTS (SELECT 1 FROM group_join_requests WHERE group_id = g.id AND user_id = ?1 AND status = 'pending')
+	          ORDER BY (SELECT COUNT(*) FROM group_members gm
+	                    JOIN followers f ON f.following_id = gm.user_id AND f.follower_id = ?1
+	                    WHERE gm.group_id = g.id) DESC,
+	                   member_count DESC, g.created_at DESC
+	          LIMIT ?2`
+
+	rows, err := db.Query(query, userID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var groups []*Group
+	return db.scanGroupList(rows, &userID)
+}
+
+// GetGroupsFromFollowings returns public groups that at least one person the user follows
+// is a member of, excluding groups the user joined or has a pending request for.
+// Groups with the most followings come first
+func (db *DB) GetGroupsFromFollowings(userID int64, limit, offset int) ([]*Group, error) {
+	query := `SELECT g.id, g.name, g.description, g.creator_id, g.avatar, g.privacy, 
+	                 g.created_at, g.updated_at,
+	                 (SELECT COUNT(*) FROM group_members WHERE group_id = g.id) as member_count,
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 ` + groupActivityColumns + `,
+	                 COUNT(gm.user_id) as following_member_count
+	          FROM groups g
+	          JOIN group_members gm ON gm.group_id = g.id
+	          JOIN followers f ON f.following_id = gm.user_id AND f.follower_id = ?1
+	          LEFT JOIN users u ON g.creator_id = u.id
+	          WHERE g.privacy = 'public'
+	          AND NOT EXISTS (SELECT 1 FROM group_members WHERE group_id = g.id AND user_id = ?1)
+	          AND NOT EXISTS (SELECT 1 FROM group_join_requests WHERE group_id = g.id AND user_id = ?1 AND status = 'pending')
+	          GROUP BY g.id
+	          ORDER BY following_member_count DESC, member_count DESC, g.created_at DESC
+	          LIMIT ?2 OFFSET ?3`
+
+	rows, err := db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups from followings: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []*Group{}
 	for rows.Next() {
 		var group Group
+		var creatorName, lastActivityAt sql.NullString
 		if err := rows.Scan(
-			&group.ID,
-			&group.Name,
-			&group.Description,
-			&group.CreatorID,
-			&group.Avatar,
-			&group.Privacy,
-			&group.CreatedAt,
-			&group.UpdatedAt,
+			&group.ID, &group.Name, &group.Description, &group.CreatorID,
+			&group.Avatar, &group.Privacy, &group.CreatedAt, &group.UpdatedAt,
+			&group.MemberCount, &creatorName, &group.PostCount, &lastActivityAt,
+			&group.FollowingMemberCount,
 		); err != nil {
 			return nil, err
 		}
-		groups = append(groups, &group)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+		if creatorName.Valid {
+			group.CreatorName = creatorName.String
+		}
+
+		if lastActivity, ok := parseSQLiteTime(lastActivityAt.String); ok {
+			group.LastActivityAt = &lastActivity
+		}
+
+		groups = append(groups, &group)
 	}
 
-	return groups, nil
+	return groups, rows.Err()
 }
 
 // HasPendingInvitation checks if a user has a pending invitation to a group
@@ -606,8 +1262,47 @@ func (db *DB) UpdateInvitationStatus(invitationID int64, status string) error {
 	return err
 }
 
-// GetUserInvitations retrieves all invitations for a user
-func (db *DB) GetUserInvitations(userID int64, status string) ([]*GroupInvitation, error) {
+// GetGroupInvitation retrieves a group invitation by ID
+func (db *DB) GetGroupInvitation(invitationID int64) (*GroupInvitation, error) {
+	query := `SELECT id, group_id, inviter_id, invitee_id, status, created_at 
+	          FROM group_invitations WHERE id = ?`
+
+	var inv GroupInvitation
+	err := db.QueryRow(query, invitationID).Scan(
+		&inv.ID, &inv.GroupID, &inv.InviterID, &inv.InviteeID, &inv.Status, &inv.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	inv.UpdatedAt = inv.CreatedAt
+
+	return &inv, nil
+}
+
+// DeleteGroupInvitation removes an invitation that hasn't been accepted or rejected yet
+func (db *DB) DeleteGroupInvitation(invitationID int64) error {
+	result, err := db.Exec(`DELETE FROM group_invitations WHERE id = ? AND status = 'pending'`, invitationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
+
+// GetUserInvitations retrieves a page of a user's invitations with the given status, newest first
+func (db *DB) GetUserInvitations(userID int64, status string, limit, offset int) ([]*GroupInvitation, error) {
 	query := `SELECT gi.id, gi.group_id, gi.inviter_id, gi.invitee_id, gi.status, 
 	                 gi.created_at, g.name as group_name,
 	                 u.first_name || ' ' || u.last_name as inviter_name
@@ -615,9 +1310,10 @@ func (db *DB) GetUserInvitations(userID int64, status string) ([]*GroupInvitatio
 	          JOIN groups g ON gi.group_id = g.id
 	          JOIN users u ON gi.inviter_id = u.id
 	          WHERE gi.invitee_id = ? AND gi.status = ?
-	          ORDER BY gi.created_at DESC`
+	          ORDER BY gi.created_at DESC, gi.id DESC
+	          LIMIT ? OFFSET ?`
 
-	rows, err := db.Query(query, userID, status)
+	rows, err := db.Query(query, userID, status, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -640,6 +1336,51 @@ func (db *DB) GetUserInvitations(userID int64, status string) ([]*GroupInvitatio
 	return invitations, rows.Err()
 }
 
+// CountUserInvitations returns how many invitations with the given status a user has received
+func (db *DB) CountUserInvitations(userID int64, status string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM group_invitations WHERE invitee_id = ? AND status = ?`,
+		userID, status).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count invitations: %v", err)
+	}
+	return count, nil
+}
+
+// GetGroupPendingInvitations retrieves the pending invitations sent for a group, newest first
+func (db *DB) GetGroupPendingInvitations(groupID int64) ([]*GroupInvitation, error) {
+	query := `SELECT gi.id, gi.group_id, gi.inviter_id, gi.invitee_id, gi.status, gi.created_at,
+	                 inviter.first_name || ' ' || inviter.last_name as inviter_name,
+	                 invitee.first_name || ' ' || invitee.last_name as invitee_name,
+	                 COALESCE(invitee.avatar, '') as invitee_avatar
+	          FROM group_invitations gi
+	          JOIN users inviter ON gi.inviter_id = inviter.id
+	          JOIN users invitee ON gi.invitee_id = invitee.id
+	          WHERE gi.group_id = ? AND gi.status = 'pending'
+	          ORDER BY gi.created_at DESC`
+
+	rows, err := db.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*GroupInvitation{}
+	for rows.Next() {
+		var inv GroupInvitation
+		if err := rows.Scan(
+			&inv.ID, &inv.GroupID, &inv.InviterID, &inv.InviteeID, &inv.Status, &inv.CreatedAt,
+			&inv.InviterName, &inv.InviteeName, &inv.InviteeAvatar,
+		); err != nil {
+			return nil, err
+		}
+		inv.UpdatedAt = inv.CreatedAt
+		invitations = append(invitations, &inv)
+	}
+
+	return invitations, rows.Err()
+}
+
 // CreateJoinRequest creates a new join request
 func (db *DB) CreateJoinRequest(request *GroupJoinRequest) (int64, error) {
 	query := `INSERT INTO group_join_requests (group_id, user_id, message, status) 
@@ -653,6 +1394,26 @@ func (db *DB) CreateJoinRequest(request *GroupJoinRequest) (int64, error) {
 	return result.LastInsertId()
 }
 
+// DeleteJoinRequest withdraws a user's pending request to join a group
+func (db *DB) DeleteJoinRequest(groupID, userID int64) error {
+	result, err := db.Exec(`DELETE FROM group_join_requests WHERE group_id = ? AND user_id = ? AND status = 'pending'`,
+		groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete join request: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("join request not found")
+	}
+
+	return nil
+}
+
 // GetGroupJoinRequests retrieves all join requests for a group
 func (db *DB) GetGroupJoinRequests(groupID int64, status string) ([]*GroupJoinRequest, error) {
 	query := `SELECT gjr.id, gjr.group_id, gjr.user_id, gjr.status, gjr.message,
@@ -685,6 +1446,37 @@ func (db *DB) GetGroupJoinRequests(groupID int64, status string) ([]*GroupJoinRe
 	return requests, rows.Err()
 }
 
+// GetUserJoinRequests retrieves the join requests a user has sent, newest first.
+// An empty status returns requests in every status
+func (db *DB) GetUserJoinRequests(userID int64, status string) ([]*GroupJoinRequest, error) {
+	query := `SELECT gjr.id, gjr.group_id, gjr.user_id, gjr.status, COALESCE(gjr.message, ''),
+	                 gjr.created_at, gjr.updated_at, g.name as group_name
+	          FROM group_join_requests gjr
+	          JOIN groups g ON gjr.group_id = g.id
+	          WHERE gjr.user_id = ? AND (? = '' OR gjr.status = ?)
+	          ORDER BY gjr.created_at DESC, gjr.id DESC`
+
+	rows, err := db.Query(query, userID, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join requests: %v", err)
+	}
+	defer rows.Close()
+
+	var requests []*GroupJoinRequest
+	for rows.Next() {
+		var req GroupJoinRequest
+		if err := rows.Scan(
+			&req.ID, &req.GroupID, &req.UserID, &req.Status, &req.Message,
+			&req.CreatedAt, &req.UpdatedAt, &req.GroupName,
+		); err != nil {
+			return nil, err
+		}
+		requests = append(requests, &req)
+	}
+
+	return requests, rows.Err()
+}
+
 // UpdateJoinRequestStatus updates the status of a join request
 func (db *DB) UpdateJoinRequestStatus(requestID int64, status string) error {
 	query := `UPDATE group_join_requests SET status = ?, updated_at = CURRENT_TIMESTAMP 
@@ -696,12 +1488,13 @@ func (db *DB) UpdateJoinRequestStatus(requestID int64, status string) error {
 
 // Group Posts Functions
 
-// CreateGroupPost creates a new post in a group
+// CreateGroupPost creates a new post in a group. Drafts are hidden until published.
+// Posts with an EventID belong to that event's discussion instead of the group feed
 func (db *DB) CreateGroupPost(post *GroupPost) (int64, error) {
-	query := `INSERT INTO group_posts (group_id, author_id, content, image_path) 
-	          VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO group_posts (group_id, author_id, content, image_path, thumbnail_path, is_draft, event_id) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := db.Exec(query, post.GroupID, post.AuthorID, post.Content, post.ImagePath)
+	result, err := db.Exec(query, post.GroupID, post.AuthorID, post.Content, post.ImagePath, post.ThumbnailURL, post.IsDraft, post.EventID)
 	if err != nil {
 		return 0, err
 	}
@@ -709,34 +1502,156 @@ func (db *DB) CreateGroupPost(post *GroupPost) (int64, error) {
 	return result.LastInsertId()
 }
 
-// GetGroupPosts retrieves all posts for a group with pagination
-func (db *DB) GetGroupPosts(groupID int64, limit, offset int, userID int64) ([]*GroupPost, error) {
-	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, 
-	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes, gp.created_at, gp.updated_at,
+// GetGroupPosts retrieves all posts for a group with pagination, leaving out event discussion posts
+// When beforeID is greater than zero, the posts that follow that post in feed order are
+// returned and offset is ignored, giving stable cursor-based paging
+func (db *DB) GetGroupPosts(groupID int64, limit, offset int, beforeID int64, userID int64) ([]*GroupPost, error) {
+	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, COALESCE(gp.thumbnail_path, ''),
+	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes,
+	                 COALESCE(gp.is_pinned, 0), gp.pinned_at, COALESCE(gp.is_draft, 0), gp.event_id, gp.created_at, gp.updated_at,
 	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
 	          FROM group_posts gp
 	          JOIN users u ON gp.author_id = u.id
-	          WHERE gp.group_id = ?
-	          ORDER BY gp.created_at DESC
+	          WHERE gp.group_id = ? AND gp.event_id IS NULL AND COALESCE(gp.is_draft, 0) = 0 AND COALESCE(gp.is_deleted, 0) = 0`
+
+	args := []interface{}{groupID}
+	if beforeID > 0 {
+		// Pinned posts all come first, so later pages continue with the unpinned posts in
+		// the same order. A pinned cursor means the unpinned posts haven't started yet
+		cursor := `(SELECT created_at FROM group_posts WHERE id = ?2)`
+		query += ` AND COALESCE(gp.is_pinned, 0) = 0
+	          AND ((SELECT COALESCE(is_pinned, 0) FROM group_posts WHERE id = ?2) = 1
+	               OR gp.created_at < ` + cursor + `
+	               OR (gp.created_at = ` + cursor + ` AND gp.id < ?2))
+	          ORDER BY gp.created_at DESC, gp.id DESC
+	          LIMIT ?3`
+		args = append(args, beforeID, limit)
+	} else {
+		// Pinned posts always come first
+		query += `
+	          ORDER BY COALESCE(gp.is_pinned, 0) DESC, gp.pinned_at DESC, gp.created_at DESC, gp.id DESC
 	          LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return db.scanGroupPostList(rows, userID)
+}
+
+// GetEventPosts retrieves the discussion posts of a group event, newest first
+func (db *DB) GetEventPosts(eventID int64, limit, offset int, userID int64) ([]*GroupPost, error) {
+	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, COALESCE(gp.thumbnail_path, ''),
+	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes,
+	                 COALESCE(gp.is_pinned, 0), gp.pinned_at, COALESCE(gp.is_draft, 0), gp.event_id, gp.created_at, gp.updated_at,
+	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
+	          FROM group_posts gp
+	          JOIN users u ON gp.author_id = u.id
+	          WHERE gp.event_id = ? AND COALESCE(gp.is_draft, 0) = 0 AND COALESCE(gp.is_deleted, 0) = 0
+	          ORDER BY gp.created_at DESC, gp.id DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(query, eventID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event posts: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanGroupPostList(rows, userID)
+}
+
+// SearchGroupPosts finds posts in a group whose content contains the query
+// (case-insensitive), newest first
+func (db *DB) SearchGroupPosts(groupID int64, query string, limit, offset int, userID int64) ([]*GroupPost, error) {
+	sqlQuery := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, COALESCE(gp.thumbnail_path, ''),
+	                    gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes,
+	                    COALESCE(gp.is_pinned, 0), gp.pinned_at, COALESCE(gp.is_draft, 0), gp.event_id, gp.created_at, gp.updated_at,
+	                    u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
+	             FROM group_posts gp
+	             JOIN users u ON gp.author_id = u.id
+	             WHERE gp.group_id = ? AND COALESCE(gp.is_draft, 0) = 0 AND COALESCE(gp.is_deleted, 0) = 0 AND LOWER(gp.content) LIKE ? ESCAPE '\'
+	             ORDER BY gp.created_at DESC, gp.id DESC
+	             LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(sqlQuery, groupID, likePattern(query), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return db.scanGroupPostList(rows, userID)
+}
+
+// GetGroupPostDrafts retrieves the drafts a user has saved in a group, most recently updated first
+func (db *DB) GetGroupPostDrafts(groupID, authorID int64) ([]*GroupPost, error) {
+	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, COALESCE(gp.thumbnail_path, ''),
+	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes,
+	                 COALESCE(gp.is_pinned, 0), gp.pinned_at, COALESCE(gp.is_draft, 0), gp.event_id, gp.created_at, gp.updated_at,
+	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
+	          FROM group_posts gp
+	          JOIN users u ON gp.author_id = u.id
+	          WHERE gp.group_id = ? AND gp.author_id = ? AND gp.is_draft = 1 AND COALESCE(gp.is_deleted, 0) = 0
+	          ORDER BY gp.updated_at DESC, gp.id DESC`
+
+	rows, err := db.Query(query, groupID, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drafts: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanGroupPostList(rows, authorID)
+}
+
+// PublishGroupPost turns a draft into a regular post. The post's creation time is
+// reset so it appears as new in the group feed
+func (db *DB) PublishGroupPost(postID int64) error {
+	query := `UPDATE group_posts SET is_draft = 0, created_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ? AND is_draft = 1`
+
+	result, err := db.Exec(query, postID)
+	if err != nil {
+		return fmt.Errorf("failed to publish post: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
 
-	rows, err := db.Query(query, groupID, limit, offset)
-	if err != nil {
-		return nil, err
+	if rowsAffected == 0 {
+		return fmt.Errorf("draft not found")
 	}
-	defer rows.Close()
 
+	return nil
+}
+
+// scanGroupPostList scans group post rows and fills in the user's like and vote state
+func (db *DB) scanGroupPostList(rows *sql.Rows, userID int64) ([]*GroupPost, error) {
 	var posts []*GroupPost
 	for rows.Next() {
 		var post GroupPost
+		var pinnedAt sql.NullTime
+		var eventID sql.NullInt64
 		if err := rows.Scan(
-			&post.ID, &post.GroupID, &post.AuthorID, &post.Content, &post.ImagePath,
-			&post.LikesCount, &post.CommentsCount, &post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
+			&post.ID, &post.GroupID, &post.AuthorID, &post.Content, &post.ImagePath, &post.ThumbnailURL,
+			&post.LikesCount, &post.CommentsCount, &post.Upvotes, &post.Downvotes,
+			&post.IsPinned, &pinnedAt, &post.IsDraft, &eventID, &post.CreatedAt, &post.UpdatedAt,
 			&post.AuthorName, &post.AuthorAvatar,
 		); err != nil {
 			return nil, err
 		}
 
+		if pinnedAt.Valid {
+			post.PinnedAt = &pinnedAt.Time
+		}
+		if eventID.Valid {
+			post.EventID = &eventID.Int64
+		}
+
 		// Check if user liked this post
 		post.IsLiked = db.HasUserLikedGroupPost(post.ID, userID)
 
@@ -746,6 +1661,8 @@ func (db *DB) GetGroupPosts(groupID int64, limit, offset int, userID int64) ([]*
 			post.UserVote = userVote
 		}
 
+		post.UnreadComments = db.GetUnreadCommentCount(post.ID, userID)
+
 		posts = append(posts, &post)
 	}
 
@@ -754,17 +1671,21 @@ func (db *DB) GetGroupPosts(groupID int64, limit, offset int, userID int64) ([]*
 
 // GetGroupPost retrieves a specific group post by ID
 func (db *DB) GetGroupPost(postID int64, userID int64) (*GroupPost, error) {
-	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, 
-	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes, gp.created_at, gp.updated_at,
+	query := `SELECT gp.id, gp.group_id, gp.author_id, gp.content, gp.image_path, COALESCE(gp.thumbnail_path, ''),
+	                 gp.likes_count, gp.comments_count, gp.upvotes, gp.downvotes,
+	                 COALESCE(gp.is_pinned, 0), gp.pinned_at, COALESCE(gp.is_draft, 0), gp.event_id, gp.created_at, gp.updated_at,
 	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
 	          FROM group_posts gp
 	          JOIN users u ON gp.author_id = u.id
-	          WHERE gp.id = ?`
+	          WHERE gp.id = ? AND COALESCE(gp.is_deleted, 0) = 0`
 
 	var post GroupPost
+	var pinnedAt sql.NullTime
+	var eventID sql.NullInt64
 	err := db.QueryRow(query, postID).Scan(
-		&post.ID, &post.GroupID, &post.AuthorID, &post.Content, &post.ImagePath,
-		&post.LikesCount, &post.CommentsCount, &post.Upvotes, &post.Downvotes, &post.CreatedAt, &post.UpdatedAt,
+		&post.ID, &post.GroupID, &post.AuthorID, &post.Content, &post.ImagePath, &post.ThumbnailURL,
+		&post.LikesCount, &post.CommentsCount, &post.Upvotes, &post.Downvotes,
+		&post.IsPinned, &pinnedAt, &post.IsDraft, &eventID, &post.CreatedAt, &post.UpdatedAt,
 		&post.AuthorName, &post.AuthorAvatar,
 	)
 
@@ -775,6 +1696,13 @@ func (db *DB) GetGroupPost(postID int64, userID int64) (*GroupPost, error) {
 		return nil, err
 	}
 
+	if pinnedAt.Valid {
+		post.PinnedAt = &pinnedAt.Time
+	}
+	if eventID.Valid {
+		post.EventID = &eventID.Int64
+	}
+
 	// Check if user liked this post
 	post.IsLiked = db.HasUserLikedGroupPost(post.ID, userID)
 
@@ -784,9 +1712,110 @@ func (db *DB) GetGroupPost(postID int64, userID int64) (*GroupPost, error) {
 		post.UserVote = userVote
 	}
 
+	// Get reaction breakdown and the user's own reaction
+	reactions, err := db.GetGroupPostReactionCounts(post.ID)
+	if err == nil {
+		post.Reactions = reactions
+	}
+	post.UserReaction = db.GetUserGroupPostReaction(post.ID, userID)
+
 	return &post, nil
 }
 
+// UpdateGroupPost updates the content and image of a group post
+func (db *DB) UpdateGroupPost(postID int64, content string, imagePath string) error {
+	// A new image makes the stored thumbnail stale, so it is dropped along with the old image
+	query := `UPDATE group_posts SET content = ?, image_path = ?,
+	                 thumbnail_path = CASE WHEN COALESCE(image_path, '') = ? THEN thumbnail_path ELSE NULL END,
+	                 updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ?`
+
+	result, err := db.Exec(query, content, imagePath, imagePath, postID)
+	if err != nil {
+		return fmt.Errorf("failed to update post: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	return nil
+}
+
+// SetGroupPostThumbnail stores the URL of the downscaled copy of a group post's image
+func (db *DB) SetGroupPostThumbnail(postID int64, thumbnailURL string) error {
+	_, err := db.Exec(`UPDATE group_posts SET thumbnail_path = ? WHERE id = ?`, thumbnailURL, postID)
+	if err != nil {
+		return fmt.Errorf("failed to set post thumbnail: %v", err)
+	}
+
+	return nil
+}
+
+// ClearGroupPostImage removes the image from a group post, leaving its content untouched
+func (db *DB) ClearGroupPostImage(postID int64) error {
+	query := `UPDATE group_posts SET image_path = '', thumbnail_path = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := db.Exec(query, postID)
+	if err != nil {
+		return fmt.Errorf("failed to clear post image: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	return nil
+}
+
+// MaxPinnedGroupPosts is the maximum number of pinned posts a group can have
+const MaxPinnedGroupPosts = 3
+
+// SetGroupPostPinned pins or unpins a group post. Pinning fails with "pin limit reached"
+// when the group already has MaxPinnedGroupPosts pinned posts; the count is checked in
+// the same statement so concurrent pins can't exceed it
+func (db *DB) SetGroupPostPinned(postID int64, pinned bool) error {
+	var result sql.Result
+	var err error
+	if pinned {
+		result, err = db.Exec(`UPDATE group_posts SET is_pinned = 1, pinned_at = CURRENT_TIMESTAMP
+		          WHERE id = ?1 AND (COALESCE(is_pinned, 0) = 1 OR
+		                (SELECT COUNT(*) FROM group_posts p
+		                 WHERE p.group_id = group_posts.group_id AND p.is_pinned = 1 AND COALESCE(p.is_deleted, 0) = 0) < ?2)`,
+			postID, MaxPinnedGroupPosts)
+	} else {
+		result, err = db.Exec(`UPDATE group_posts SET is_pinned = 0, pinned_at = NULL WHERE id = ?`, postID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pin status: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		var exists bool
+		if pinned && db.QueryRow(`SELECT 1 FROM group_posts WHERE id = ?`, postID).Scan(&exists) == nil {
+			return fmt.Errorf("pin limit reached")
+		}
+		return fmt.Errorf("post not found")
+	}
+
+	return nil
+}
+
 // LikeGroupPost adds a like to a group post
 func (db *DB) LikeGroupPost(postID, userID int64) error {
 	// Check if already liked
@@ -831,6 +1860,74 @@ func (db *DB) UnlikeGroupPost(postID, userID int64) error {
 	return err
 }
 
+// GroupPostReactions lists the reaction types allowed on group posts
+var GroupPostReactions = map[string]bool{
+	"like":  true,
+	"love":  true,
+	"laugh": true,
+	"sad":   true,
+	"angry": true,
+}
+
+// SetGroupPostReaction sets or changes a user's reaction on a group post.
+// likes_count is kept as the total number of reactions of any type.
+func (db *DB) SetGroupPostReaction(postID, userID int64, reaction string) error {
+	if !GroupPostReactions[reaction] {
+		return fmt.Errorf("invalid reaction: %s", reaction)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO group_post_likes (post_id, user_id, reaction) VALUES (?, ?, ?)
+	                  ON CONFLICT(post_id, user_id) DO UPDATE SET reaction = excluded.reaction`,
+		postID, userID, reaction)
+	if err != nil {
+		return fmt.Errorf("failed to set reaction: %v", err)
+	}
+
+	_, err = tx.Exec(`UPDATE group_posts SET likes_count = (SELECT COUNT(*) FROM group_post_likes WHERE post_id = ?1) WHERE id = ?1`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to update likes count: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetUserGroupPostReaction returns a user's reaction on a group post, or "" if none
+func (db *DB) GetUserGroupPostReaction(postID, userID int64) string {
+	var reaction string
+	query := `SELECT reaction FROM group_post_likes WHERE post_id = ? AND user_id = ?`
+	db.QueryRow(query, postID, userID).Scan(&reaction)
+	return reaction
+}
+
+// GetGroupPostReactionCounts returns the number of each reaction type on a group post
+func (db *DB) GetGroupPostReactionCounts(postID int64) (map[string]int, error) {
+	query := `SELECT reaction, COUNT(*) FROM group_post_likes WHERE post_id = ? GROUP BY reaction`
+
+	rows, err := db.Query(query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reaction counts: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reaction string
+		var count int
+		if err := rows.Scan(&reaction, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction count: %v", err)
+		}
+		counts[reaction] = count
+	}
+
+	return counts, rows.Err()
+}
+
 // HasUserLikedGroupPost checks if a user has liked a specific group post
 func (db *DB) HasUserLikedGroupPost(postID, userID int64) bool {
 	var count int
@@ -841,12 +1938,50 @@ func (db *DB) HasUserLikedGroupPost(postID, userID int64) bool {
 
 // Group Post Comments Functions
 
+// MarkGroupPostRead moves the user's read marker on a post to its newest comment.
+// The marker only moves forward, the ID it points to afterwards is returned
+func (db *DB) MarkGroupPostRead(postID, userID int64) (int64, error) {
+	var latestCommentID int64
+	err := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM group_post_comments WHERE post_id = ?`, postID).Scan(&latestCommentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest comment: %v", err)
+	}
+
+	query := `INSERT INTO group_post_reads (post_id, user_id, last_read_comment_id) VALUES (?1, ?2, ?3)
+	          ON CONFLICT(post_id, user_id) DO UPDATE SET
+	              last_read_comment_id = MAX(last_read_comment_id, ?3),
+	              updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.Exec(query, postID, userID, latestCommentID); err != nil {
+		return 0, fmt.Errorf("failed to mark post as read: %v", err)
+	}
+
+	var lastReadID int64
+	err = db.QueryRow(`SELECT last_read_comment_id FROM group_post_reads WHERE post_id = ? AND user_id = ?`, postID, userID).Scan(&lastReadID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get read marker: %v", err)
+	}
+
+	return lastReadID, nil
+}
+
+// GetUnreadCommentCount returns how many comments by other users were added to a post
+// since the user last opened it. On posts the user never opened every comment is unread
+func (db *DB) GetUnreadCommentCount(postID, userID int64) int {
+	query := `SELECT COUNT(*) FROM group_post_comments c
+	          WHERE c.post_id = ?1 AND c.author_id != ?2
+	          AND c.id > COALESCE((SELECT last_read_comment_id FROM group_post_reads WHERE post_id = ?1 AND user_id = ?2), 0)`
+
+	var count int
+	db.QueryRow(query, postID, userID).Scan(&count)
+	return count
+}
+
 // CreateGroupPostComment adds a comment to a group post
 func (db *DB) CreateGroupPostComment(comment *GroupPostComment) (int64, error) {
-	query := `INSERT INTO group_post_comments (post_id, author_id, content, image_path) 
-	          VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO group_post_comments (post_id, author_id, content, image_path, parent_comment_id) 
+	          VALUES (?, ?, ?, ?, ?)`
 
-	result, err := db.Exec(query, comment.PostID, comment.AuthorID, comment.Content, comment.ImagePath)
+	result, err := db.Exec(query, comment.PostID, comment.AuthorID, comment.Content, comment.ImagePath, comment.ParentCommentID)
 	if err != nil {
 		return 0, err
 	}
@@ -866,7 +2001,7 @@ func (db *DB) CreateGroupPostComment(comment *GroupPostComment) (int64, error) {
 // GetGroupPostComments retrieves all comments for a group post
 func (db *DB) GetGroupPostComments(postID int64) ([]*GroupPostComment, error) {
 	query := `SELECT gpc.id, gpc.post_id, gpc.author_id, gpc.content, gpc.image_path, gpc.vote_count, gpc.upvotes, gpc.downvotes, gpc.created_at,
-	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
+	                 gpc.parent_comment_id, u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
 	          FROM group_post_comments gpc
 	          JOIN users u ON gpc.author_id = u.id
 	          WHERE gpc.post_id = ?
@@ -881,12 +2016,16 @@ func (db *DB) GetGroupPostComments(postID int64) ([]*GroupPostComment, error) {
 	var comments []*GroupPostComment
 	for rows.Next() {
 		var comment GroupPostComment
+		var parentCommentID sql.NullInt64
 		if err := rows.Scan(
 			&comment.ID, &comment.PostID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.VoteCount, &comment.Upvotes, &comment.Downvotes, &comment.CreatedAt,
-			&comment.AuthorName, &comment.AuthorAvatar,
+			&parentCommentID, &comment.AuthorName, &comment.AuthorAvatar,
 		); err != nil {
 			return nil, err
 		}
+		if parentCommentID.Valid {
+			comment.ParentCommentID = &parentCommentID.Int64
+		}
 		comments = append(comments, &comment)
 	}
 
@@ -914,15 +2053,16 @@ func (db *DB) GetGroupPostCommentsWithUserVotes(postID int64, userID int64) ([]*
 // GetGroupPostComment retrieves a specific group post comment by ID
 func (db *DB) GetGroupPostComment(commentID int64, userID int64) (*GroupPostComment, error) {
 	query := `SELECT gpc.id, gpc.post_id, gpc.author_id, gpc.content, gpc.image_path, gpc.vote_count, gpc.upvotes, gpc.downvotes, gpc.created_at,
-	                 u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
+	                 gpc.parent_comment_id, u.first_name || ' ' || u.last_name as author_name, u.avatar as author_avatar
 	          FROM group_post_comments gpc
 	          JOIN users u ON gpc.author_id = u.id
 	          WHERE gpc.id = ?`
 
 	var comment GroupPostComment
+	var parentCommentID sql.NullInt64
 	err := db.QueryRow(query, commentID).Scan(
 		&comment.ID, &comment.PostID, &comment.AuthorID, &comment.Content, &comment.ImagePath, &comment.VoteCount, &comment.Upvotes, &comment.Downvotes, &comment.CreatedAt,
-		&comment.AuthorName, &comment.AuthorAvatar,
+		&parentCommentID, &comment.AuthorName, &comment.AuthorAvatar,
 	)
 
 	if err != nil {
@@ -932,6 +2072,10 @@ func (db *DB) GetGroupPostComment(commentID int64, userID int64) (*GroupPostComm
 		return nil, err
 	}
 
+	if parentCommentID.Valid {
+		comment.ParentCommentID = &parentCommentID.Int64
+	}
+
 	// Get user's vote on this comment
 	userVote, err := db.GetUserVote(int(userID), comment.ID, "group_post_comment")
 	if err == nil {
@@ -960,8 +2104,17 @@ func (db *DB) DeleteGroupPostComment(commentID int64) error {
 		return err
 	}
 
-	// Delete the comment
-	result, err := tx.Exec("DELETE FROM group_post_comments WHERE id = ?", commentID)
+	// Votes and reports on the comment and its replies go with them
+	const threadIDs = `SELECT id FROM group_post_comments WHERE id = ?1 OR parent_comment_id = ?1`
+	for _, table := range []string{"votes", "reports"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE content_type = 'group_post_comment' AND content_id IN ("+threadIDs+")", commentID)
+		if err != nil {
+			return fmt.Errorf("failed to delete comment %s: %v", table, err)
+		}
+	}
+
+	// Delete the comment together with its replies
+	result, err := tx.Exec("DELETE FROM group_post_comments WHERE id = ?1 OR parent_comment_id = ?1", commentID)
 	if err != nil {
 		return err
 	}
@@ -976,96 +2129,457 @@ func (db *DB) DeleteGroupPostComment(commentID int64) error {
 		return fmt.Errorf("comment not found")
 	}
 
-	// Update comments count in the post
-	_, err = tx.Exec("UPDATE group_posts SET comments_count = comments_count - 1 WHERE id = ?", postID)
-	if err != nil {
-		return err
+	// Update comments count in the post
+	_, err = tx.Exec("UPDATE group_posts SET comments_count = MAX(comments_count - ?, 0) WHERE id = ?", rowsAffected, postID)
+	if err != nil {
+		return err
+	}
+
+	// Commit transaction
+	return tx.Commit()
+}
+
+// Group Events Functions
+
+// DefaultRecurrenceHorizon is how far ahead open-ended recurring events are expanded
+const DefaultRecurrenceHorizon = 90 * 24 * time.Hour
+
+// MaxRecurrenceYears is how far after its first occurrence a recurring event may end
+const MaxRecurrenceYears = 2
+
+// IsRecurring reports whether the event repeats
+func (e *GroupEvent) IsRecurring() bool {
+	return e.Recurrence == "daily" || e.Recurrence == "weekly" || e.Recurrence == "monthly"
+}
+
+// zone returns the time zone the event was created in. Occurrences are expanded
+// in this zone so they keep their local day and wall-clock time across DST changes
+func (e *GroupEvent) zone() *time.Location {
+	if e.location == nil {
+		e.location = time.UTC
+		if loc, err := time.LoadLocation(e.Timezone); err == nil {
+			e.location = loc
+		}
+	}
+	return e.location
+}
+
+// localDay returns the day (YYYY-MM-DD) of t in the event's time zone
+func (e *GroupEvent) localDay(t time.Time) string {
+	return t.In(e.zone()).Format("2006-01-02")
+}
+
+// occurrenceAt returns the start time (UTC) of the nth occurrence of the event
+func (e *GroupEvent) occurrenceAt(n int) time.Time {
+	start := e.EventDate.In(e.zone())
+
+	switch e.Recurrence {
+	case "daily":
+		return start.AddDate(0, 0, n).UTC()
+	case "weekly":
+		return start.AddDate(0, 0, 7*n).UTC()
+	case "monthly":
+		// Clamp to the last day of shorter months instead of rolling into the next one
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1,
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		day := start.Day()
+		if last := daysInMonth(first); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1).UTC()
+	}
+	return e.EventDate
+}
+
+// daysInMonth returns the number of days in the month containing t
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// OccurrenceDates returns the start time of every occurrence up to the given time,
+// stopping early at the end of the recurrence end day in the event's time zone
+func (e *GroupEvent) OccurrenceDates(until time.Time) []time.Time {
+	if !e.IsRecurring() || e.EventDate.IsZero() {
+		return []time.Time{e.EventDate}
+	}
+
+	if e.RecurrenceEnd != nil {
+		end := time.Date(e.RecurrenceEnd.Year(), e.RecurrenceEnd.Month(), e.RecurrenceEnd.Day()+1, 0, 0, 0, 0, e.zone())
+		if end.Before(until) {
+			until = end
+		}
+	}
+
+	var dates []time.Time
+	for n := 0; ; n++ {
+		date := e.occurrenceAt(n)
+		if !date.Before(until) {
+			break
+		}
+		dates = append(dates, date)
+	}
+
+	return dates
+}
+
+// OccursOn reports whether the event has an occurrence on the given local day (YYYY-MM-DD)
+func (e *GroupEvent) OccursOn(day string) bool {
+	date, err := time.ParseInLocation("2006-01-02", day, e.zone())
+	if err != nil {
+		return false
+	}
+
+	for _, occurrence := range e.OccurrenceDates(date.AddDate(0, 0, 1)) {
+		if e.localDay(occurrence) == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOccurrence returns the start of the first occurrence at or after t that wasn't
+// removed, looking as far ahead as GetGroupEvents lists occurrences. Once the series
+// has ended it falls back to the last remaining occurrence; ok is false if none remain
+func (e *GroupEvent) NextOccurrence(t time.Time, exceptions map[string]bool) (time.Time, bool) {
+	var last time.Time
+	found := false
+	for _, date := range e.OccurrenceDates(t.Add(DefaultRecurrenceHorizon)) {
+		if exceptions[e.localDay(date)] {
+			continue
+		}
+		if !date.Before(t) {
+			return date, true
+		}
+		last, found = date, true
+	}
+	return last, found
+}
+
+// occurrenceKey returns the value stored in group_event_responses.occurrence_date
+// for the event, the occurrence's day in the event's time zone. Non-recurring events use an empty key
+func (e *GroupEvent) occurrenceKey() string {
+	if !e.IsRecurring() {
+		return ""
+	}
+	return e.localDay(e.EventDate)
+}
+
+// CreateGroupEvent creates a new event in a group
+func (db *DB) CreateGroupEvent(event *GroupEvent) (int64, error) {
+	// Extract date and time separately from EventDate
+	eventDate := event.EventDate.Format("2006-01-02")
+	eventTime := event.EventDate.Format("15:04")
+
+	if event.Recurrence == "" {
+		event.Recurrence = "none"
+	}
+
+	if event.Timezone == "" {
+		event.Timezone = "UTC"
+	}
+
+	var recurrenceEnd interface{}
+	if event.RecurrenceEnd != nil {
+		recurrenceEnd = event.RecurrenceEnd.Format("2006-01-02")
+	}
+
+	query := `INSERT INTO group_events (group_id, creator_id, title, description, event_date, event_time, recurrence, recurrence_end, location, timezone) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.Exec(query, event.GroupID, event.CreatorID, event.Title, event.Description, eventDate, eventTime,
+		event.Recurrence, recurrenceEnd, event.Location, event.Timezone)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// scanGroupEvent scans a group event row and combines its date and time columns
+func scanGroupEvent(scanner interface{ Scan(...interface{}) error }) (*GroupEvent, error) {
+	var event GroupEvent
+	var eventDate, eventTime string
+	var recurrence, recurrenceEnd, location, timezone sql.NullString
+	if err := scanner.Scan(
+		&event.ID, &event.GroupID, &event.CreatorID, &event.Title, &event.Description,
+		&eventDate, &eventTime, &recurrence, &recurrenceEnd, &location, &timezone,
+		&event.IsFeatured, &event.CreatedAt, &event.UpdatedAt, &event.CreatorName,
+	); err != nil {
+		return nil, err
+	}
+
+	// The driver may return DATE columns as full timestamps, keep only the date part
+	if len(eventDate) > 10 {
+		eventDate = eventDate[:10]
+	}
+
+	// Combine date and time back into EventDate
+	dateTimeStr := eventDate + " " + eventTime
+	if parsedDateTime, err := time.Parse("2006-01-02 15:04", dateTimeStr); err == nil {
+		event.EventDate = parsedDateTime
+	}
+
+	event.Recurrence = "none"
+	if recurrence.Valid && recurrence.String != "" {
+		event.Recurrence = recurrence.String
+	}
+
+	event.Location = location.String
+	event.Timezone = "UTC"
+	if timezone.Valid && timezone.String != "" {
+		event.Timezone = timezone.String
+	}
+
+	if recurrenceEnd.Valid && len(recurrenceEnd.String) >= 10 {
+		if parsedEnd, err := time.Parse("2006-01-02", recurrenceEnd.String[:10]); err == nil {
+			event.RecurrenceEnd = &parsedEnd
+		}
+	}
+
+	return &event, nil
+}
+
+// loadEventResponses fills in the response counts and the user's own response
+// for the event's current occurrence
+func (db *DB) loadEventResponses(event *GroupEvent, userID int64) {
+	occurrenceDate := event.occurrenceKey()
+	if event.IsRecurring() {
+		event.OccurrenceDate = occurrenceDate
+	}
+
+	// Get response counts
+	event.GoingCount, event.NotGoingCount, event.MaybeCount = db.GetEventResponseCounts(event.ID, occurrenceDate)
+
+	// Get user's response
+	event.UserResponse = db.GetUserEventResponse(event.ID, userID, occurrenceDate)
+}
+
+// GetGroupEvents retrieves all events for a group, expanding recurring events
+// into one entry per occurrence
+func (db *DB) GetGroupEvents(groupID int64, userID int64) ([]*GroupEvent, error) {
+	query := `SELECT ge.id, ge.group_id, ge.creator_id, ge.title, ge.description, 
+	                 ge.event_date, ge.event_time, ge.recurrence, ge.recurrence_end, ge.location, ge.timezone,
+	                 COALESCE(ge.is_featured, 0), ge.created_at, ge.updated_at,
+	                 u.first_name || ' ' || u.last_name as creator_name
+	          FROM group_events ge
+	          JOIN users u ON ge.creator_id = u.id
+	          WHERE ge.group_id = ?
+	          ORDER BY ge.event_date ASC, ge.event_time ASC`
+
+	rows, err := db.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []*GroupEvent
+	for rows.Next() {
+		event, err := scanGroupEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	// Commit transaction
-	return tx.Commit()
-}
+	horizon := time.Now().Add(DefaultRecurrenceHorizon)
 
-// Group Events Functions
+	var events []*GroupEvent
+	for _, event := range series {
+		if !event.IsRecurring() {
+			db.loadEventResponses(event, userID)
+			events = append(events, event)
+			continue
+		}
 
-// CreateGroupEvent creates a new event in a group
-func (db *DB) CreateGroupEvent(event *GroupEvent) (int64, error) {
-	// Extract date and time separately from EventDate
-	eventDate := event.EventDate.Format("2006-01-02")
-	eventTime := event.EventDate.Format("15:04")
+		exceptions, err := db.GetEventExceptions(event.ID)
+		if err != nil {
+			return nil, err
+		}
 
-	query := `INSERT INTO group_events (group_id, creator_id, title, description, event_date, event_time) 
-	          VALUES (?, ?, ?, ?, ?, ?)`
+		// OccurrenceDates stops at the recurrence end when it comes before the horizon
+		for _, date := range event.OccurrenceDates(horizon) {
+			if exceptions[event.localDay(date)] {
+				continue
+			}
 
-	result, err := db.Exec(query, event.GroupID, event.CreatorID, event.Title, event.Description, eventDate, eventTime)
-	if err != nil {
-		return 0, err
+			occurrence := *event
+			occurrence.EventDate = date
+			db.loadEventResponses(&occurrence, userID)
+			events = append(events, &occurrence)
+		}
 	}
 
-	return result.LastInsertId()
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventDate.Before(events[j].EventDate)
+	})
+
+	// The featured event goes first. For recurring events only the next upcoming
+	// occurrence is moved, falling back to the latest one if all have passed
+	featured := -1
+	now := time.Now()
+	for i, event := range events {
+		if !event.IsFeatured {
+			continue
+		}
+		featured = i
+		if !event.EventDate.Before(now) {
+			break
+		}
+	}
+	if featured > 0 {
+		event := events[featured]
+		copy(events[1:featured+1], events[:featured])
+		events[0] = event
+	}
+
+	return events, nil
 }
 
-// GetGroupEvents retrieves all events for a group
-func (db *DB) GetGroupEvents(groupID int64, userID int64) ([]*GroupEvent, error) {
-	query := `SELECT ge.id, ge.group_id, ge.creator_id, ge.title, ge.description, 
-	                 ge.event_date, ge.event_time, ge.created_at, ge.updated_at,
-	                 u.first_name || ' ' || u.last_name as creator_name
+// GetUpcomingEventsForUser retrieves future events, including occurrences of recurring
+// events, from every group the user belongs to, soonest first. Responses are only
+// loaded for the events that are returned
+func (db *DB) GetUpcomingEventsForUser(userID int64, limit int) ([]*GroupEvent, error) {
+	// Past one-off events are skipped here. Recurring series are kept until their
+	// end day has passed in every time zone, their occurrences are filtered below
+	query := `SELECT ge.id, ge.group_id, ge.creator_id, ge.title, ge.description,
+	                 ge.event_date, ge.event_time, ge.recurrence, ge.recurrence_end, ge.location, ge.timezone,
+	                 COALESCE(ge.is_featured, 0), ge.created_at, ge.updated_at,
+	                 u.first_name || ' ' || u.last_name as creator_name,
+	                 g.name
 	          FROM group_events ge
 	          JOIN users u ON ge.creator_id = u.id
-	          WHERE ge.group_id = ?
+	          JOIN groups g ON ge.group_id = g.id
+	          JOIN group_members gm ON gm.group_id = ge.group_id AND gm.user_id = ?
+	          WHERE ge.event_date >= date('now')
+	             OR (ge.recurrence IN ('daily', 'weekly', 'monthly')
+	                 AND (ge.recurrence_end IS NULL OR ge.recurrence_end >= date('now', '-1 day')))
 	          ORDER BY ge.event_date ASC, ge.event_time ASC`
 
-	rows, err := db.Query(query, groupID)
+	rows, err := db.Query(query, userID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get upcoming events: %v", err)
 	}
 	defer rows.Close()
 
-	var events []*GroupEvent
+	var series []*GroupEvent
 	for rows.Next() {
-		var event GroupEvent
-		var eventDate, eventTime string
-		if err := rows.Scan(
-			&event.ID, &event.GroupID, &event.CreatorID, &event.Title, &event.Description,
-			&eventDate, &eventTime, &event.CreatedAt, &event.UpdatedAt, &event.CreatorName,
-		); err != nil {
+		var groupName string
+		event, err := scanGroupEvent(scanFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &groupName)...)
+		}))
+		if err != nil {
 			return nil, err
 		}
+		event.GroupName = groupName
+		series = append(series, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	horizon := now.Add(DefaultRecurrenceHorizon)
+
+	events := []*GroupEvent{}
+	for _, event := range series {
+		if !event.IsRecurring() {
+			if !event.EventDate.Before(now) {
+				events = append(events, event)
+			}
+			continue
+		}
+
+		exceptions, err := db.GetEventExceptions(event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exceptions for event %d: %v", event.ID, err)
+		}
+
+		for _, date := range event.OccurrenceDates(horizon) {
+			if date.Before(now) || exceptions[event.localDay(date)] {
+				continue
+			}
 
-		// Combine date and time back into EventDate
-		dateTimeStr := eventDate + " " + eventTime
-		if parsedDateTime, err := time.Parse("2006-01-02 15:04", dateTimeStr); err == nil {
-			event.EventDate = parsedDateTime
+			occurrence := *event
+			occurrence.EventDate = date
+			events = append(events, &occurrence)
 		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventDate.Before(events[j].EventDate)
+	})
+
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+
+	for _, event := range events {
+		db.loadEventResponses(event, userID)
+	}
+
+	return events, nil
+}
 
-		// Get response counts
-		event.GoingCount, event.NotGoingCount = db.GetEventResponseCounts(event.ID)
+// scanFunc lets a plain scan function be passed where a row scanner is expected,
+// for example to scan extra columns after the ones a shared scanner reads
+type scanFunc func(dest ...interface{}) error
 
-		// Get user's response
-		event.UserResponse = db.GetUserEventResponse(event.ID, userID)
+// Scan calls the function
+func (f scanFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
+
+// SetGroupEventFeatured features or unfeatures an event. Featuring an event
+// unfeatures any other event in the same group
+func (db *DB) SetGroupEventFeatured(eventID int64, featured bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var groupID int64
+	err = tx.QueryRow(`SELECT group_id FROM group_events WHERE id = ?`, eventID).Scan(&groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("event not found")
+		}
+		return fmt.Errorf("failed to get event: %v", err)
+	}
+
+	if featured {
+		_, err = tx.Exec(`UPDATE group_events SET is_featured = 0 WHERE group_id = ? AND id != ? AND is_featured = 1`, groupID, eventID)
+		if err != nil {
+			return fmt.Errorf("failed to unfeature events: %v", err)
+		}
+	}
 
-		events = append(events, &event)
+	_, err = tx.Exec(`UPDATE group_events SET is_featured = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, featured, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to update featured event: %v", err)
 	}
 
-	return events, rows.Err()
+	return tx.Commit()
 }
 
-// GetGroupEvent retrieves a specific group event by ID
+// GetGroupEvent retrieves a specific group event by ID. For recurring events the
+// response details refer to the first occurrence
 func (db *DB) GetGroupEvent(eventID int64, userID int64) (*GroupEvent, error) {
 	query := `SELECT ge.id, ge.group_id, ge.creator_id, ge.title, ge.description, 
-	                 ge.event_date, ge.event_time, ge.created_at, ge.updated_at,
+	                 ge.event_date, ge.event_time, ge.recurrence, ge.recurrence_end, ge.location, ge.timezone,
+	                 COALESCE(ge.is_featured, 0), ge.created_at, ge.updated_at,
 	                 u.first_name || ' ' || u.last_name as creator_name
 	          FROM group_events ge
 	          JOIN users u ON ge.creator_id = u.id
 	          WHERE ge.id = ?`
 
-	var event GroupEvent
-	var eventDate, eventTime string
-	err := db.QueryRow(query, eventID).Scan(
-		&event.ID, &event.GroupID, &event.CreatorID, &event.Title, &event.Description,
-		&eventDate, &eventTime, &event.CreatedAt, &event.UpdatedAt, &event.CreatorName,
-	)
-
+	event, err := scanGroupEvent(db.QueryRow(query, eventID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -1073,80 +2587,119 @@ func (db *DB) GetGroupEvent(eventID int64, userID int64) (*GroupEvent, error) {
 		return nil, err
 	}
 
-	// Combine date and time back into EventDate
-	dateTimeStr := eventDate + " " + eventTime
-	if parsedDateTime, err := time.Parse("2006-01-02 15:04", dateTimeStr); err == nil {
-		event.EventDate = parsedDateTime
+	db.loadEventResponses(event, userID)
+
+	return event, nil
+}
+
+// GetGroupEventOccurrence retrieves a single occurrence (YYYY-MM-DD) of a recurring event
+func (db *DB) GetGroupEventOccurrence(eventID, userID int64, occurrenceDate string) (*GroupEvent, error) {
+	event, err := db.GetGroupEvent(eventID, userID)
+	if err != nil || event == nil || !event.IsRecurring() {
+		return event, err
 	}
 
-	// Get response counts
-	event.GoingCount, event.NotGoingCount = db.GetEventResponseCounts(event.ID)
+	day, err := time.Parse("2006-01-02", occurrenceDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid occurrence date: %v", err)
+	}
 
-	// Get user's response
-	event.UserResponse = db.GetUserEventResponse(event.ID, userID)
+	// Keep the local start time on the requested local day
+	start := event.EventDate.In(event.zone())
+	event.EventDate = time.Date(day.Year(), day.Month(), day.Day(),
+		start.Hour(), start.Minute(), 0, 0, start.Location()).UTC()
+	db.loadEventResponses(event, userID)
 
-	return &event, nil
+	return event, nil
+}
+
+// GetNextGroupEventOccurrence retrieves an event with the response details of its next
+// occurrence that wasn't removed. Non-recurring events are returned as GetGroupEvent does
+func (db *DB) GetNextGroupEventOccurrence(eventID, userID int64) (*GroupEvent, error) {
+	event, err := db.GetGroupEvent(eventID, userID)
+	if err != nil || event == nil || !event.IsRecurring() {
+		return event, err
+	}
+
+	exceptions, err := db.GetEventExceptions(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, ok := event.NextOccurrence(time.Now(), exceptions)
+	if !ok {
+		return event, nil
+	}
+
+	return db.GetGroupEventOccurrence(eventID, userID, event.localDay(date))
 }
 
-// RespondToEvent adds, updates, or removes a user's response to an event
-func (db *DB) RespondToEvent(eventID, userID int64, response string) error {
-	// Check if response already exists
-	var existingResponse string
-	query := `SELECT response FROM group_event_responses WHERE event_id = ? AND user_id = ?`
-	err := db.QueryRow(query, eventID, userID).Scan(&existingResponse)
+// EventResponses are the responses a user can store for an event
+var EventResponses = map[string]bool{
+	"going":     true,
+	"not_going": true,
+	"maybe":     true,
+}
 
+// RespondToEvent adds, updates, or removes a user's response to an event. A response
+// of "remove" deletes it. occurrenceDate identifies the occurrence of a recurring
+// event and is empty otherwise
+func (db *DB) RespondToEvent(eventID, userID int64, response string, occurrenceDate string) error {
 	if response == "remove" {
-		// Remove the user's response
-		deleteQuery := `DELETE FROM group_event_responses WHERE event_id = ? AND user_id = ?`
-		_, err = db.Exec(deleteQuery, eventID, userID)
-		return err
+		deleteQuery := `DELETE FROM group_event_responses WHERE event_id = ? AND user_id = ? AND occurrence_date = ?`
+		if _, err := db.Exec(deleteQuery, eventID, userID, occurrenceDate); err != nil {
+			return fmt.Errorf("failed to remove event response: %v", err)
+		}
+		return nil
 	}
 
-	switch err {
-	case sql.ErrNoRows:
-		// Insert new response
-		insertQuery := `INSERT INTO group_event_responses (event_id, user_id, response) 
-		                VALUES (?, ?, ?)`
-		_, err = db.Exec(insertQuery, eventID, userID, response)
-	case nil:
-		// Update existing response
-		updateQuery := `UPDATE group_event_responses 
-		                SET response = ?, updated_at = CURRENT_TIMESTAMP 
-		                WHERE event_id = ? AND user_id = ?`
-		_, err = db.Exec(updateQuery, response, eventID, userID)
+	if !EventResponses[response] {
+		return fmt.Errorf("invalid event response: %s", response)
 	}
 
-	return err
+	// Upsert so concurrent responses from the same user can't create duplicates
+	query := `INSERT INTO group_event_responses (event_id, user_id, occurrence_date, response)
+	          VALUES (?, ?, ?, ?)
+	          ON CONFLICT(event_id, user_id, occurrence_date) DO UPDATE SET response = excluded.response, updated_at = CURRENT_TIMESTAMP`
+	if _, err := db.Exec(query, eventID, userID, occurrenceDate, response); err != nil {
+		return fmt.Errorf("failed to save event response: %v", err)
+	}
+
+	return nil
 }
 
-// GetEventResponseCounts returns the counts of going and not going responses
-func (db *DB) GetEventResponseCounts(eventID int64) (going int, notGoing int) {
+// GetEventResponseCounts returns the counts of going, not going and maybe responses
+func (db *DB) GetEventResponseCounts(eventID int64, occurrenceDate string) (going int, notGoing int, maybe int) {
 	query := `SELECT 
-	            SUM(CASE WHEN response = 'going' THEN 1 ELSE 0 END) as going,
-	            SUM(CASE WHEN response = 'not_going' THEN 1 ELSE 0 END) as not_going
+	            COALESCE(SUM(CASE WHEN response = 'going' THEN 1 ELSE 0 END), 0) as going,
+	            COALESCE(SUM(CASE WHEN response = 'not_going' THEN 1 ELSE 0 END), 0) as not_going,
+	            COALESCE(SUM(CASE WHEN response = 'maybe' THEN 1 ELSE 0 END), 0) as maybe
 	          FROM group_event_responses 
-	          WHERE event_id = ?`
+	          WHERE event_id = ? AND occurrence_date = ?`
 
-	db.QueryRow(query, eventID).Scan(&going, &notGoing)
+	db.QueryRow(query, eventID, occurrenceDate).Scan(&going, &notGoing, &maybe)
 	return
 }
 
 // GetUserEventResponse gets a user's response to a specific event
-func (db *DB) GetUserEventResponse(eventID, userID int64) string {
+func (db *DB) GetUserEventResponse(eventID, userID int64, occurrenceDate string) string {
 	var response string
-	query := `SELECT response FROM group_event_responses WHERE event_id = ? AND user_id = ?`
-	db.QueryRow(query, eventID, userID).Scan(&response)
+	query := `SELECT response FROM group_event_responses WHERE event_id = ? AND user_id = ? AND occurrence_date = ?`
+	db.QueryRow(query, eventID, userID, occurrenceDate).Scan(&response)
 	return response
 }
 
-// GetEventResponses retrieves all responses for an event
-func (db *DB) GetEventResponses(eventID int64) ([]*GroupEventResponse, error) {
-	query := `SELECT ger.id, ger.event_id, ger.user_id, ger.response, ger.created_at, ger.updated_at
+// GetEventResponses retrieves all responses for an event (or one occurrence of a
+// recurring event) along with the responding users' details
+func (db *DB) GetEventResponses(eventID int64, occurrenceDate string) ([]*GroupEventResponse, error) {
+	query := `SELECT ger.id, ger.event_id, ger.user_id, ger.occurrence_date, ger.response, ger.created_at, ger.updated_at,
+	                 u.first_name, u.last_name, COALESCE(u.avatar, '')
 	          FROM group_event_responses ger
-	          WHERE ger.event_id = ?
+	          JOIN users u ON ger.user_id = u.id
+	          WHERE ger.event_id = ? AND ger.occurrence_date = ?
 	          ORDER BY ger.created_at DESC`
 
-	rows, err := db.Query(query, eventID)
+	rows, err := db.Query(query, eventID, occurrenceDate)
 	if err != nil {
 		return nil, err
 	}
@@ -1156,8 +2709,8 @@ func (db *DB) GetEventResponses(eventID int64) ([]*GroupEventResponse, error) {
 	for rows.Next() {
 		var response GroupEventResponse
 		if err := rows.Scan(
-			&response.ID, &response.EventID, &response.UserID, &response.Response,
-			&response.CreatedAt, &response.UpdatedAt,
+			&response.ID, &response.EventID, &response.UserID, &response.OccurrenceDate, &response.Response,
+			&response.CreatedAt, &response.UpdatedAt, &response.FirstName, &response.LastName, &response.Avatar,
 		); err != nil {
 			return nil, err
 		}
@@ -1182,6 +2735,18 @@ func (db *DB) DeleteGroupEvent(eventID int64) error {
 		return err
 	}
 
+	// Delete skipped occurrences of recurring events
+	_, err = tx.Exec(`DELETE FROM group_event_exceptions WHERE event_id = ?`, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Keep the event's discussion posts as regular group posts
+	_, err = tx.Exec(`UPDATE group_posts SET event_id = NULL WHERE event_id = ?`, eventID)
+	if err != nil {
+		return err
+	}
+
 	// Delete the event itself
 	_, err = tx.Exec(`DELETE FROM group_events WHERE id = ?`, eventID)
 	if err != nil {
@@ -1192,6 +2757,53 @@ func (db *DB) DeleteGroupEvent(eventID int64) error {
 	return tx.Commit()
 }
 
+// GetEventExceptions returns the occurrence dates that were removed from a recurring event
+func (db *DB) GetEventExceptions(eventID int64) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT occurrence_date FROM group_event_exceptions WHERE event_id = ?`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := make(map[string]bool)
+	for rows.Next() {
+		var occurrenceDate string
+		if err := rows.Scan(&occurrenceDate); err != nil {
+			return nil, err
+		}
+		if len(occurrenceDate) >= 10 {
+			occurrenceDate = occurrenceDate[:10]
+		}
+		exceptions[occurrenceDate] = true
+	}
+
+	return exceptions, rows.Err()
+}
+
+// DeleteGroupEventOccurrence removes a single occurrence of a recurring event
+// by recording an exception and clearing that occurrence's responses
+func (db *DB) DeleteGroupEventOccurrence(eventID int64, occurrenceDate string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM group_event_responses WHERE event_id = ? AND occurrence_date = ?`,
+		eventID, occurrenceDate)
+	if err != nil {
+		return fmt.Errorf("failed to delete occurrence responses: %v", err)
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO group_event_exceptions (event_id, occurrence_date) VALUES (?, ?)`,
+		eventID, occurrenceDate)
+	if err != nil {
+		return fmt.Errorf("failed to add event exception: %v", err)
+	}
+
+	return tx.Commit()
+}
+
 // Group Chat Functions
 
 // CreateGroupConversation creates a chat conversation for a group
@@ -1312,18 +2924,39 @@ func (db *DB) DeleteGroupPost(postID int64) error {
 	}
 	defer tx.Rollback()
 
-	// Delete all comments associated with the post
+	// Delete the votes and reports on the post's comments, then the comments themselves
+	for _, table := range []string{"votes", "reports"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE post_id = ?)", postID)
+		if err != nil {
+			return fmt.Errorf("failed to delete comment %s: %v", table, err)
+		}
+	}
+
 	_, err = tx.Exec("DELETE FROM group_post_comments WHERE post_id = ?", postID)
 	if err != nil {
 		return fmt.Errorf("failed to delete post comments: %v", err)
 	}
 
+	// Delete the votes and reports on the post
+	for _, table := range []string{"votes", "reports"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE content_type = 'group_post' AND content_id = ?", postID)
+		if err != nil {
+			return fmt.Errorf("failed to delete post %s: %v", table, err)
+		}
+	}
+
 	// Delete all likes/votes associated with the post
 	_, err = tx.Exec("DELETE FROM group_post_likes WHERE post_id = ?", postID)
 	if err != nil {
 		return fmt.Errorf("failed to delete post likes: %v", err)
 	}
 
+	// Delete the members' read markers for the post
+	_, err = tx.Exec("DELETE FROM group_post_reads WHERE post_id = ?", postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post read markers: %v", err)
+	}
+
 	// Delete the post itself
 	result, err := tx.Exec("DELETE FROM group_posts WHERE id = ?", postID)
 	if err != nil {
@@ -1341,3 +2974,225 @@ func (db *DB) DeleteGroupPost(postID int64) error {
 
 	return tx.Commit()
 }
+
+// SoftDeleteGroupPost hides a group post until it is restored or purged after DeletedPostGracePeriod
+func (db *DB) SoftDeleteGroupPost(postID, deletedBy int64) error {
+	result, err := db.Exec(`UPDATE group_posts SET is_deleted = 1, deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+	          WHERE id = ? AND COALESCE(is_deleted, 0) = 0`, deletedBy, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	return nil
+}
+
+// RestoreGroupPost undoes a soft delete. Only the user who deleted the post can restore it,
+// and only within DeletedPostGracePeriod
+func (db *DB) RestoreGroupPost(postID, userID int64) error {
+	var withinWindow bool
+	err := db.QueryRow(`SELECT deleted_at > datetime('now', ?) FROM group_posts
+	          WHERE id = ? AND deleted_by = ? AND is_deleted = 1`,
+		gracePeriodModifier(), postID, userID).Scan(&withinWindow)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
+		return fmt.Errorf("failed to get deleted post: %v", err)
+	}
+
+	if !withinWindow {
+		return fmt.Errorf("restore window expired")
+	}
+
+	_, err = db.Exec(`UPDATE group_posts SET is_deleted = 0, deleted_at = NULL, deleted_by = NULL WHERE id = ?`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to restore post: %v", err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedGroupPosts permanently deletes group posts whose grace period has passed.
+// It returns the image paths of the purged posts and their comments so the files can be removed
+func (db *DB) PurgeDeletedGroupPosts() (*PurgedImages, error) {
+	postIDs, images, err := db.expiredDeletedPosts(
+		`SELECT id, COALESCE(image_path, '') FROM group_posts WHERE is_deleted = 1 AND deleted_at <= datetime('now', ?)`,
+		`SELECT image_path FROM group_post_comments WHERE post_id = ? AND image_path IS NOT NULL AND image_path != ''`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, postID := range postIDs {
+		if err := db.DeleteGroupPost(postID); err != nil {
+			return nil, fmt.Errorf("failed to purge group post %d: %v", postID, err)
+		}
+	}
+
+	return images, nil
+}
+
+// DeletedGroupContent describes what LeaveGroupAndDeleteContent removed. Images
+// are returned so the files can be deleted once the rows are gone
+type DeletedGroupContent struct {
+	Posts    int64
+	Comments int64
+	Images   PurgedImages
+}
+
+// LeaveGroupAndDeleteContent removes a user from a group and permanently deletes their
+// posts and comments in it, along with replies to their comments and everything attached
+// to their posts, including reports. It all happens in one transaction so the user
+// never leaves with only part of their content deleted. Comment counts of the
+// remaining posts are recalculated
+func (db *DB) LeaveGroupAndDeleteContent(groupID, userID int64) (*DeletedGroupContent, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Comments on the user's posts, the user's own comments and replies to them
+	const commentsToDelete = `SELECT id FROM group_post_comments
+	          WHERE post_id IN (SELECT id FROM group_posts WHERE group_id = ?1 AND author_id = ?2)
+	          OR id IN (SELECT c.id FROM group_post_comments c JOIN group_posts p ON c.post_id = p.id
+	                    WHERE p.group_id = ?1 AND c.author_id = ?2)
+	          OR parent_comment_id IN (SELECT c.id FROM group_post_comments c JOIN group_posts p ON c.post_id = p.id
+	                                   WHERE p.group_id = ?1 AND c.author_id = ?2)`
+	const postsToDelete = `SELECT id FROM group_posts WHERE group_id = ?1 AND author_id = ?2`
+
+	deleted := &DeletedGroupContent{}
+	imageQueries := []struct {
+		query  string
+		images *[]string
+	}{
+		{"SELECT image_path FROM group_posts WHERE id IN (" + postsToDelete + ") AND COALESCE(image_path, '') != ''", &deleted.Images.Posts},
+		{"SELECT image_path FROM group_post_comments WHERE id IN (" + commentsToDelete + ") AND COALESCE(image_path, '') != ''", &deleted.Images.Comments},
+	}
+	for _, imageQuery := range imageQueries {
+		rows, err := tx.Query(imageQuery.query, groupID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get images: %v", err)
+		}
+		for rows.Next() {
+			var image string
+			if err := rows.Scan(&image); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan image: %v", err)
+			}
+			*imageQuery.images = append(*imageQuery.images, image)
+		}
+		rows.Close()
+	}
+
+	steps := []struct {
+		query string
+		name  string
+	}{
+		{"DELETE FROM votes WHERE content_type = 'group_post_comment' AND content_id IN (" + commentsToDelete + ")", "comment votes"},
+		{"DELETE FROM votes WHERE content_type = 'group_post' AND content_id IN (" + postsToDelete + ")", "post votes"},
+		{"DELETE FROM reports WHERE content_type = 'group_post_comment' AND content_id IN (" + commentsToDelete + ")", "comment reports"},
+		{"DELETE FROM reports WHERE content_type = 'group_post' AND content_id IN (" + postsToDelete + ")", "post reports"},
+		{"DELETE FROM group_post_likes WHERE post_id IN (" + postsToDelete + ")", "post likes"},
+		{"DELETE FROM group_post_reads WHERE post_id IN (" + postsToDelete + ")", "post read markers"},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, groupID, userID); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %v", step.name, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM group_post_comments WHERE id IN ("+commentsToDelete+")", groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete comments: %v", err)
+	}
+	deleted.Comments, err = result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	result, err = tx.Exec("DELETE FROM group_posts WHERE group_id = ? AND author_id = ?", groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete posts: %v", err)
+	}
+	deleted.Posts, err = result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	// Recount comments on the posts that remain in the group
+	_, err = tx.Exec(`UPDATE group_posts SET comments_count = (SELECT COUNT(*) FROM group_post_comments WHERE post_id = group_posts.id)
+	                  WHERE group_id = ?`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment counts: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to leave group: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return deleted, nil
+}
+
+// GetGroupActivity returns the group's posts, events and member joins merged into
+// one list, newest first
+func (db *DB) GetGroupActivity(groupID int64, limit, offset int) ([]*GroupActivity, error) {
+	query := `SELECT 'post' AS activity_type, p.id, p.author_id, u.first_name || ' ' || u.last_name,
+	                 COALESCE(u.avatar, ''), '', p.content, p.created_at AS created_at
+	          FROM group_posts p
+	          JOIN users u ON p.author_id = u.id
+	          WHERE p.group_id = ?1 AND COALESCE(p.is_draft, 0) = 0 AND COALESCE(p.is_deleted, 0) = 0
+	          UNION ALL
+	          SELECT 'event', e.id, e.creator_id, u.first_name || ' ' || u.last_name,
+	                 COALESCE(u.avatar, ''), e.title, COALESCE(e.description, ''), e.created_at
+	          FROM group_events e
+	          JOIN users u ON e.creator_id = u.id
+	          WHERE e.group_id = ?1
+	          UNION ALL
+	          SELECT 'member_joined', m.user_id, m.user_id, u.first_name || ' ' || u.last_name,
+	                 COALESCE(u.avatar, ''), '', '', m.joined_at
+	          FROM group_members m
+	          JOIN users u ON m.user_id = u.id
+	          WHERE m.group_id = ?1
+	          ORDER BY created_at DESC
+	          LIMIT ?2 OFFSET ?3`
+
+	rows, err := db.Query(query, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group activity: %v", err)
+	}
+	defer rows.Close()
+
+	activities := []*GroupActivity{}
+	for rows.Next() {
+		var activity GroupActivity
+		if err := rows.Scan(
+			&activity.ActivityType,
+			&activity.ID,
+			&activity.ActorID,
+			&activity.ActorName,
+			&activity.ActorAvatar,
+			&activity.Title,
+			&activity.Content,
+			&activity.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan group activity: %v", err)
+		}
+		activities = append(activities, &activity)
+	}
+
+	return activities, rows.Err()
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"fmt"
+	"testing"
+)
+
+const (
+	benchUsers         = 100
+	benchConversations = 20
+	benchRowsPerTable  = 5000
+)
+
+// seedHotPathTables fills the tables behind the hot query paths with a few thousand rows each
+func seedHotPathTables(b *testing.B, db *DB) {
+	b.Helper()
+
+	userIDs := make([]int, benchUsers)
+	for i := range userIDs {
+		userIDs[i] = createTestUser(b, db, fmt.Sprintf("bench%d", i))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		b.Fatalf("Failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i := 1; i <= benchConversations; i++ {
+		if _, err := tx.Exec(`INSERT INTO chat_conversations (id) VALUES (?)`, i); err != nil {
+			b.Fatalf("Failed to create conversation: %v", err)
+		}
+	}
+
+	for i := 0; i < benchRowsPerTable; i++ {
+		userID := userIDs[i%benchUsers]
+
+		_, err := tx.Exec(`INSERT INTO chat_messages (conversation_id, sender_id, content) VALUES (?, ?, ?)`,
+			i%benchConversations+1, userID, "message")
+		if err != nil {
+			b.Fatalf("Failed to create message: %v", err)
+		}
+
+		_, err = tx.Exec(`INSERT INTO notifications (receiver_id, sender_id, type, content) VALUES (?, ?, 'system', ?)`,
+			userID, userIDs[(i+1)%benchUsers], "notification")
+		if err != nil {
+			b.Fatalf("Failed to create notification: %v", err)
+		}
+
+		// Each user votes once on each of the first benchRowsPerTable/benchUsers posts
+		_, err = tx.Exec(`INSERT INTO votes (user_id, content_id, content_type, vote_type) VALUES (?, ?, 'post', 1)`,
+			userID, i/benchUsers+1)
+		if err != nil {
+			b.Fatalf("Failed to create vote: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("Failed to commit seed data: %v", err)
+	}
+}
+
+// BenchmarkHotPathQueries compares the hot lookups with and without hotPathIndexes
+func BenchmarkHotPathQueries(b *testing.B) {
+	queries := []struct {
+		name  string
+		query string
+		arg   int
+	}{
+		{"votes by content", `SELECT COUNT(*) FROM votes WHERE content_id = ? AND content_type = 'post'`, 25},
+		{"notifications by receiver", `SELECT id FROM notifications WHERE receiver_id = ? ORDER BY created_at DESC LIMIT 20`, 50},
+		{"chat messages by conversation", `SELECT id FROM chat_messages WHERE conversation_id = ? ORDER BY created_at ASC LIMIT 50`, 10},
+	}
+
+	for _, indexed := range []bool{false, true} {
+		name := "without indexes"
+		if indexed {
+			name = "with indexes"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			db := newTestDB(b)
+			seedHotPathTables(b, db)
+
+			if !indexed {
+				for _, index := range hotPathIndexes {
+					if _, err := db.Exec(`DROP INDEX IF EXISTS ` + index.name); err != nil {
+						b.Fatalf("Failed to drop index %s: %v", index.name, err)
+					}
+				}
+			}
+
+			for _, q := range queries {
+				b.Run(q.name, func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						rows, err := db.Query(q.query, q.arg)
+						if err != nil {
+							b.Fatalf("Query failed: %v", err)
+						}
+						for rows.Next() {
+						}
+						rows.Close()
+					}
+				})
+			}
+		})
+	}
+}
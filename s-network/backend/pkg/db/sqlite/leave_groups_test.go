@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestLeaveAllGroupsKeepsOwnedGroups(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+	member := int64(createTestUser(t, db, "member"))
+
+	joinedID, err := db.CreateGroup(&Group{Name: "Joined", CreatorID: owner, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := db.AddGroupMember(joinedID, member, "member"); err != nil {
+		t.Fatalf("Failed to add group member: %v", err)
+	}
+
+	ownedID, err := db.CreateGroup(&Group{Name: "Owned", CreatorID: member, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	left, owned, err := db.LeaveAllGroups(member)
+	if err != nil {
+		t.Fatalf("LeaveAllGroups returned error: %v", err)
+	}
+	if len(left) != 1 || left[0] != joinedID {
+		t.Errorf("Left groups %v, want [%d]", left, joinedID)
+	}
+	if len(owned) != 1 || owned[0] != ownedID {
+		t.Errorf("Owned groups %v, want [%d]", owned, ownedID)
+	}
+
+	if db.IsGroupMember(joinedID, member) {
+		t.Error("User is still a member of the joined group")
+	}
+	if !db.IsGroupMember(ownedID, member) {
+		t.Error("User is no longer a member of the group they created")
+	}
+	if !db.IsGroupMember(joinedID, owner) {
+		t.Error("Other members were removed from the joined group")
+	}
+}
+
+func TestLeaveGroupAndDeleteContentRemovesReports(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+	member := int64(createTestUser(t, db, "member"))
+
+	groupID, err := db.CreateGroup(&Group{Name: "Group", CreatorID: owner, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := db.AddGroupMember(groupID, member, "member"); err != nil {
+		t.Fatalf("Failed to add group member: %v", err)
+	}
+
+	postID, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: member, Content: "Hello", ImagePath: "/uploads/groups/post.jpg"})
+	if err != nil {
+		t.Fatalf("Failed to create group post: %v", err)
+	}
+	if _, err := db.CreateReport(owner, postID, "group_post", "spam"); err != nil {
+		t.Fatalf("Failed to report post: %v", err)
+	}
+
+	deleted, err := db.LeaveGroupAndDeleteContent(groupID, member)
+	if err != nil {
+		t.Fatalf("LeaveGroupAndDeleteContent returned error: %v", err)
+	}
+	if deleted.Posts != 1 {
+		t.Errorf("Deleted %d posts, want 1", deleted.Posts)
+	}
+	if len(deleted.Images.Posts) != 1 || deleted.Images.Posts[0] != "/uploads/groups/post.jpg" {
+		t.Errorf("Got post images %v, want the post image", deleted.Images.Posts)
+	}
+
+	if count := countRows(t, db, `SELECT COUNT(*) FROM reports WHERE content_type = 'group_post' AND content_id = ?`, postID); count != 0 {
+		t.Errorf("Got %d reports on the deleted post, want 0", count)
+	}
+	if db.IsGroupMember(groupID, member) {
+		t.Error("User is still a member after leaving")
+	}
+}
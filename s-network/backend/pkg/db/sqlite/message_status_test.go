@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestMarkMessagesDeliveredKeepsReadMessages(t *testing.T) {
+	db := newTestDB(t)
+	sender := int64(createTestUser(t, db, "sender"))
+	recipient := int64(createTestUser(t, db, "recipient"))
+
+	conversationID, err := db.CreateConversation(&ChatConversation{})
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	for _, userID := range []int64{sender, recipient} {
+		if err := db.AddParticipant(conversationID, userID); err != nil {
+			t.Fatalf("Failed to add participant: %v", err)
+		}
+	}
+
+	var messageIDs []int64
+	for _, senderID := range []int64{sender, sender, recipient} {
+		id, err := db.CreateMessage(&ChatMessage{ConversationID: conversationID, SenderID: senderID, Content: "Hi"})
+		if err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+		messageIDs = append(messageIDs, id)
+	}
+
+	if err := db.UpsertMessageStatus(messageIDs[0], recipient, MessageStatusRead); err != nil {
+		t.Fatalf("Failed to mark message read: %v", err)
+	}
+
+	if err := db.MarkMessagesDelivered(recipient, messageIDs); err != nil {
+		t.Fatalf("MarkMessagesDelivered returned error: %v", err)
+	}
+
+	want := []string{MessageStatusRead, MessageStatusDelivered}
+	for i, status := range want {
+		if got, _ := db.GetMessageStatus(messageIDs[i]); got != status {
+			t.Errorf("Message %d is %s, want %s", i, got, status)
+		}
+	}
+
+	// The recipient's own message is not delivered to them
+	if count := countRows(t, db, `SELECT COUNT(*) FROM message_status WHERE message_id = ?`, messageIDs[2]); count != 0 {
+		t.Errorf("Got %d status rows for the recipient's own message, want 0", count)
+	}
+}
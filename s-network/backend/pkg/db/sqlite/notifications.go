@@ -17,6 +17,32 @@ type Notification struct {
 	ReferenceID int64     `json:"reference_id"`
 	IsRead      bool      `json:"is_read"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// Sender details for API responses
+	SenderFirstName string `json:"sender_first_name,omitempty"`
+	SenderLastName  string `json:"sender_last_name,omitempty"`
+	SenderAvatar    string `json:"sender_avatar,omitempty"`
+}
+
+// Notification categories users can turn off in their preferences
+const (
+	NotificationCategoryFollows      = "follows"
+	NotificationCategoryGroupInvites = "group_invites"
+	NotificationCategoryEvents       = "events"
+	NotificationCategoryComments     = "comments"
+	NotificationCategoryMentions     = "mentions"
+	NotificationCategoryMessages     = "messages"
+)
+
+// NotificationPreferences holds which notification categories a user receives
+type NotificationPreferences struct {
+	UserID       int64 `json:"user_id"`
+	Follows      bool  `json:"follows"`
+	GroupInvites bool  `json:"group_invites"`
+	Events       bool  `json:"events"`
+	Comments     bool  `json:"comments"`
+	Mentions     bool  `json:"mentions"`
+	Messages     bool  `json:"messages"`
 }
 
 // EnsureNotificationsTableExists ensures the notifications table exists
@@ -129,8 +155,108 @@ func (db *DB) CreateNotification(notification *Notification) (int64, error) {
 	return result.LastInsertId()
 }
 
+// GetNotificationPreferences returns a user's notification preferences.
+// Users who never saved any get every category enabled
+func (db *DB) GetNotificationPreferences(userID int64) (*NotificationPreferences, error) {
+	prefs := &NotificationPreferences{
+		UserID:       userID,
+		Follows:      true,
+		GroupInvites: true,
+		Events:       true,
+		Comments:     true,
+		Mentions:     true,
+		Messages:     true,
+	}
+
+	query := `SELECT follows, group_invites, events, comments, mentions, messages
+	          FROM notification_preferences WHERE user_id = ?`
+
+	err := db.QueryRow(query, userID).Scan(
+		&prefs.Follows,
+		&prefs.GroupInvites,
+		&prefs.Events,
+		&prefs.Comments,
+		&prefs.Mentions,
+		&prefs.Messages,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+
+	return prefs, nil
+}
+
+// UpdateNotificationPreferences saves a user's notification preferences
+func (db *DB) UpdateNotificationPreferences(prefs *NotificationPreferences) error {
+	query := `INSERT INTO notification_preferences (user_id, follows, group_invites, events, comments, mentions, messages, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	          ON CONFLICT(user_id) DO UPDATE SET
+	            follows = excluded.follows,
+	            group_invites = excluded.group_invites,
+	            events = excluded.events,
+	            comments = excluded.comments,
+	            mentions = excluded.mentions,
+	            messages = excluded.messages,
+	            updated_at = CURRENT_TIMESTAMP`
+
+	_, err := db.Exec(query, prefs.UserID, prefs.Follows, prefs.GroupInvites, prefs.Events,
+		prefs.Comments, prefs.Mentions, prefs.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to update notification preferences: %v", err)
+	}
+
+	return nil
+}
+
+// ShouldNotify reports whether a user wants notifications of the given category.
+// Unknown categories and lookup errors default to notifying
+func (db *DB) ShouldNotify(userID int64, category string) bool {
+	prefs, err := db.GetNotificationPreferences(userID)
+	if err != nil {
+		return true
+	}
+
+	switch category {
+	case NotificationCategoryFollows:
+		return prefs.Follows
+	case NotificationCategoryGroupInvites:
+		return prefs.GroupInvites
+	case NotificationCategoryEvents:
+		return prefs.Events
+	case NotificationCategoryComments:
+		return prefs.Comments
+	case NotificationCategoryMentions:
+		return prefs.Mentions
+	case NotificationCategoryMessages:
+		return prefs.Messages
+	default:
+		return true
+	}
+}
+
+// HasRecentNotification reports whether the sender already sent the receiver a notification
+// of the given type and reference within the window. Used to debounce repeated actions.
+func (db *DB) HasRecentNotification(receiverID, senderID int64, notificationType string, referenceID int64, window time.Duration) (bool, error) {
+	query := `SELECT COUNT(*) FROM notifications
+	          WHERE receiver_id = ? AND sender_id = ? AND type = ? AND reference_id = ?
+	          AND created_at >= datetime('now', ?)`
+
+	var count int
+	modifier := fmt.Sprintf("-%d seconds", int(window.Seconds()))
+	err := db.QueryRow(query, receiverID, senderID, notificationType, referenceID, modifier).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check recent notifications: %v", err)
+	}
+
+	return count > 0, nil
+}
+
 // CreateMessageNotification creates a notification for a new message
 func (db *DB) CreateMessageNotification(receiverID, senderID, conversationID int64, senderName string) (int64, error) {
+	if !db.ShouldNotify(receiverID, NotificationCategoryMessages) {
+		return 0, nil
+	}
+
 	notification := &Notification{
 		ReceiverID:  receiverID,
 		SenderID:    senderID,
@@ -289,6 +415,57 @@ func (db *DB) GetUserNotifications(userID int64, notificationType string, limit,
 	return notifications, nil
 }
 
+// GetNotifications retrieves a page of a user's notifications, newest first, with the
+// sender's details joined in. unreadOnly and typeFilter narrow the results when set
+func (db *DB) GetNotifications(userID int64, limit, offset int, unreadOnly bool, typeFilter string) ([]*Notification, error) {
+	query := `SELECT n.id, n.receiver_id, COALESCE(n.sender_id, 0), n.type, n.content, COALESCE(n.reference_id, 0), n.is_read, n.created_at,
+	                 COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), COALESCE(u.avatar, '')
+	          FROM notifications n
+	          LEFT JOIN users u ON n.sender_id = u.id
+	          WHERE n.receiver_id = ?`
+	args := []interface{}{userID}
+
+	if unreadOnly {
+		query += ` AND n.is_read = FALSE`
+	}
+	if typeFilter != "" {
+		query += ` AND n.type = ?`
+		args = append(args, typeFilter)
+	}
+
+	query += ` ORDER BY n.created_at DESC, n.id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		var notification Notification
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.ReceiverID,
+			&notification.SenderID,
+			&notification.Type,
+			&notification.Content,
+			&notification.ReferenceID,
+			&notification.IsRead,
+			&notification.CreatedAt,
+			&notification.SenderFirstName,
+			&notification.SenderLastName,
+			&notification.SenderAvatar,
+		); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, rows.Err()
+}
+
 // MarkNotificationAsRead marks a specific notification as read
 func (db *DB) MarkNotificationAsRead(id int64) error {
 	query := `UPDATE notifications SET is_read = TRUE WHERE id = ?`
@@ -298,11 +475,28 @@ func (db *DB) MarkNotificationAsRead(id int64) error {
 
 // MarkNotificationsAsRead marks all notifications of a user as read
 func (db *DB) MarkNotificationsAsRead(userID int64) error {
-	query := `UPDATE notifications SET is_read = TRUE WHERE receiver_id = ? AND is_read = FALSE`
-	_, err := db.Exec(query, userID)
+	_, err := db.MarkAllNotificationsRead(userID, "")
 	return err
 }
 
+// MarkAllNotificationsRead marks a user's unread notifications as read, optionally
+// only those of the given type, and returns how many were updated
+func (db *DB) MarkAllNotificationsRead(userID int64, notificationType string) (int64, error) {
+	query := `UPDATE notifications SET is_read = TRUE WHERE receiver_id = ? AND is_read = FALSE`
+	args := []interface{}{userID}
+	if notificationType != "" {
+		query += ` AND type = ?`
+		args = append(args, notificationType)
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // GetUnreadNotificationCount returns the number of unread notifications for a user
 func (db *DB) GetUnreadNotificationCount(userID int64) (int, error) {
 	// Ensure the table exists with correct schema
@@ -344,21 +538,39 @@ func (db *DB) GetUnreadNotificationCount(userID int64) (int, error) {
 	return count, nil
 }
 
-// DeleteNotification deletes a notification
-func (db *DB) DeleteNotification(id int64) error {
-	query := `DELETE FROM notifications WHERE id = ?`
-	_, err := db.Exec(query, id)
-	return err
+// DeleteNotification deletes a notification received by the given user
+func (db *DB) DeleteNotification(notificationID, userID int64) error {
+	query := `DELETE FROM notifications WHERE id = ? AND receiver_id = ?`
+	result, err := db.Exec(query, notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
 }
 
-// DeleteUserNotifications deletes all notifications for a user
-func (db *DB) DeleteUserNotifications(userID int64) error {
+// DeleteAllNotifications deletes all notifications for a user and returns how many were removed
+func (db *DB) DeleteAllNotifications(userID int64) (int64, error) {
 	query := `DELETE FROM notifications WHERE receiver_id = ?`
-	_, err := db.Exec(query, userID)
-	return err
+	result, err := db.Exec(query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %v", err)
+	}
+	return result.RowsAffected()
 }
 
-// CreateSystemNotification is a helper method to create a system notification
+// CreateSystemNotification is a helper method to create a system notification.
+// System notices belong to no preference category and are intentionally not gated by
+// ShouldNotify, so account and moderation notices always reach the user
 func (db *DB) CreateSystemNotification(userID int64, content string) (int64, error) {
 	notification := &Notification{
 		ReceiverID: userID,
@@ -372,6 +584,10 @@ func (db *DB) CreateSystemNotification(userID int64, content string) (int64, err
 
 // CreateGroupInviteNotification is a helper method to create a group invite notification
 func (db *DB) CreateGroupInviteNotification(userID, senderID, groupID int64, groupName, senderName string) (int64, error) {
+	if !db.ShouldNotify(userID, NotificationCategoryGroupInvites) {
+		return 0, nil
+	}
+
 	notification := &Notification{
 		ReceiverID:  userID,
 		SenderID:    senderID,
@@ -386,6 +602,10 @@ func (db *DB) CreateGroupInviteNotification(userID, senderID, groupID int64, gro
 
 // CreatePostLikeNotification is a helper method to create a post like notification
 func (db *DB) CreatePostLikeNotification(userID, senderID, postID int64, senderName string) (int64, error) {
+	if !db.ShouldNotify(userID, NotificationCategoryComments) {
+		return 0, nil
+	}
+
 	notification := &Notification{
 		ReceiverID:  userID,
 		Type:        "post_like",
@@ -399,6 +619,10 @@ func (db *DB) CreatePostLikeNotification(userID, senderID, postID int64, senderN
 
 // CreatePostCommentNotification is a helper method to create a post comment notification
 func (db *DB) CreatePostCommentNotification(userID, senderID, postID int64, senderName string) (int64, error) {
+	if !db.ShouldNotify(userID, NotificationCategoryComments) {
+		return 0, nil
+	}
+
 	notification := &Notification{
 		ReceiverID:  userID,
 		Type:        "post_comment",
@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+// createTestGroupPosts creates a public group with count posts, oldest first
+func createTestGroupPosts(t *testing.T, db *DB, ownerID int64, count int) (int64, []int64) {
+	t.Helper()
+
+	groupID, err := db.CreateGroup(&Group{Name: "Group", CreatorID: ownerID, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	var postIDs []int64
+	for i := 0; i < count; i++ {
+		postID, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: ownerID, Content: "Hello"})
+		if err != nil {
+			t.Fatalf("Failed to create group post: %v", err)
+		}
+		postIDs = append(postIDs, postID)
+	}
+
+	return groupID, postIDs
+}
+
+func TestSetGroupPostPinnedEnforcesLimit(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+	_, postIDs := createTestGroupPosts(t, db, owner, MaxPinnedGroupPosts+1)
+
+	for _, postID := range postIDs[:MaxPinnedGroupPosts] {
+		if err := db.SetGroupPostPinned(postID, true); err != nil {
+			t.Fatalf("SetGroupPostPinned returned error: %v", err)
+		}
+	}
+
+	err := db.SetGroupPostPinned(postIDs[MaxPinnedGroupPosts], true)
+	if err == nil || err.Error() != "pin limit reached" {
+		t.Errorf("Pinning past the limit returned %v, want pin limit reached", err)
+	}
+
+	// Pinning an already pinned post is still allowed
+	if err := db.SetGroupPostPinned(postIDs[0], true); err != nil {
+		t.Errorf("Re-pinning a pinned post returned error: %v", err)
+	}
+
+	if err := db.SetGroupPostPinned(999999, true); err == nil || err.Error() != "post not found" {
+		t.Errorf("Pinning a missing post returned %v, want post not found", err)
+	}
+}
+
+func TestGetGroupPostsCursorKeepsPinnedFirstOrder(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+	groupID, postIDs := createTestGroupPosts(t, db, owner, 4)
+
+	// Pin the oldest post so it leads the feed
+	if err := db.SetGroupPostPinned(postIDs[0], true); err != nil {
+		t.Fatalf("SetGroupPostPinned returned error: %v", err)
+	}
+
+	first, err := db.GetGroupPosts(groupID, 2, 0, 0, owner)
+	if err != nil {
+		t.Fatalf("GetGroupPosts returned error: %v", err)
+	}
+	var seen []int64
+	for _, post := range first {
+		seen = append(seen, post.ID)
+	}
+
+	rest, err := db.GetGroupPosts(groupID, 10, 0, seen[len(seen)-1], owner)
+	if err != nil {
+		t.Fatalf("GetGroupPosts returned error: %v", err)
+	}
+	for _, post := range rest {
+		seen = append(seen, post.ID)
+	}
+
+	want := []int64{postIDs[0], postIDs[3], postIDs[2], postIDs[1]}
+	if len(seen) != len(want) {
+		t.Fatalf("Got posts %v across pages, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("Got posts %v across pages, want %v", seen, want)
+		}
+	}
+
+	// A page ending on the pinned post continues with every unpinned post
+	afterPinned, err := db.GetGroupPosts(groupID, 10, 0, postIDs[0], owner)
+	if err != nil {
+		t.Fatalf("GetGroupPosts returned error: %v", err)
+	}
+	if len(afterPinned) != 3 || afterPinned[0].ID != postIDs[3] {
+		t.Errorf("Got %d posts after the pinned cursor, want 3 starting with %d", len(afterPinned), postIDs[3])
+	}
+}
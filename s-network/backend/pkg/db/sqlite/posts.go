@@ -2,10 +2,21 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 )
 
 // CreatePost adds a new post to the database with title support
 func (db *DB) CreatePost(userID int, title string, content string, imageURL string, privacy string, allowedFollowers []int) (int64, error) {
+	return db.createPost(userID, title, content, imageURL, privacy, allowedFollowers, nil)
+}
+
+// CreateScheduledPost adds a post that stays hidden until PublishScheduledPosts runs after scheduledAt
+func (db *DB) CreateScheduledPost(userID int, title string, content string, imageURL string, privacy string, allowedFollowers []int, scheduledAt time.Time) (int64, error) {
+	return db.createPost(userID, title, content, imageURL, privacy, allowedFollowers, &scheduledAt)
+}
+
+func (db *DB) createPost(userID int, title string, content string, imageURL string, privacy string, allowedFollowers []int, scheduledAt *time.Time) (int64, error) {
 	// Ensure tables exist
 	if err := db.ensurePostTablesExist(); err != nil {
 		return 0, err
@@ -21,11 +32,19 @@ func (db *DB) CreatePost(userID int, title string, content string, imageURL stri
 		}
 	}()
 
+	// Scheduled posts are stored unpublished, with the time in SQLite's UTC format
+	var scheduled interface{}
+	isPublished := true
+	if scheduledAt != nil {
+		scheduled = scheduledAt.UTC().Format("2006-01-02 15:04:05")
+		isPublished = false
+	}
+
 	// Insert post with title
-	query := `INSERT INTO posts (user_id, title, content, image_url, privacy) 
-			  VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT INTO posts (user_id, title, content, image_url, privacy, scheduled_at, is_published) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
 	
-	result, err := tx.Exec(query, userID, title, content, imageURL, privacy)
+	result, err := tx.Exec(query, userID, title, content, imageURL, privacy, scheduled, isPublished)
 	if err != nil {
 		return 0, err
 	}
@@ -56,6 +75,245 @@ func (db *DB) CreatePost(userID int, title string, content string, imageURL stri
 	return postID, nil
 }
 
+// UpdatePost updates a post's title, content, privacy and image.
+// Access rows are cleared when the post is no longer private. For private posts
+// a non-nil allowedFollowers replaces the audience in the same transaction,
+// while nil keeps the current one.
+func (db *DB) UpdatePost(postID int64, title, content, privacy string, imageURL string, allowedFollowers []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// A new image makes the stored thumbnail stale, so it is dropped along with the old image
+	query := `UPDATE posts SET title = ?, content = ?, privacy = ?, image_url = ?,
+			         thumbnail_url = CASE WHEN COALESCE(image_url, '') = ? THEN thumbnail_url ELSE NULL END,
+			         updated_at = CURRENT_TIMESTAMP 
+			  WHERE id = ?`
+
+	result, err := tx.Exec(query, title, content, privacy, imageURL, imageURL, postID)
+	if err != nil {
+		return fmt.Errorf("failed to update post: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	if privacy != "private" {
+		_, err = tx.Exec("DELETE FROM post_access WHERE post_id = ?", postID)
+		if err != nil {
+			return fmt.Errorf("failed to clear post access: %v", err)
+		}
+	} else if allowedFollowers != nil {
+		if err := replacePostAccess(tx, postID, allowedFollowers); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetPostThumbnail stores the URL of the downscaled copy of a post's image
+func (db *DB) SetPostThumbnail(postID int64, thumbnailURL string) error {
+	_, err := db.Exec(`UPDATE posts SET thumbnail_url = ? WHERE id = ?`, thumbnailURL, postID)
+	if err != nil {
+		return fmt.Errorf("failed to set post thumbnail: %v", err)
+	}
+
+	return nil
+}
+
+// SetPostAccess replaces the list of followers allowed to see a private post
+func (db *DB) SetPostAccess(postID int64, allowedFollowers []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replacePostAccess(tx, postID, allowedFollowers); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replacePostAccess swaps a post's access rows for the given followers within tx
+func replacePostAccess(tx *sql.Tx, postID int64, allowedFollowers []int) error {
+	// Clear stale access rows first
+	_, err := tx.Exec("DELETE FROM post_access WHERE post_id = ?", postID)
+	if err != nil {
+		return fmt.Errorf("failed to clear post access: %v", err)
+	}
+
+	for _, followerID := range allowedFollowers {
+		_, err := tx.Exec(
+			"INSERT OR IGNORE INTO post_access (post_id, follower_id) VALUES (?, ?)",
+			postID, followerID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add post access: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// PublishScheduledPosts publishes scheduled posts whose time has passed and returns their IDs.
+// created_at is moved to the scheduled time so they sort as if posted then.
+func (db *DB) PublishScheduledPosts() ([]int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM posts 
+			  WHERE is_published = 0 AND scheduled_at IS NOT NULL AND scheduled_at <= datetime('now')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due scheduled posts: %v", err)
+	}
+
+	var postIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		postIDs = append(postIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, postID := range postIDs {
+		_, err := tx.Exec(`UPDATE posts SET is_published = 1, created_at = scheduled_at WHERE id = ?`, postID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish scheduled posts: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return postIDs, nil
+}
+
+// GetScheduledPosts returns a user's posts that are waiting to be published, soonest first
+func (db *DB) GetScheduledPosts(userID int) ([]map[string]interface{}, error) {
+	query := `
+		SELECT id, title, content, image_url, privacy, scheduled_at, created_at
+		FROM posts
+		WHERE user_id = ? AND is_published = 0 AND scheduled_at IS NOT NULL
+		ORDER BY scheduled_at ASC
+	`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled posts: %v", err)
+	}
+	defer rows.Close()
+
+	posts := []map[string]interface{}{}
+
+	for rows.Next() {
+		var id int64
+		var title, content, privacy string
+		var imageURL sql.NullString
+		var scheduledAt, createdAt time.Time
+
+		err := rows.Scan(&id, &title, &content, &imageURL, &privacy, &scheduledAt, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		post := map[string]interface{}{
+			"id":           id,
+			"title":        title,
+			"content":      content,
+			"privacy":      privacy,
+			"scheduled_at": scheduledAt,
+			"created_at":   createdAt,
+		}
+
+		if imageURL.Valid {
+			post["image_url"] = imageURL.String
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetPostAudience returns the users explicitly allowed to see a private post
+func (db *DB) GetPostAudience(postID int64) ([]map[string]interface{}, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.avatar
+		FROM post_access pa
+		JOIN users u ON pa.follower_id = u.id
+		WHERE pa.post_id = ?
+		ORDER BY u.first_name, u.last_name
+	`
+
+	rows, err := db.Query(query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post audience: %v", err)
+	}
+	defer rows.Close()
+
+	audience := []map[string]interface{}{}
+
+	for rows.Next() {
+		var id int
+		var firstName, lastName string
+		var avatar sql.NullString
+
+		err := rows.Scan(&id, &firstName, &lastName, &avatar)
+		if err != nil {
+			return nil, err
+		}
+
+		user := map[string]interface{}{
+			"id":         id,
+			"first_name": firstName,
+			"last_name":  lastName,
+		}
+
+		if avatar.Valid {
+			user["avatar"] = avatar.String
+		}
+
+		audience = append(audience, user)
+	}
+
+	return audience, rows.Err()
+}
+
+// HasPostAccess checks if a user was explicitly given access to a private post
+func (db *DB) HasPostAccess(postID int64, userID int) (bool, error) {
+	var exists int
+	err := db.QueryRow(`SELECT 1 FROM post_access WHERE post_id = ? AND follower_id = ?`, postID, userID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
 // ensurePostTablesExist makes sure all tables needed for posts exist
 func (db *DB) ensurePostTablesExist() error {
 	// This is just a safety check in case InitializeTables wasn't called
@@ -117,24 +375,28 @@ func (db *DB) GetPost(postID int64) (map[string]interface{}, error) {
 	}
 
 	query := `
-		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
+		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.thumbnail_url, p.privacy, p.created_at, p.updated_at, 
 		       p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
-		       (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
+		       (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count,
+		       COALESCE(p.is_published, 1), p.scheduled_at
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		WHERE p.id = ?
+		WHERE p.id = ? AND COALESCE(p.is_deleted, 0) = 0
 	`
 	
 	row := db.QueryRow(query, postID)
 	
 	var id, userID int64
 	var title, content, privacy, createdAt, updatedAt string
-	var imageURL, avatar sql.NullString
+	var imageURL, thumbnailURL, avatar sql.NullString
 	var firstName, lastName string
 	var upvotes, downvotes, commentCount int
+	var isPublished bool
+	var scheduledAt sql.NullTime
 	
-	err := row.Scan(&id, &userID, &title, &content, &imageURL, &privacy, &createdAt, &updatedAt, 
-	                &upvotes, &downvotes, &firstName, &lastName, &avatar, &commentCount)
+	err := row.Scan(&id, &userID, &title, &content, &imageURL, &thumbnailURL, &privacy, &createdAt, &updatedAt, 
+	                &upvotes, &downvotes, &firstName, &lastName, &avatar, &commentCount,
+	                &isPublished, &scheduledAt)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +411,8 @@ func (db *DB) GetPost(postID int64) (map[string]interface{}, error) {
 		"updated_at": updatedAt,
 		"upvotes":    upvotes,
 		"downvotes":  downvotes,
-		"comment_count": commentCount,
+		"comments_count": commentCount, // Named like the group post field so clients read both the same way
+		"is_published":  isPublished,
 		"author": map[string]interface{}{
 			"id":         userID,
 			"first_name": firstName,
@@ -160,11 +423,18 @@ func (db *DB) GetPost(postID int64) (map[string]interface{}, error) {
 	if imageURL.Valid {
 		post["image_url"] = imageURL.String
 	}
+	if thumbnailURL.Valid && thumbnailURL.String != "" {
+		post["thumbnail_url"] = thumbnailURL.String
+	}
 	
 	if avatar.Valid {
 		post["author"].(map[string]interface{})["avatar"] = avatar.String
 	}
 
+	if scheduledAt.Valid && !isPublished {
+		post["scheduled_at"] = scheduledAt.Time
+	}
+
 	return post, nil
 }
 
@@ -177,10 +447,35 @@ func (db *DB) GetPosts(userID int, page, limit int) ([]map[string]interface{}, e
 
 	offset := (page - 1) * limit
 
-	// Check if tables exist and construct appropriate query
-	var query string
-	var args []interface{}
+	// HOME FEED: Only user's posts and friends' posts
+	condition, args := db.homeFeedCondition(userID)
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.thumbnail_url, p.privacy, p.created_at, p.updated_at, 
+			p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0 AND (` + condition + `)
+		ORDER BY p.created_at DESC
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, limit, offset)
+
+	// Execute the query
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return db.scanPostList(rows, userID)
+}
 
+// homeFeedCondition builds the filter for posts shown in a user's home feed:
+// their own posts, followed users' public/almost_private posts and private posts
+// shared with them. Parts relying on the followers or post_access tables are only
+// included when those tables exist.
+func (db *DB) homeFeedCondition(userID int) (string, []interface{}) {
 	// Check if followers table exists
 	var followersExistQuery = "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='followers'"
 	var followersCount int
@@ -193,134 +488,59 @@ func (db *DB) GetPosts(userID int, page, limit int) ([]map[string]interface{}, e
 	err = db.QueryRow(accessExistQuery).Scan(&accessCount)
 	accessExist := err == nil && accessCount > 0
 
-	// Build query based on which tables exist - HOME FEED: Only user's posts and friends' posts
-	if !followersExist && !accessExist {
-		// Basic query - only user's own posts (no friends system available)
-		query = `
-			SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
-				p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
-				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			WHERE p.user_id = ?
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{userID, limit, offset}
-	} else if followersExist && !accessExist {
-		// Query with followers table - user's posts + friends' public/almost_private posts
-		query = `
-			SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
-				p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
-				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			WHERE 
-				p.user_id = ?
-				OR (p.privacy IN ('public', 'almost_private') AND EXISTS (
-					SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = p.user_id
-				))
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{userID, userID, limit, offset}
-	} else if !followersExist && accessExist {
-		// Query with post_access table - user's posts + accessible private posts
-		query = `
-			SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
-				p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
-				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			WHERE 
-				p.user_id = ?
-				OR (p.privacy = 'private' AND EXISTS (
-					SELECT 1 FROM post_access pa WHERE pa.post_id = p.id AND pa.follower_id = ?
-				))
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{userID, userID, limit, offset}
-	} else {
-		// Full query with both tables - user's posts + friends' posts + accessible private posts
-		query = `
-			SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
-				p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
-				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			WHERE 
-				p.user_id = ?
-				OR (p.privacy IN ('public', 'almost_private') AND EXISTS (
-					SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = p.user_id
-				))
-				OR (p.privacy = 'private' AND EXISTS (
-					SELECT 1 FROM post_access pa WHERE pa.post_id = p.id AND pa.follower_id = ?
-				))
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{userID, userID, userID, limit, offset}
+	condition := "p.user_id = ?"
+	args := []interface{}{userID}
+
+	if followersExist {
+		condition += `
+			OR (p.privacy IN ('public', 'almost_private') AND EXISTS (
+				SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = p.user_id
+			))`
+		args = append(args, userID)
 	}
-	
-	// Execute the query
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		return nil, err
+
+	if accessExist {
+		condition += `
+			OR (p.privacy = 'private' AND EXISTS (
+				SELECT 1 FROM post_access pa WHERE pa.post_id = p.id AND pa.follower_id = ?
+			))`
+		args = append(args, userID)
 	}
-	defer rows.Close()
 
-	posts := []map[string]interface{}{}
+	return condition, args
+}
 
-	for rows.Next() {
-		var id, postUserID int64
-		var title, content, privacy, createdAt, updatedAt string
-		var imageURL, avatar sql.NullString
-		var firstName, lastName string
-		var upvotes, downvotes, commentCount int
-		
-		err := rows.Scan(&id, &postUserID, &title, &content, &imageURL, &privacy, &createdAt, &updatedAt, 
-		                 &upvotes, &downvotes, &firstName, &lastName, &avatar, &commentCount)
-		if err != nil {
-			return nil, err
-		}
+// CountVisiblePosts returns the number of published posts in a user's home feed
+func (db *DB) CountVisiblePosts(userID int) (int, error) {
+	if err := db.ensurePostTablesExist(); err != nil {
+		return 0, err
+	}
 
-		post := map[string]interface{}{
-			"id":         id,
-			"user_id":    postUserID,
-			"title":      title,
-			"content":    content,
-			"privacy":    privacy,
-			"created_at": createdAt,
-			"updated_at": updatedAt,
-			"upvotes":    upvotes,
-			"downvotes":  downvotes,
-			"comment_count": commentCount,
-			"author": map[string]interface{}{
-				"id":         postUserID,
-				"first_name": firstName,
-				"last_name":  lastName,
-			},
-		}
+	condition, args := db.homeFeedCondition(userID)
+	query := `SELECT COUNT(*) FROM posts p
+	          WHERE COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0 AND (` + condition + `)`
 
-		if imageURL.Valid {
-			post["image_url"] = imageURL.String
-		}
-		
-		if avatar.Valid {
-			post["author"].(map[string]interface{})["avatar"] = avatar.String
-		}
+	var count int
+	if err := db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count posts: %v", err)
+	}
 
-		// Check user's vote on this post
-		userVote, err := db.GetUserVote(userID, id, "post")
-		if err == nil {
-			post["user_vote"] = userVote
-		}
+	return count, nil
+}
 
-		posts = append(posts, post)
+// CountExplorePosts returns the number of published public posts shown on the explore page
+func (db *DB) CountExplorePosts() (int, error) {
+	if err := db.ensurePostTablesExist(); err != nil {
+		return 0, err
+	}
+
+	var count int
+	query := `SELECT COUNT(*) FROM posts p WHERE p.privacy = 'public' AND COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0`
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count explore posts: %v", err)
 	}
 
-	return posts, nil
+	return count, nil
 }
 
 // GetExplorePosts retrieves all public posts for the explore page
@@ -334,12 +554,12 @@ func (db *DB) GetExplorePosts(userID int, page, limit int) ([]map[string]interfa
 
 	// Simple query that gets all public posts from all users
 	query := `
-		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.privacy, p.created_at, p.updated_at, 
+		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.thumbnail_url, p.privacy, p.created_at, p.updated_at, 
 			p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
 			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		WHERE p.privacy = 'public'
+		WHERE p.privacy = 'public' AND COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0
 		ORDER BY p.created_at DESC
 		LIMIT ? OFFSET ?
 	`
@@ -351,32 +571,110 @@ func (db *DB) GetExplorePosts(userID int, page, limit int) ([]map[string]interfa
 	}
 	defer rows.Close()
 
+	return db.scanPostList(rows, userID)
+} 
+// GetUserVisiblePosts retrieves a user's posts that the viewer is allowed to see.
+// Public posts are always visible, almost_private posts require the viewer to follow
+// the author and private posts require an entry in post_access.
+func (db *DB) GetUserVisiblePosts(targetID, viewerID int64, page, limit int) ([]map[string]interface{}, error) {
+	// Ensure tables exist
+	if err := db.ensurePostTablesExist(); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.thumbnail_url, p.privacy, p.created_at, p.updated_at, 
+			p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.user_id = ?1 AND COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0 AND (
+			p.user_id = ?2
+			OR p.privacy = 'public'
+			OR (p.privacy = 'almost_private' AND EXISTS (
+				SELECT 1 FROM followers f WHERE f.follower_id = ?2 AND f.following_id = p.user_id
+			))
+			OR (p.privacy = 'private' AND EXISTS (
+				SELECT 1 FROM post_access pa WHERE pa.post_id = p.id AND pa.follower_id = ?2
+			))
+		)
+		ORDER BY p.created_at DESC
+		LIMIT ?3 OFFSET ?4
+	`
+
+	rows, err := db.Query(query, targetID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user posts: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanPostList(rows, int(viewerID))
+}
+
+// GetTrendingPosts retrieves public posts ordered by how many votes they received
+// in the last week, newest first among posts with the same activity
+func (db *DB) GetTrendingPosts(userID int, page, limit int) ([]map[string]interface{}, error) {
+	// Ensure tables exist
+	if err := db.ensurePostTablesExist(); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.image_url, p.thumbnail_url, p.privacy, p.created_at, p.updated_at, 
+			p.upvotes, p.downvotes, u.first_name, u.last_name, u.avatar,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comment_count
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.privacy = 'public' AND COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0
+		ORDER BY (
+			SELECT COUNT(*) FROM votes v
+			WHERE v.content_type = 'post' AND v.content_id = p.id
+			AND v.created_at >= datetime('now', '-7 days')
+		) DESC, p.created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := db.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending posts: %v", err)
+	}
+	defer rows.Close()
+
+	return db.scanPostList(rows, userID)
+}
+
+// scanPostList scans post rows with author details and fills in the user's vote on each post
+func (db *DB) scanPostList(rows *sql.Rows, userID int) ([]map[string]interface{}, error) {
 	posts := []map[string]interface{}{}
 
 	for rows.Next() {
 		var id, postUserID int64
 		var title, content, privacy, createdAt, updatedAt string
-		var imageURL, avatar sql.NullString
+		var imageURL, thumbnailURL, avatar sql.NullString
 		var firstName, lastName string
 		var upvotes, downvotes, commentCount int
-		
-		err := rows.Scan(&id, &postUserID, &title, &content, &imageURL, &privacy, &createdAt, &updatedAt, 
-		                 &upvotes, &downvotes, &firstName, &lastName, &avatar, &commentCount)
+
+		err := rows.Scan(&id, &postUserID, &title, &content, &imageURL, &thumbnailURL, &privacy, &createdAt, &updatedAt,
+			&upvotes, &downvotes, &firstName, &lastName, &avatar, &commentCount)
 		if err != nil {
 			return nil, err
 		}
 
 		post := map[string]interface{}{
-			"id":         id,
-			"user_id":    postUserID,
-			"title":      title,
-			"content":    content,
-			"privacy":    privacy,
-			"created_at": createdAt,
-			"updated_at": updatedAt,
-			"upvotes":    upvotes,
-			"downvotes":  downvotes,
-			"comment_count": commentCount,
+			"id":             id,
+			"user_id":        postUserID,
+			"title":          title,
+			"content":        content,
+			"privacy":        privacy,
+			"created_at":     createdAt,
+			"updated_at":     updatedAt,
+			"upvotes":        upvotes,
+			"downvotes":      downvotes,
+			"comments_count": commentCount, // Named like the group post field so clients read both the same way
 			"author": map[string]interface{}{
 				"id":         postUserID,
 				"first_name": firstName,
@@ -387,7 +685,10 @@ func (db *DB) GetExplorePosts(userID int, page, limit int) ([]map[string]interfa
 		if imageURL.Valid {
 			post["image_url"] = imageURL.String
 		}
-		
+		if thumbnailURL.Valid && thumbnailURL.String != "" {
+			post["thumbnail_url"] = thumbnailURL.String
+		}
+
 		if avatar.Valid {
 			post["author"].(map[string]interface{})["avatar"] = avatar.String
 		}
@@ -398,8 +699,76 @@ func (db *DB) GetExplorePosts(userID int, page, limit int) ([]map[string]interfa
 			post["user_vote"] = userVote
 		}
 
+		post["is_bookmarked"] = db.IsBookmarked(userID, id)
+
 		posts = append(posts, post)
 	}
 
-	return posts, nil
-} 
\ No newline at end of file
+	return posts, rows.Err()
+}
+
+// AddBookmark saves a post for the user. Saving an already saved post is a no-op
+func (db *DB) AddBookmark(userID int, postID int64) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO bookmarks (user_id, post_id) VALUES (?, ?)`, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to add bookmark: %v", err)
+	}
+	return nil
+}
+
+// RemoveBookmark removes a saved post
+func (db *DB) RemoveBookmark(userID int, postID int64) error {
+	result, err := db.Exec(`DELETE FROM bookmarks WHERE user_id = ? AND post_id = ?`, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to remove bookmark: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bookmark not found")
+	}
+
+	return nil
+}
+
+// IsBookmarked checks if the user saved a post
+func (db *DB) IsBookmarked(userID int, postID int64) bool {
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM bookmarks WHERE user_id = ? AND post_id = ?`, userID, postID).Scan(&count)
+	return count > 0
+}
+
+// GetBookmarks returns the IDs of the posts a user saved and when they were saved, newest first.
+// Bookmarks are kept when the post is deleted or becomes hidden, so callers must check access.
+func (db *DB) GetBookmarks(userID int, limit, offset int) ([]map[string]interface{}, error) {
+	query := `SELECT post_id, created_at FROM bookmarks
+	          WHERE user_id = ?
+	          ORDER BY created_at DESC, post_id DESC
+	          LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks: %v", err)
+	}
+	defer rows.Close()
+
+	bookmarks := []map[string]interface{}{}
+	for rows.Next() {
+		var postID int64
+		var createdAt string
+		if err := rows.Scan(&postID, &createdAt); err != nil {
+			return nil, err
+		}
+
+		bookmarks = append(bookmarks, map[string]interface{}{
+			"post_id":       postID,
+			"bookmarked_at": createdAt,
+		})
+	}
+
+	return bookmarks, rows.Err()
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestPurgeDeletedPostsAfterGracePeriod(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	follower := createTestUser(t, db, "follower")
+
+	postID, err := db.CreatePost(owner, "Title", "Content", "/uploads/posts/post.jpg", "private", []int{follower})
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	commentID, err := db.AddComment(postID, int64(owner), "Comment", "/uploads/comments/comment.jpg")
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+
+	// Rows pointing at the post and its comment must go with them
+	if err := db.Vote(follower, postID, "post", 1); err != nil {
+		t.Fatalf("Failed to vote on post: %v", err)
+	}
+	if err := db.Vote(follower, commentID, "comment", 1); err != nil {
+		t.Fatalf("Failed to vote on comment: %v", err)
+	}
+	if _, err := db.CreateReport(int64(follower), postID, "post", "spam"); err != nil {
+		t.Fatalf("Failed to report post: %v", err)
+	}
+	if _, err := db.CreateReport(int64(follower), commentID, "comment", "spam"); err != nil {
+		t.Fatalf("Failed to report comment: %v", err)
+	}
+	if err := db.AddBookmark(follower, postID); err != nil {
+		t.Fatalf("Failed to bookmark post: %v", err)
+	}
+	if err := db.SoftDeletePost(postID, int64(owner)); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+
+	// Posts within the grace period can still be restored and are kept
+	purged, err := db.PurgeDeletedPosts()
+	if err != nil {
+		t.Fatalf("PurgeDeletedPosts returned error: %v", err)
+	}
+	if len(purged.Posts)+len(purged.Comments) != 0 {
+		t.Errorf("Purged images %+v within the grace period, want none", purged)
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE id = ?`, postID); count != 1 {
+		t.Fatalf("Got %d posts within the grace period, want 1", count)
+	}
+
+	if _, err := db.Exec(`UPDATE posts SET deleted_at = datetime('now', '-1 hour') WHERE id = ?`, postID); err != nil {
+		t.Fatalf("Failed to age deleted post: %v", err)
+	}
+
+	purged, err = db.PurgeDeletedPosts()
+	if err != nil {
+		t.Fatalf("PurgeDeletedPosts returned error: %v", err)
+	}
+	if len(purged.Posts) != 1 || purged.Posts[0] != "/uploads/posts/post.jpg" {
+		t.Errorf("Purged post images %v, want the post image only", purged.Posts)
+	}
+	if len(purged.Comments) != 1 || purged.Comments[0] != "/uploads/comments/comment.jpg" {
+		t.Errorf("Purged comment images %v, want the comment image only", purged.Comments)
+	}
+
+	if count := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE id = ?`, postID); count != 0 {
+		t.Errorf("Got %d posts after the grace period, want 0", count)
+	}
+	checks := []struct {
+		desc  string
+		query string
+	}{
+		{"comments", `SELECT COUNT(*) FROM comments WHERE post_id = ?1`},
+		{"votes", `SELECT COUNT(*) FROM votes WHERE (content_type = 'post' AND content_id = ?1) OR (content_type = 'comment' AND content_id = ?2)`},
+		{"reports", `SELECT COUNT(*) FROM reports WHERE (content_type = 'post' AND content_id = ?1) OR (content_type = 'comment' AND content_id = ?2)`},
+		{"post access rows", `SELECT COUNT(*) FROM post_access WHERE post_id = ?1`},
+		{"bookmarks", `SELECT COUNT(*) FROM bookmarks WHERE post_id = ?1`},
+	}
+	for _, check := range checks {
+		if count := countRows(t, db, check.query, postID, commentID); count != 0 {
+			t.Errorf("Got %d %s after the grace period, want 0", count, check.desc)
+		}
+	}
+}
+
+func TestPurgeDeletedGroupPostsRemovesVotesAndReports(t *testing.T) {
+	db := newTestDB(t)
+	owner := int64(createTestUser(t, db, "owner"))
+
+	groupID, err := db.CreateGroup(&Group{Name: "Group", CreatorID: owner, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	postID, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: owner, Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed to create group post: %v", err)
+	}
+	commentID, err := db.CreateGroupPostComment(&GroupPostComment{PostID: postID, AuthorID: owner, Content: "Comment"})
+	if err != nil {
+		t.Fatalf("Failed to create comment: %v", err)
+	}
+
+	for _, content := range []struct {
+		id          int64
+		contentType string
+	}{{postID, "group_post"}, {commentID, "group_post_comment"}} {
+		if err := db.Vote(int(owner), content.id, content.contentType, 1); err != nil {
+			t.Fatalf("Failed to vote on %s: %v", content.contentType, err)
+		}
+		if _, err := db.CreateReport(owner, content.id, content.contentType, "spam"); err != nil {
+			t.Fatalf("Failed to report %s: %v", content.contentType, err)
+		}
+	}
+
+	if err := db.SoftDeleteGroupPost(postID, owner); err != nil {
+		t.Fatalf("Failed to delete group post: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE group_posts SET deleted_at = datetime('now', '-1 hour') WHERE id = ?`, postID); err != nil {
+		t.Fatalf("Failed to age deleted post: %v", err)
+	}
+	if _, err := db.PurgeDeletedGroupPosts(); err != nil {
+		t.Fatalf("PurgeDeletedGroupPosts returned error: %v", err)
+	}
+
+	for _, table := range []string{"group_posts", "group_post_comments"} {
+		if count := countRows(t, db, `SELECT COUNT(*) FROM `+table+` WHERE id IN (?, ?)`, postID, commentID); count != 0 {
+			t.Errorf("Got %d rows in %s after the purge, want 0", count, table)
+		}
+	}
+	for _, table := range []string{"votes", "reports"} {
+		query := `SELECT COUNT(*) FROM ` + table + ` WHERE (content_type = 'group_post' AND content_id = ?) OR (content_type = 'group_post_comment' AND content_id = ?)`
+		if count := countRows(t, db, query, postID, commentID); count != 0 {
+			t.Errorf("Got %d %s after the purge, want 0", count, table)
+		}
+	}
+}
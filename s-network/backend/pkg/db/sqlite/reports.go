@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report represents a user's report of a post or comment
+type Report struct {
+	ID          int64     `json:"id"`
+	ReporterID  int64     `json:"reporter_id"`
+	ContentID   int64     `json:"content_id"`
+	ContentType string    `json:"content_type"`
+	Reason      string    `json:"reason"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Reporter details for API responses
+	ReporterFirstName string `json:"reporter_first_name,omitempty"`
+	ReporterLastName  string `json:"reporter_last_name,omitempty"`
+}
+
+// ReportContentTables maps each reportable content type to the table holding it
+var ReportContentTables = map[string]string{
+	"post":               "posts",
+	"comment":            "comments",
+	"group_post":         "group_posts",
+	"group_post_comment": "group_post_comments",
+}
+
+// ReportedContentExists checks that the content being reported exists
+func (db *DB) ReportedContentExists(contentID int64, contentType string) (bool, error) {
+	table, ok := ReportContentTables[contentType]
+	if !ok {
+		return false, fmt.Errorf("invalid content type: %s", contentType)
+	}
+
+	var exists int
+	err := db.QueryRow(`SELECT 1 FROM `+table+` WHERE id = ?`, contentID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateReport records a report. A user can only report the same content once.
+func (db *DB) CreateReport(reporterID, contentID int64, contentType, reason string) (int64, error) {
+	if _, ok := ReportContentTables[contentType]; !ok {
+		return 0, fmt.Errorf("invalid content type: %s", contentType)
+	}
+
+	query := `INSERT INTO reports (reporter_id, content_id, content_type, reason) VALUES (?, ?, ?, ?)`
+	result, err := db.Exec(query, reporterID, contentID, contentType, reason)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, fmt.Errorf("content already reported")
+		}
+		return 0, fmt.Errorf("failed to create report: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+const reportSelect = `SELECT r.id, r.reporter_id, r.content_id, r.content_type, r.reason, r.status, r.created_at,
+	                         u.first_name, u.last_name
+	                  FROM reports r
+	                  JOIN users u ON r.reporter_id = u.id`
+
+// GetGroupReports retrieves reports on posts and comments in a group, newest first
+func (db *DB) GetGroupReports(groupID int64) ([]*Report, error) {
+	query := reportSelect + `
+	          WHERE (r.content_type = 'group_post'
+	                 AND r.content_id IN (SELECT id FROM group_posts WHERE group_id = ?1))
+	             OR (r.content_type = 'group_post_comment'
+	                 AND r.content_id IN (SELECT c.id FROM group_post_comments c
+	                                      JOIN group_posts gp ON c.post_id = gp.id
+	                                      WHERE gp.group_id = ?1))
+	          ORDER BY r.created_at DESC`
+
+	rows, err := db.Query(query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group reports: %v", err)
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+// scanReports reads report rows selected with reportSelect
+func scanReports(rows *sql.Rows) ([]*Report, error) {
+	var reports []*Report
+	for rows.Next() {
+		var report Report
+		err := rows.Scan(&report.ID, &report.ReporterID, &report.ContentID, &report.ContentType,
+			&report.Reason, &report.Status, &report.CreatedAt,
+			&report.ReporterFirstName, &report.ReporterLastName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report: %v", err)
+		}
+		reports = append(reports, &report)
+	}
+
+	return reports, rows.Err()
+}
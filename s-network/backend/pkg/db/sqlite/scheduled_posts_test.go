@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishScheduledPostsReturnsDuePosts(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+
+	dueID, err := db.CreateScheduledPost(owner, "Due", "Content", "", "public", nil, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create scheduled post: %v", err)
+	}
+	laterID, err := db.CreateScheduledPost(owner, "Later", "Content", "", "public", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create scheduled post: %v", err)
+	}
+
+	published, err := db.PublishScheduledPosts()
+	if err != nil {
+		t.Fatalf("PublishScheduledPosts returned error: %v", err)
+	}
+	if len(published) != 1 || published[0] != dueID {
+		t.Errorf("Published posts %v, want [%d]", published, dueID)
+	}
+
+	if count := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE id = ? AND is_published = 0`, laterID); count != 1 {
+		t.Errorf("Post scheduled for later was published")
+	}
+
+	// Published posts are not returned again
+	if published, err := db.PublishScheduledPosts(); err != nil || len(published) != 0 {
+		t.Errorf("Second run published %v (err %v), want none", published, err)
+	}
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestSearchMessagesOnlyPagesThroughOwnConversations(t *testing.T) {
+	db := newTestDB(t)
+	user := int64(createTestUser(t, db, "user"))
+	other := int64(createTestUser(t, db, "other"))
+
+	createConversation := func(participants ...int64) int64 {
+		t.Helper()
+		conversationID, err := db.CreateConversation(&ChatConversation{})
+		if err != nil {
+			t.Fatalf("Failed to create conversation: %v", err)
+		}
+		for _, userID := range participants {
+			if err := db.AddParticipant(conversationID, userID); err != nil {
+				t.Fatalf("Failed to add participant: %v", err)
+			}
+		}
+		return conversationID
+	}
+	own := createConversation(user, other)
+	foreign := createConversation(other)
+
+	// Newer matches the user can't see must not take up room in the page
+	for _, conversationID := range []int64{own, own, foreign, foreign} {
+		if _, err := db.CreateMessage(&ChatMessage{ConversationID: conversationID, SenderID: other, Content: "hello"}); err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+	}
+
+	results, err := db.SearchMessages(user, "hello", 2, 0)
+	if err != nil {
+		t.Fatalf("SearchMessages returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Got %d results, want 2", len(results))
+	}
+	for _, result := range results {
+		if result.ConversationID != own {
+			t.Errorf("Got a result from conversation %d, want only %d", result.ConversationID, own)
+		}
+	}
+}
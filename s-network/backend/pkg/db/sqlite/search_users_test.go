@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"fmt"
+	"testing"
+)
+
+// createNamedTestUser inserts a user with the given first and last name and returns its ID
+func createNamedTestUser(t *testing.T, db *DB, firstName, lastName, nickname string) int64 {
+	t.Helper()
+
+	email := fmt.Sprintf("%s.%s@example.com", firstName, lastName)
+	userID, err := db.CreateUser(email, "password", firstName, lastName, "2000-01-01", "", nickname, "")
+	if err != nil {
+		t.Fatalf("Failed to create user %s %s: %v", firstName, lastName, err)
+	}
+
+	return userID
+}
+
+func searchResultNames(users []map[string]interface{}) []string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, fmt.Sprintf("%s %s", user["first_name"], user["last_name"]))
+	}
+	return names
+}
+
+func TestSearchUsersPrefixRanking(t *testing.T) {
+	db := newTestDB(t)
+	viewer := createNamedTestUser(t, db, "Viewer", "Person", "")
+	createNamedTestUser(t, db, "Bojo", "Smith", "")
+	createNamedTestUser(t, db, "Alice", "Jordan", "")
+	createNamedTestUser(t, db, "John", "Doe", "")
+	createNamedTestUser(t, db, "Zed", "Brown", "jolly")
+	createNamedTestUser(t, db, "Jo", "Exact", "")
+
+	users, err := db.SearchUsers("jo", viewer, 20)
+	if err != nil {
+		t.Fatalf("SearchUsers returned error: %v", err)
+	}
+
+	// Exact match, then first name or nickname prefixes, then last name prefixes, then anything containing the term
+	want := []string{"Jo Exact", "John Doe", "Zed Brown", "Alice Jordan", "Bojo Smith"}
+	got := searchResultNames(users)
+	if len(got) != len(want) {
+		t.Fatalf("Got results %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Got results %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSearchUsersLimit(t *testing.T) {
+	db := newTestDB(t)
+	viewer := createNamedTestUser(t, db, "Viewer", "Person", "")
+	for i := 0; i < 5; i++ {
+		createNamedTestUser(t, db, fmt.Sprintf("Sam%d", i), "Test", "")
+	}
+
+	users, err := db.SearchUsers("sam", viewer, 3)
+	if err != nil {
+		t.Fatalf("SearchUsers returned error: %v", err)
+	}
+	if len(users) != 3 {
+		t.Errorf("Got %d results, want 3", len(users))
+	}
+}
+
+func TestSearchUsersExcludesViewerAndBlocks(t *testing.T) {
+	db := newTestDB(t)
+	viewer := createNamedTestUser(t, db, "Jack", "Viewer", "")
+	blockedByViewer := createNamedTestUser(t, db, "Jack", "Blocked", "")
+	blockedViewer := createNamedTestUser(t, db, "Jack", "Blocker", "")
+	createNamedTestUser(t, db, "Jack", "Visible", "")
+
+	if err := db.BlockUser(viewer, blockedByViewer); err != nil {
+		t.Fatalf("Failed to block user: %v", err)
+	}
+	if err := db.BlockUser(blockedViewer, viewer); err != nil {
+		t.Fatalf("Failed to block user: %v", err)
+	}
+
+	users, err := db.SearchUsers("jack", viewer, 20)
+	if err != nil {
+		t.Fatalf("SearchUsers returned error: %v", err)
+	}
+
+	got := searchResultNames(users)
+	if len(got) != 1 || got[0] != "Jack Visible" {
+		t.Errorf("Got results %v, want [Jack Visible]", got)
+	}
+}
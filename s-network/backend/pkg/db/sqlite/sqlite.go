@@ -1,7 +1,9 @@
 package sqlite
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -135,6 +137,31 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Add scheduling columns to posts. Scheduled posts stay unpublished until their time passes
+	_, err = db.Exec(`ALTER TABLE posts ADD COLUMN scheduled_at DATETIME`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE posts ADD COLUMN is_published BOOLEAN DEFAULT 1`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add soft-delete columns to posts. Deleted posts are hidden and purged after a grace period
+	for _, column := range []string{"is_deleted BOOLEAN DEFAULT 0", "deleted_at DATETIME", "deleted_by INTEGER"} {
+		_, err = db.Exec(`ALTER TABLE posts ADD COLUMN ` + column)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+
+	// Add thumbnail_url to posts for the downscaled copy of the post image
+	_, err = db.Exec(`ALTER TABLE posts ADD COLUMN thumbnail_url TEXT`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
 	// Create comments table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS comments (
@@ -184,6 +211,68 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Create blocked_users table if it doesn't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS blocked_users (
+			blocker_id INTEGER NOT NULL,
+			blocked_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (blocker_id, blocked_id),
+			FOREIGN KEY (blocker_id) REFERENCES users (id) ON DELETE CASCADE,
+			FOREIGN KEY (blocked_id) REFERENCES users (id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create close_friends table for quickly sharing private posts
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS close_friends (
+			user_id INTEGER NOT NULL,
+			friend_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, friend_id),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+			FOREIGN KEY (friend_id) REFERENCES users (id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create bookmarks table for posts users saved for later
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS bookmarks (
+			user_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id),
+			FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create reports table for flagging posts and comments to moderators
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			reporter_id INTEGER NOT NULL,
+			content_id INTEGER NOT NULL,
+			content_type TEXT NOT NULL CHECK (content_type IN ('post', 'comment', 'group_post', 'group_post_comment')),
+			reason TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(reporter_id, content_id, content_type),
+			FOREIGN KEY (reporter_id) REFERENCES users (id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create post_access table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS post_access (
@@ -249,6 +338,48 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Add recurrence columns to group_events table
+	_, err = db.Exec(`ALTER TABLE group_events ADD COLUMN recurrence TEXT DEFAULT 'none'`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE group_events ADD COLUMN recurrence_end DATE`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add location and timezone columns to group_events table
+	_, err = db.Exec(`ALTER TABLE group_events ADD COLUMN location TEXT DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE group_events ADD COLUMN timezone TEXT DEFAULT 'UTC'`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// A group can feature one event at a time
+	_, err = db.Exec(`ALTER TABLE group_events ADD COLUMN is_featured BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Create group_event_exceptions table for skipped occurrences of recurring events
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_event_exceptions (
+			event_id INTEGER NOT NULL,
+			occurrence_date DATE NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (event_id, occurrence_date),
+			FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Add banner column to users table for existing databases
 	_, err = db.Exec(`ALTER TABLE users ADD COLUMN banner TEXT`)
 	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
@@ -262,22 +393,33 @@ func (db *DB) InitializeTables() error {
 	}
 
 	// Create group_event_responses table if it doesn't exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS group_event_responses (
-			event_id INTEGER NOT NULL,
-			user_id INTEGER NOT NULL,
-			response TEXT NOT NULL CHECK(response IN ('going', 'not_going')),
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (event_id, user_id),
-			FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-		)
-	`)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS group_event_responses (` + eventResponsesColumns + `)`)
+	if err != nil {
+		return err
+	}
+
+	// Older databases key responses by (event_id, user_id) only, which doesn't
+	// allow separate responses per occurrence of a recurring event
+	var hasOccurrenceDate int
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('group_event_responses') WHERE name = 'occurrence_date'`).Scan(&hasOccurrenceDate)
 	if err != nil {
 		return err
 	}
 
+	// SQLite can't alter a CHECK constraint, so tables created before 'maybe'
+	// was a valid response also need to be rebuilt
+	var allowsMaybe int
+	err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'group_event_responses' AND sql LIKE '%''maybe''%'`).Scan(&allowsMaybe)
+	if err != nil {
+		return err
+	}
+
+	if hasOccurrenceDate == 0 || allowsMaybe == 0 {
+		if err = db.rebuildEventResponsesTable(hasOccurrenceDate > 0); err != nil {
+			return err
+		}
+	}
+
 	// Create groups table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS groups (
@@ -296,6 +438,18 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Add auto_approve_requests column so creators can skip manual join approval
+	_, err = db.Exec(`ALTER TABLE groups ADD COLUMN auto_approve_requests BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add max_members column, NULL means the group has no member limit
+	_, err = db.Exec(`ALTER TABLE groups ADD COLUMN max_members INTEGER`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
 	// Create group_members table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS group_members (
@@ -349,6 +503,40 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Create group_bans table for users who may not rejoin a group
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_bans (
+			group_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			banned_by INTEGER NOT NULL,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_id),
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (banned_by) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_member_settings table for per-member group preferences
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_member_settings (
+			group_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			muted BOOLEAN DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_id),
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create group_posts table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS group_posts (
@@ -371,6 +559,43 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Add pinning columns to group_posts table
+	_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN is_pinned BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN pinned_at DATETIME`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add draft flag to group_posts table; drafts are only visible to their author
+	_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN is_draft BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add event_id to group_posts so a post can belong to an event's discussion
+	_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN event_id INTEGER`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Add soft-delete columns to group_posts. Deleted posts are hidden and purged after a grace period
+	for _, column := range []string{"is_deleted BOOLEAN DEFAULT 0", "deleted_at DATETIME", "deleted_by INTEGER"} {
+		_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN ` + column)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+
+	// Add thumbnail_path to group_posts for the downscaled copy of the post image
+	_, err = db.Exec(`ALTER TABLE group_posts ADD COLUMN thumbnail_path TEXT`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
 	// Create group_post_likes table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS group_post_likes (
@@ -386,6 +611,12 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
+	// Add reaction type to group_post_likes so a like can also be love, laugh, etc.
+	_, err = db.Exec(`ALTER TABLE group_post_likes ADD COLUMN reaction TEXT NOT NULL DEFAULT 'like'`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
 	// Create group_post_comments table if it doesn't exist
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS group_post_comments (
@@ -416,17 +647,21 @@ func (db *DB) InitializeTables() error {
 		}
 	}
 
-	// Create group_event_responses table if it doesn't exist
+	// Add parent_comment_id column so comments can reply to other comments
+	_, err = db.Exec(`ALTER TABLE group_post_comments ADD COLUMN parent_comment_id INTEGER`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+
+	// Create group_post_reads table to track the last comment each member has seen on a post
 	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS group_event_responses (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			event_id INTEGER NOT NULL,
+		CREATE TABLE IF NOT EXISTS group_post_reads (
+			post_id INTEGER NOT NULL,
 			user_id INTEGER NOT NULL,
-			response TEXT NOT NULL CHECK(response IN ('going', 'not_going')),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_read_comment_id INTEGER NOT NULL DEFAULT 0,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(event_id, user_id),
-			FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+			PRIMARY KEY (post_id, user_id),
+			FOREIGN KEY (post_id) REFERENCES group_posts(id) ON DELETE CASCADE,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)
 	`)
@@ -500,59 +735,224 @@ func (db *DB) InitializeTables() error {
 		return err
 	}
 
-	return nil
-}
+	// Create attachment tables for direct and group messages
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			file_url TEXT NOT NULL,
+			file_type TEXT NOT NULL,
+			file_name TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
 
-// Migrate runs the database migrations
-func (db *DB) Migrate(migrationPath string) error {
-	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_message_attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			file_url TEXT NOT NULL,
+			file_type TEXT NOT NULL,
+			file_name TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (message_id) REFERENCES group_messages(id) ON DELETE CASCADE
+		)
+	`)
 	if err != nil {
-		return fmt.Errorf("could not create migration driver: %w", err)
+		return err
 	}
 
-	// Ensure path format is correct for file:// URL
-	sourceURL := fmt.Sprintf("file://%s", migrationPath)
-	fmt.Printf("Using migration path: %s\n", sourceURL)
+	// Create message_status table for per-recipient delivery and read state of direct messages.
+	// A missing row means the message was sent but not yet delivered
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_status (
+			message_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			status TEXT NOT NULL CHECK(status IN ('sent', 'delivered', 'read')),
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (message_id, user_id),
+			FOREIGN KEY (message_id) REFERENCES chat_messages(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
 
-	m, err := migrate.NewWithDatabaseInstance(sourceURL, "sqlite3", driver)
+	// Create notification_preferences table for the notification categories a user wants.
+	// A missing row means every category is enabled
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER PRIMARY KEY,
+			follows BOOLEAN NOT NULL DEFAULT 1,
+			group_invites BOOLEAN NOT NULL DEFAULT 1,
+			events BOOLEAN NOT NULL DEFAULT 1,
+			comments BOOLEAN NOT NULL DEFAULT 1,
+			mentions BOOLEAN NOT NULL DEFAULT 1,
+			messages BOOLEAN NOT NULL DEFAULT 1,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
 	if err != nil {
-		return fmt.Errorf("could not create migration instance: %w", err)
+		return err
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("could not run migration up: %w", err)
+	// Add edited_at columns so message edits can be shown
+	_, err = db.Exec(`ALTER TABLE chat_messages ADD COLUMN edited_at DATETIME`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
 	}
 
-	return nil
-}
+	_, err = db.Exec(`ALTER TABLE group_messages ADD COLUMN edited_at DATETIME`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 
-// CreateUser adds a new user to the database
-func (db *DB) CreateUser(email, password, firstName, lastName, dob, avatar, nickname, aboutMe string) (int64, error) {
-	query := `INSERT INTO users (email, password, first_name, last_name, date_of_birth, avatar, nickname, about_me, is_public) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	// Flag group messages posted as announcements by the group's creator or admins
+	_, err = db.Exec(`ALTER TABLE group_messages ADD COLUMN is_announcement BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 
-	// Set is_public to true (1) by default for all new users
-	isPublic := true
+	// Let participants mute a conversation without leaving it
+	_, err = db.Exec(`ALTER TABLE chat_participants ADD COLUMN muted BOOLEAN DEFAULT 0`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 
-	result, err := db.Exec(query, email, password, firstName, lastName, dob, avatar, nickname, aboutMe, isPublic)
-	if err != nil {
-		return 0, err
+	// Add edited_at to post comments so edits can be shown
+	_, err = db.Exec(`ALTER TABLE comments ADD COLUMN edited_at DATETIME`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
+	for _, index := range hotPathIndexes {
+		if _, err := db.Exec(index.statement); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", index.name, err)
+		}
 	}
 
-	return id, nil
+	return nil
 }
 
-// GetUserByEmail retrieves a user by email
-func (db *DB) GetUserByEmail(email string) (map[string]interface{}, error) {
-	query := `SELECT id, email, password, first_name, last_name, date_of_birth, avatar, banner, nickname, about_me, is_public 
-			  FROM users WHERE email = ?`
-
-	row := db.QueryRow(query, email)
+// hotPathIndexes index the lookups behind vote counts, notification lists and chat history.
+// InitializeTables runs before Migrate and its schema no longer matches the legacy
+// migrations, so this is where the indexes are created rather than in a migration.
+// group_members needs none since its primary key already starts with group_id
+var hotPathIndexes = []struct {
+	name      string
+	statement string
+}{
+	{"idx_votes_content", `CREATE INDEX IF NOT EXISTS idx_votes_content ON votes(content_id, content_type)`},
+	{"idx_notifications_receiver_id", `CREATE INDEX IF NOT EXISTS idx_notifications_receiver_id ON notifications(receiver_id, created_at)`},
+	{"idx_chat_messages_conversation_id", `CREATE INDEX IF NOT EXISTS idx_chat_messages_conversation_id ON chat_messages(conversation_id, created_at)`},
+}
+
+// eventResponsesColumns is the single definition of group_event_responses, shared by
+// table creation and rebuilds. The CHECK must allow every value in EventResponses
+const eventResponsesColumns = `
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			occurrence_date TEXT NOT NULL DEFAULT '',
+			response TEXT NOT NULL CHECK(response IN ('going', 'not_going', 'maybe')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(event_id, user_id, occurrence_date),
+			FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		`
+
+// rebuildEventResponsesTable recreates group_event_responses with the current schema,
+// keeping existing responses. There is no migration for this: InitializeTables runs
+// before Migrate, and only it can tell whether occurrence_date needs to be copied
+func (db *DB) rebuildEventResponsesTable(hasOccurrenceDate bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	copyResponses := `INSERT INTO group_event_responses_new (event_id, user_id, response, created_at, updated_at)
+		 SELECT event_id, user_id, response, created_at, updated_at FROM group_event_responses`
+	if hasOccurrenceDate {
+		copyResponses = `INSERT INTO group_event_responses_new (event_id, user_id, occurrence_date, response, created_at, updated_at)
+		 SELECT event_id, user_id, occurrence_date, response, created_at, updated_at FROM group_event_responses`
+	}
+
+	statements := []string{
+		`CREATE TABLE group_event_responses_new (` + eventResponsesColumns + `)`,
+		copyResponses,
+		`DROP TABLE group_event_responses`,
+		`ALTER TABLE group_event_responses_new RENAME TO group_event_responses`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to rebuild group_event_responses: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Migrate runs the database migrations
+func (db *DB) Migrate(migrationPath string) error {
+	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	if err != nil {
+		return fmt.Errorf("could not create migration driver: %w", err)
+	}
+
+	// Ensure path format is correct for file:// URL
+	sourceURL := fmt.Sprintf("file://%s", migrationPath)
+	fmt.Printf("Using migration path: %s\n", sourceURL)
+
+	m, err := migrate.NewWithDatabaseInstance(sourceURL, "sqlite3", driver)
+	if err != nil {
+		return fmt.Errorf("could not create migration instance: %w", err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("could not run migration up: %w", err)
+	}
+
+	return nil
+}
+
+// CreateUser adds a new user to the database
+func (db *DB) CreateUser(email, password, firstName, lastName, dob, avatar, nickname, aboutMe string) (int64, error) {
+	query := `INSERT INTO users (email, password, first_name, last_name, date_of_birth, avatar, nickname, about_me, is_public) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Set is_public to true (1) by default for all new users
+	isPublic := true
+
+	result, err := db.Exec(query, email, password, firstName, lastName, dob, avatar, nickname, aboutMe, isPublic)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetUserByEmail retrieves a user by email
+func (db *DB) GetUserByEmail(email string) (map[string]interface{}, error) {
+	query := `SELECT id, email, password, first_name, last_name, date_of_birth, avatar, banner, nickname, about_me, is_public 
+			  FROM users WHERE email = ?`
+
+	row := db.QueryRow(query, email)
 
 	var id int
 	var password, firstName, lastName, dob string
@@ -643,6 +1043,38 @@ func (db *DB) CheckEmailExists(email string) (bool, error) {
 	return count > 0, nil
 }
 
+// GetUserByNickname retrieves a user by nickname (case-insensitive).
+// It returns nil without an error when no user has the nickname.
+func (db *DB) GetUserByNickname(nickname string) (map[string]interface{}, error) {
+	query := `SELECT id, first_name, last_name, avatar, nickname 
+			  FROM users WHERE nickname IS NOT NULL AND nickname != '' AND LOWER(nickname) = LOWER(?)`
+
+	var id int
+	var firstName, lastName string
+	var avatar sql.NullString
+
+	err := db.QueryRow(query, nickname).Scan(&id, &firstName, &lastName, &avatar, &nickname)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	user := map[string]interface{}{
+		"id":         id,
+		"first_name": firstName,
+		"last_name":  lastName,
+		"nickname":   nickname,
+	}
+
+	if avatar.Valid {
+		user["avatar"] = avatar.String
+	}
+
+	return user, nil
+}
+
 // CheckNicknameExists checks if a nickname already exists in the database
 func (db *DB) CheckNicknameExists(nickname string) (bool, error) {
 	if nickname == "" {
@@ -725,6 +1157,56 @@ func (db *DB) DeleteSessionsByUserID(userID int) error {
 	return err
 }
 
+// DeleteOtherSessions removes all sessions for a user except the given one
+func (db *DB) DeleteOtherSessions(userID int, keepSessionID string) error {
+	query := `DELETE FROM sessions WHERE user_id = ? AND id != ?`
+
+	_, err := db.Exec(query, userID, keepSessionID)
+	return err
+}
+
+// Session represents a user's login session
+type Session struct {
+	// ID is the session cookie value and must never be sent to clients, Handle identifies it instead
+	ID        string    `json:"-"`
+	Handle    string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IsCurrent bool      `json:"is_current"`
+}
+
+// SessionHandle returns an opaque identifier for a session that can't be used as a session cookie
+func SessionHandle(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// GetSessionsByUserID retrieves all active sessions for a user, newest first
+func (db *DB) GetSessionsByUserID(userID int) ([]Session, error) {
+	query := `SELECT id, created_at, expires_at 
+			  FROM sessions WHERE user_id = ? AND expires_at > datetime('now')
+			  ORDER BY created_at DESC`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(&session.ID, &session.CreatedAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %v", err)
+		}
+		session.Handle = SessionHandle(session.ID)
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
 // CleanupExpiredSessions removes all expired sessions and auth tokens
 func (db *DB) CleanupExpiredSessions() error {
 	// Delete expired sessions
@@ -744,6 +1226,48 @@ func (db *DB) CleanupExpiredSessions() error {
 	return nil
 }
 
+// GetAllReferencedImagePaths returns every uploaded file path still referenced by a row,
+// covering post and comment images, group images, avatars, banners and chat attachments.
+// Paths are returned exactly as stored, e.g. "/uploads/posts/<name>.jpg".
+func (db *DB) GetAllReferencedImagePaths() (map[string]bool, error) {
+	queries := []string{
+		`SELECT image_url FROM posts WHERE image_url IS NOT NULL AND image_url != ''`,
+		`SELECT image_url FROM comments WHERE image_url IS NOT NULL AND image_url != ''`,
+		`SELECT image_path FROM group_posts WHERE image_path IS NOT NULL AND image_path != ''`,
+		`SELECT image_path FROM group_post_comments WHERE image_path IS NOT NULL AND image_path != ''`,
+		`SELECT avatar FROM users WHERE avatar IS NOT NULL AND avatar != ''`,
+		`SELECT banner FROM users WHERE banner IS NOT NULL AND banner != ''`,
+		`SELECT avatar FROM groups WHERE avatar IS NOT NULL AND avatar != ''`,
+		`SELECT file_url FROM chat_attachments`,
+		`SELECT file_url FROM group_message_attachments`,
+	}
+
+	paths := make(map[string]bool)
+	for _, query := range queries {
+		rows, err := db.Query(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referenced image paths: %v", err)
+		}
+
+		for rows.Next() {
+			var path string
+			if err := rows.Scan(&path); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan image path: %v", err)
+			}
+			paths[path] = true
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referenced image paths: %v", err)
+		}
+	}
+
+	return paths, nil
+}
+
 // CreateAuthToken creates a token for password reset or email verification
 func (db *DB) CreateAuthToken(tokenID string, userID int, tokenType string, expiresAt string) error {
 	query := `INSERT INTO auth_tokens (id, user_id, token_type, expires_at) 
@@ -854,6 +1378,200 @@ func (db *DB) UpdateUser(userID int, data map[string]interface{}) error {
 	return err
 }
 
+// UpdateUserPassword replaces a user's password hash
+func (db *DB) UpdateUserPassword(userID int, hash string) error {
+	query := `UPDATE users SET password = ? WHERE id = ?`
+
+	result, err := db.Exec(query, hash, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetCreatedGroupIDs returns the IDs of the groups a user created
+func (db *DB) GetCreatedGroupIDs(userID int64) ([]int64, error) {
+	rows, err := db.Query(`SELECT id FROM groups WHERE creator_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groupIDs []int64
+	for rows.Next() {
+		var groupID int64
+		if err := rows.Scan(&groupID); err != nil {
+			return nil, err
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	return groupIDs, rows.Err()
+}
+
+// DeleteUser permanently removes a user and their content in a single transaction.
+// Groups created by the user are deleted along with everything in them
+func (db *DB) DeleteUser(userID int64) error {
+	groupIDs, err := db.GetCreatedGroupIDs(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get created groups: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, groupID := range groupIDs {
+		if err := deleteGroupTx(tx, groupID); err != nil {
+			return err
+		}
+	}
+
+	// Remember what the user voted on so the counts can be recalculated afterwards
+	type votedContent struct {
+		id          int64
+		contentType string
+	}
+	var voted []votedContent
+	rows, err := tx.Query(`SELECT content_id, content_type FROM votes WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user votes: %v", err)
+	}
+	for rows.Next() {
+		var content votedContent
+		if err := rows.Scan(&content.id, &content.contentType); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan user vote: %v", err)
+		}
+		voted = append(voted, content)
+	}
+	rows.Close()
+
+	// Any failure rolls back the whole deletion
+	deletions := []struct {
+		query string
+		desc  string
+	}{
+		// Reports on anything below that disappears with the user
+		{"DELETE FROM reports WHERE (content_type = 'post' AND content_id IN (SELECT id FROM posts WHERE user_id = ?1))" +
+			" OR (content_type = 'comment' AND content_id IN (SELECT id FROM comments WHERE user_id = ?1 OR post_id IN (SELECT id FROM posts WHERE user_id = ?1)))" +
+			" OR (content_type = 'group_post' AND content_id IN (SELECT id FROM group_posts WHERE author_id = ?1))" +
+			" OR (content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE author_id = ?1 OR post_id IN (SELECT id FROM group_posts WHERE author_id = ?1)))", "reports on content"},
+
+		// Content on the user's posts, then the posts themselves
+		{"DELETE FROM votes WHERE content_type = 'comment' AND content_id IN (SELECT id FROM comments WHERE post_id IN (SELECT id FROM posts WHERE user_id = ?))", "votes on comments of posts"},
+		{"DELETE FROM comments WHERE post_id IN (SELECT id FROM posts WHERE user_id = ?)", "comments on posts"},
+		{"DELETE FROM votes WHERE content_type = 'post' AND content_id IN (SELECT id FROM posts WHERE user_id = ?)", "votes on posts"},
+		{"DELETE FROM post_access WHERE post_id IN (SELECT id FROM posts WHERE user_id = ?)", "post access"},
+		{"DELETE FROM posts WHERE user_id = ?", "posts"},
+
+		// The user's group posts in groups they didn't create
+		{"DELETE FROM votes WHERE content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE author_id = ?))", "votes on group post comments"},
+		{"DELETE FROM group_post_comments WHERE post_id IN (SELECT id FROM group_posts WHERE author_id = ?)", "comments on group posts"},
+		{"DELETE FROM group_post_likes WHERE post_id IN (SELECT id FROM group_posts WHERE author_id = ?)", "likes on group posts"},
+		{"DELETE FROM votes WHERE content_type = 'group_post' AND content_id IN (SELECT id FROM group_posts WHERE author_id = ?)", "votes on group posts"},
+		{"DELETE FROM group_posts WHERE author_id = ?", "group posts"},
+
+		// Comments, votes and likes the user left elsewhere
+		{"DELETE FROM votes WHERE content_type = 'comment' AND content_id IN (SELECT id FROM comments WHERE user_id = ?)", "votes on comments"},
+		{"DELETE FROM comments WHERE user_id = ?", "comments"},
+		{"DELETE FROM votes WHERE content_type = 'group_post_comment' AND content_id IN (SELECT id FROM group_post_comments WHERE author_id = ?)", "votes on group comments"},
+		{"DELETE FROM group_post_comments WHERE author_id = ?", "group post comments"},
+		{"DELETE FROM group_post_likes WHERE user_id = ?", "group post likes"},
+		{"DELETE FROM votes WHERE user_id = ?", "votes"},
+		{"DELETE FROM bookmarks WHERE user_id = ?", "bookmarks"},
+		{"DELETE FROM notification_preferences WHERE user_id = ?", "notification preferences"},
+
+		// Events the user created in other groups and their responses
+		{"DELETE FROM group_event_responses WHERE event_id IN (SELECT id FROM group_events WHERE creator_id = ?)", "responses to events"},
+		{"DELETE FROM group_event_exceptions WHERE event_id IN (SELECT id FROM group_events WHERE creator_id = ?)", "event exceptions"},
+		{"DELETE FROM group_events WHERE creator_id = ?", "group events"},
+		{"DELETE FROM group_event_responses WHERE user_id = ?", "event responses"},
+
+		// Relationships
+		{"DELETE FROM followers WHERE follower_id = ?1 OR following_id = ?1", "follow relationships"},
+		{"DELETE FROM follow_requests WHERE requester_id = ?1 OR requested_id = ?1", "follow requests"},
+		{"DELETE FROM blocked_users WHERE blocker_id = ?1 OR blocked_id = ?1", "blocks"},
+		{"DELETE FROM close_friends WHERE user_id = ?1 OR friend_id = ?1", "close friends"},
+		{"DELETE FROM reports WHERE reporter_id = ?", "reports"},
+
+		// Group membership
+		{"DELETE FROM group_member_settings WHERE user_id = ?", "group member settings"},
+		{"DELETE FROM group_post_reads WHERE user_id = ?", "group post read markers"},
+		{"DELETE FROM group_invitations WHERE inviter_id = ?1 OR invitee_id = ?1", "group invitations"},
+		{"DELETE FROM group_join_requests WHERE user_id = ?", "group join requests"},
+		{"DELETE FROM group_bans WHERE user_id = ?1 OR banned_by = ?1", "group bans"},
+		{"DELETE FROM group_members WHERE user_id = ?", "group memberships"},
+
+		// Chat
+		{"DELETE FROM group_message_attachments WHERE message_id IN (SELECT id FROM group_messages WHERE sender_id = ?)", "group message attachments"},
+		{"DELETE FROM group_messages WHERE sender_id = ?", "group messages"},
+		{"DELETE FROM message_status WHERE user_id = ?1 OR message_id IN (SELECT id FROM chat_messages WHERE sender_id = ?1)", "message statuses"},
+		{"DELETE FROM chat_attachments WHERE message_id IN (SELECT id FROM chat_messages WHERE sender_id = ?)", "chat attachments"},
+		{"DELETE FROM chat_messages WHERE sender_id = ?", "chat messages"},
+		{"DELETE FROM chat_participants WHERE user_id = ?", "chat participants"},
+
+		// Notifications, sessions and tokens
+		{"DELETE FROM notifications WHERE receiver_id = ?1 OR sender_id = ?1", "notifications"},
+		{"DELETE FROM sessions WHERE user_id = ?", "sessions"},
+		{"DELETE FROM auth_tokens WHERE user_id = ?", "auth tokens"},
+	}
+
+	// follow_requests is only created on first use, so it may not exist yet
+	lazyTables := map[string]string{"follow requests": "follow_requests"}
+
+	for _, deletion := range deletions {
+		if table, ok := lazyTables[deletion.desc]; ok {
+			var exists int
+			err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists)
+			if err != nil {
+				return fmt.Errorf("failed to check for %s table: %v", table, err)
+			}
+			if exists == 0 {
+				continue
+			}
+		}
+
+		if _, err := tx.Exec(deletion.query, userID); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", deletion.desc, err)
+		}
+	}
+
+	for _, content := range voted {
+		if err := updateVoteCounts(tx, content.id, content.contentType); err != nil {
+			return fmt.Errorf("failed to update vote counts: %v", err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM users WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return tx.Commit()
+}
+
 // AddComment adds a comment to a post
 func (db *DB) AddComment(postID, userID int64, content string, imageURL string) (int64, error) {
 	query := `INSERT INTO comments (post_id, user_id, content, image_url) 
@@ -872,11 +1590,21 @@ func (db *DB) AddComment(postID, userID int64, content string, imageURL string)
 	return commentID, nil
 }
 
+// GetCommentCount returns how many comments a post has without loading them
+func (db *DB) GetCommentCount(postID int64) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM comments WHERE post_id = ?`, postID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count comments: %v", err)
+	}
+	return count, nil
+}
+
 // GetCommentsByPostID retrieves comments for a specific post
 func (db *DB) GetCommentsByPostID(postID int64) ([]map[string]interface{}, error) {
 	query := `
 		SELECT 
-			c.id, c.post_id, c.user_id, c.content, c.image_url, c.created_at, c.vote_count,
+			c.id, c.post_id, c.user_id, c.content, c.image_url, c.created_at, c.edited_at, c.vote_count,
 			u.first_name, u.last_name, u.avatar
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
@@ -900,13 +1628,14 @@ func (db *DB) GetCommentsByPostID(postID int64) ([]map[string]interface{}, error
 			content   string
 			imageURL  *string
 			createdAt string
+			editedAt  *string
 			voteCount int
 			firstName string
 			lastName  string
 			avatar    *string
 		)
 
-		err := rows.Scan(&id, &postID, &userID, &content, &imageURL, &createdAt, &voteCount, &firstName, &lastName, &avatar)
+		err := rows.Scan(&id, &postID, &userID, &content, &imageURL, &createdAt, &editedAt, &voteCount, &firstName, &lastName, &avatar)
 		if err != nil {
 			return nil, err
 		}
@@ -929,6 +1658,10 @@ func (db *DB) GetCommentsByPostID(postID int64) ([]map[string]interface{}, error
 			comment["image_url"] = *imageURL
 		}
 
+		if editedAt != nil {
+			comment["edited_at"] = *editedAt
+		}
+
 		if avatar != nil {
 			comment["author"].(map[string]interface{})["avatar"] = *avatar
 		}
@@ -939,6 +1672,60 @@ func (db *DB) GetCommentsByPostID(postID int64) ([]map[string]interface{}, error
 	return comments, nil
 }
 
+// GetUserComments retrieves every comment a user wrote, oldest first
+func (db *DB) GetUserComments(userID int) ([]map[string]interface{}, error) {
+	query := `
+		SELECT id, post_id, content, image_url, created_at, edited_at, vote_count
+		FROM comments
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user comments: %v", err)
+	}
+	defer rows.Close()
+
+	comments := []map[string]interface{}{}
+
+	for rows.Next() {
+		var (
+			id        int64
+			postID    int64
+			content   string
+			imageURL  *string
+			createdAt string
+			editedAt  *string
+			voteCount int
+		)
+
+		if err := rows.Scan(&id, &postID, &content, &imageURL, &createdAt, &editedAt, &voteCount); err != nil {
+			return nil, err
+		}
+
+		comment := map[string]interface{}{
+			"id":         id,
+			"post_id":    postID,
+			"content":    content,
+			"created_at": createdAt,
+			"vote_count": voteCount,
+		}
+
+		if imageURL != nil {
+			comment["image_url"] = *imageURL
+		}
+
+		if editedAt != nil {
+			comment["edited_at"] = *editedAt
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
 // GetUserFollowers returns the list of followers for a user
 func (db *DB) GetUserFollowers(userID int) ([]map[string]interface{}, error) {
 	// Check if followers table exists
@@ -992,7 +1779,8 @@ func (db *DB) GetUserFollowers(userID int) ([]map[string]interface{}, error) {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// GetUserFollowers returns the list of followers for a user
+// GetUserFollowing returns the users a user follows, with whether each account
+// is public and whether it follows the user back
 func (db *DB) GetUserFollowing(userID int) ([]map[string]interface{}, error) {
 	// Check if followers table exists
 	var tableName string
@@ -1002,8 +1790,11 @@ func (db *DB) GetUserFollowing(userID int) ([]map[string]interface{}, error) {
 		return []map[string]interface{}{}, nil
 	}
 
+	// is_mutual is set when the followed user follows this user back
 	query := `
-		SELECT u.id, u.first_name, u.last_name, u.avatar
+		SELECT u.id, u.first_name, u.last_name, u.avatar, u.is_public,
+		       EXISTS (SELECT 1 FROM followers back
+		               WHERE back.follower_id = f.following_id AND back.following_id = f.follower_id) AS is_mutual
 		FROM followers f
 		JOIN users u ON f.following_id = u.id
 		WHERE f.follower_id = ?
@@ -1021,23 +1812,26 @@ func (db *DB) GetUserFollowing(userID int) ([]map[string]interface{}, error) {
 		var id int
 		var firstName, lastName string
 		var avatar sql.NullString
+		var isPublic, isMutual bool
 
-		err := rows.Scan(&id, &firstName, &lastName, &avatar)
+		err := rows.Scan(&id, &firstName, &lastName, &avatar, &isPublic, &isMutual)
 		if err != nil {
 			return nil, err
 		}
 
-		follower := map[string]interface{}{
+		followed := map[string]interface{}{
 			"id":         id,
 			"first_name": firstName,
 			"last_name":  lastName,
+			"is_public":  isPublic,
+			"is_mutual":  isMutual,
 		}
 
 		if avatar.Valid {
-			follower["avatar"] = avatar.String
+			followed["avatar"] = avatar.String
 		}
 
-		following = append(following, follower) //appended follower even though its following (maybe wrong)
+		following = append(following, followed)
 	}
 
 	return following, nil
@@ -1119,10 +1913,36 @@ func (db *DB) IsFollowing(followerID, followingID int) (bool, error) {
 	return true, nil
 }
 
-// UnfollowUser removes a follower relationship between two users
-func (db *DB) UnfollowUser(followerID, followingID int) error {
-	// Check if followers table exists
-	var tableName string
+// GetFollowCounts returns how many users follow a user and how many they follow
+func (db *DB) GetFollowCounts(userID int) (followers int, following int, err error) {
+	err = db.QueryRow(`SELECT COUNT(*) FROM followers WHERE following_id = ?`, userID).Scan(&followers)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count followers: %v", err)
+	}
+
+	err = db.QueryRow(`SELECT COUNT(*) FROM followers WHERE follower_id = ?`, userID).Scan(&following)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count following: %v", err)
+	}
+
+	return followers, following, nil
+}
+
+// GetPostCount returns the number of posts a user has created
+func (db *DB) GetPostCount(userID int) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM posts WHERE user_id = ? AND COALESCE(is_published, 1) = 1 AND COALESCE(is_deleted, 0) = 0`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count posts: %v", err)
+	}
+
+	return count, nil
+}
+
+// UnfollowUser removes a follower relationship between two users
+func (db *DB) UnfollowUser(followerID, followingID int) error {
+	// Check if followers table exists
+	var tableName string
 	err := db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='followers'").Scan(&tableName)
 	if err != nil {
 		return fmt.Errorf("not following this user")
@@ -1393,6 +2213,13 @@ func (db *DB) AutoApproveFollowRequests(userID int64) error {
 			return fmt.Errorf("failed to delete follow request: %w", err)
 		}
 
+		// Skip the notification if the requester turned off follow notifications
+		var followsEnabled bool
+		err = tx.QueryRow(`SELECT follows FROM notification_preferences WHERE user_id = ?`, request.FollowerID).Scan(&followsEnabled)
+		if err == nil && !followsEnabled {
+			continue
+		}
+
 		// Create a notification for the requester
 		// Get the user's name for the notification
 		var firstName, lastName string
@@ -1407,8 +2234,8 @@ func (db *DB) AutoApproveFollowRequests(userID int64) error {
 
 		// Insert notification
 		_, err = tx.Exec(`
-			INSERT INTO notifications (user_id, sender_id, type, content, reference_id, created_at) 
-			VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+			INSERT INTO notifications (receiver_id, sender_id, type, content, reference_id) 
+			VALUES (?, ?, ?, ?, ?)`,
 			request.FollowerID, userID, "follow_accepted", notificationContent, request.ID)
 		if err != nil {
 			// Log warning but continue processing other requests
@@ -1434,10 +2261,24 @@ func (db *DB) DeletePost(postID int64) error {
 	}
 	defer tx.Rollback()
 
-	// First delete all comments associated with the post
-	_, err = tx.Exec("DELETE FROM comments WHERE post_id = ?", postID)
-	if err != nil {
-		return err
+	// First delete everything that points at the post or its comments, so no
+	// votes, reports or bookmarks are left referring to a reused ID
+	deletions := []struct {
+		query string
+		desc  string
+	}{
+		{"DELETE FROM votes WHERE content_type = 'comment' AND content_id IN (SELECT id FROM comments WHERE post_id = ?)", "comment votes"},
+		{"DELETE FROM reports WHERE content_type = 'comment' AND content_id IN (SELECT id FROM comments WHERE post_id = ?)", "comment reports"},
+		{"DELETE FROM comments WHERE post_id = ?", "comments"},
+		{"DELETE FROM votes WHERE content_type = 'post' AND content_id = ?", "post votes"},
+		{"DELETE FROM reports WHERE content_type = 'post' AND content_id = ?", "post reports"},
+		{"DELETE FROM post_access WHERE post_id = ?", "post access"},
+		{"DELETE FROM bookmarks WHERE post_id = ?", "bookmarks"},
+	}
+	for _, deletion := range deletions {
+		if _, err := tx.Exec(deletion.query, postID); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", deletion.desc, err)
+		}
 	}
 
 	// Then delete the post itself
@@ -1460,11 +2301,137 @@ func (db *DB) DeletePost(postID int64) error {
 	return tx.Commit()
 }
 
+// DeletedPostGracePeriod is how long a deleted post can be restored before it is purged
+const DeletedPostGracePeriod = 30 * time.Second
+
+// gracePeriodModifier returns the SQLite datetime modifier for DeletedPostGracePeriod
+func gracePeriodModifier() string {
+	return fmt.Sprintf("-%d seconds", int(DeletedPostGracePeriod.Seconds()))
+}
+
+// SoftDeletePost hides a post until it is restored or purged after DeletedPostGracePeriod
+func (db *DB) SoftDeletePost(postID, deletedBy int64) error {
+	result, err := db.Exec(`UPDATE posts SET is_deleted = 1, deleted_at = CURRENT_TIMESTAMP, deleted_by = ?
+		WHERE id = ? AND COALESCE(is_deleted, 0) = 0`, deletedBy, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete post: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	return nil
+}
+
+// RestorePost undoes a soft delete. Only the user who deleted the post can restore it,
+// and only within DeletedPostGracePeriod
+func (db *DB) RestorePost(postID, userID int64) error {
+	var withinWindow bool
+	err := db.QueryRow(`SELECT deleted_at > datetime('now', ?) FROM posts
+		WHERE id = ? AND deleted_by = ? AND is_deleted = 1`,
+		gracePeriodModifier(), postID, userID).Scan(&withinWindow)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
+		return fmt.Errorf("failed to get deleted post: %v", err)
+	}
+
+	if !withinWindow {
+		return fmt.Errorf("restore window expired")
+	}
+
+	_, err = db.Exec(`UPDATE posts SET is_deleted = 0, deleted_at = NULL, deleted_by = NULL WHERE id = ?`, postID)
+	if err != nil {
+		return fmt.Errorf("failed to restore post: %v", err)
+	}
+
+	return nil
+}
+
+// PurgedImages lists the images left behind by purged posts, split by the kind
+// of row that owned them since posts and comments keep their files apart
+type PurgedImages struct {
+	Posts    []string
+	Comments []string
+}
+
+// PurgeDeletedPosts permanently deletes posts whose grace period has passed.
+// It returns the image URLs of the purged posts and their comments so the files can be removed
+func (db *DB) PurgeDeletedPosts() (*PurgedImages, error) {
+	postIDs, images, err := db.expiredDeletedPosts(
+		`SELECT id, COALESCE(image_url, '') FROM posts WHERE is_deleted = 1 AND deleted_at <= datetime('now', ?)`,
+		`SELECT image_url FROM comments WHERE post_id = ? AND image_url IS NOT NULL AND image_url != ''`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, postID := range postIDs {
+		if err := db.DeletePost(postID); err != nil {
+			return nil, fmt.Errorf("failed to purge post %d: %v", postID, err)
+		}
+	}
+
+	return images, nil
+}
+
+// expiredDeletedPosts returns the IDs of soft-deleted posts past their grace period
+// together with the images of those posts and of their comments
+func (db *DB) expiredDeletedPosts(postsQuery, commentImagesQuery string) ([]int64, *PurgedImages, error) {
+	rows, err := db.Query(postsQuery, gracePeriodModifier())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get deleted posts: %v", err)
+	}
+
+	var postIDs []int64
+	images := &PurgedImages{}
+	for rows.Next() {
+		var id int64
+		var image string
+		if err := rows.Scan(&id, &image); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		postIDs = append(postIDs, id)
+		if image != "" {
+			images.Posts = append(images.Posts, image)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, postID := range postIDs {
+		commentRows, err := db.Query(commentImagesQuery, postID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get comment images: %v", err)
+		}
+		for commentRows.Next() {
+			var image string
+			if err := commentRows.Scan(&image); err != nil {
+				commentRows.Close()
+				return nil, nil, err
+			}
+			images.Comments = append(images.Comments, image)
+		}
+		commentRows.Close()
+	}
+
+	return postIDs, images, nil
+}
+
 // GetCommentByID retrieves a comment by its ID
 func (db *DB) GetCommentByID(commentID int64) (map[string]interface{}, error) {
 	row := db.QueryRow(`
 		SELECT 
-			c.id, c.post_id, c.user_id, c.content, c.image_url, c.created_at, c.vote_count,
+			c.id, c.post_id, c.user_id, c.content, c.image_url, c.created_at, c.edited_at, c.vote_count,
 			u.first_name, u.last_name, u.avatar
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
@@ -1478,13 +2445,14 @@ func (db *DB) GetCommentByID(commentID int64) (map[string]interface{}, error) {
 		content   string
 		imageURL  *string
 		createdAt string
+		editedAt  *string
 		voteCount int
 		firstName string
 		lastName  string
 		avatar    *string
 	)
 
-	err := row.Scan(&id, &postID, &userID, &content, &imageURL, &createdAt, &voteCount, &firstName, &lastName, &avatar)
+	err := row.Scan(&id, &postID, &userID, &content, &imageURL, &createdAt, &editedAt, &voteCount, &firstName, &lastName, &avatar)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("comment with ID %d not found", commentID)
@@ -1511,6 +2479,10 @@ func (db *DB) GetCommentByID(commentID int64) (map[string]interface{}, error) {
 		comment["image_url"] = *imageURL
 	}
 
+	if editedAt != nil {
+		comment["edited_at"] = *editedAt
+	}
+
 	if avatar != nil {
 		comment["author"].(map[string]interface{})["avatar"] = *avatar
 	}
@@ -1518,9 +2490,43 @@ func (db *DB) GetCommentByID(commentID int64) (map[string]interface{}, error) {
 	return comment, nil
 }
 
+// UpdateComment replaces a comment's content and image and records when it was edited
+func (db *DB) UpdateComment(commentID int64, content, imageURL string) error {
+	result, err := db.Exec(`UPDATE comments SET content = ?, image_url = ?, edited_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		content, imageURL, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment with ID %d not found", commentID)
+	}
+
+	return nil
+}
+
 // DeleteComment removes a comment from the database
 func (db *DB) DeleteComment(commentID int64) error {
-	result, err := db.Exec("DELETE FROM comments WHERE id = ?", commentID)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Votes and reports on the comment go with it
+	for _, table := range []string{"votes", "reports"} {
+		_, err = tx.Exec("DELETE FROM "+table+" WHERE content_type = 'comment' AND content_id = ?", commentID)
+		if err != nil {
+			return fmt.Errorf("failed to delete comment %s: %v", table, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM comments WHERE id = ?", commentID)
 	if err != nil {
 		return err
 	}
@@ -1535,7 +2541,7 @@ func (db *DB) DeleteComment(commentID int64) error {
 		return fmt.Errorf("comment with ID %d not found", commentID)
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // Vote adds or updates a user's vote on a post or comment
@@ -1549,123 +2555,66 @@ func (db *DB) Vote(userID int, contentID int64, contentType string, voteType int
 
 	// Check if user has already voted
 	var existingVoteType int
-	var voteExists bool
 	existingVoteQuery := `SELECT vote_type FROM votes WHERE user_id = ? AND content_id = ? AND content_type = ?`
 	err = tx.QueryRow(existingVoteQuery, userID, contentID, contentType).Scan(&existingVoteType)
-	if err == nil {
-		voteExists = true
-	} else if err != sql.ErrNoRows {
+
+	switch {
+	case err == sql.ErrNoRows:
+		// Create new vote. A concurrent vote may have inserted the row in the
+		// meantime, in which case it is switched to the requested type
+		_, err = tx.Exec(`INSERT INTO votes (user_id, content_id, content_type, vote_type) VALUES (?, ?, ?, ?)
+		                  ON CONFLICT(user_id, content_id, content_type) DO UPDATE SET vote_type = excluded.vote_type`,
+			userID, contentID, contentType, voteType)
+	case err != nil:
+		return err
+	case existingVoteType == voteType:
+		// Same vote type again removes the vote (toggle off)
+		_, err = tx.Exec(`DELETE FROM votes WHERE user_id = ? AND content_id = ? AND content_type = ?`,
+			userID, contentID, contentType)
+	default:
+		// Change vote type
+		_, err = tx.Exec(`UPDATE votes SET vote_type = ? WHERE user_id = ? AND content_id = ? AND content_type = ?`,
+			voteType, userID, contentID, contentType)
+	}
+	if err != nil {
 		return err
 	}
 
-	if voteExists {
-		// If vote type is the same, remove the vote (toggle off)
-		if existingVoteType == voteType {
-			_, err = tx.Exec(`DELETE FROM votes WHERE user_id = ? AND content_id = ? AND content_type = ?`,
-				userID, contentID, contentType)
-			if err != nil {
-				return err
-			}
+	// Derive the denormalized counts from the votes table instead of adjusting
+	// them, so they can't drift from the actual votes
+	if err := updateVoteCounts(tx, contentID, contentType); err != nil {
+		return err
+	}
 
-			// Update vote counts based on content type
-			switch contentType {
-			case "post":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE posts SET upvotes = upvotes - 1 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE posts SET downvotes = downvotes - 1 WHERE id = ?`, contentID)
-				}
-			case "group_post":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE group_posts SET upvotes = upvotes - 1 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE group_posts SET downvotes = downvotes - 1 WHERE id = ?`, contentID)
-				}
-			case "group_post_comment":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE group_post_comments SET upvotes = upvotes - 1, vote_count = vote_count - 1 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE group_post_comments SET downvotes = downvotes - 1, vote_count = vote_count + 1 WHERE id = ?`, contentID)
-				}
-			case "comment":
-				_, err = tx.Exec(`UPDATE comments SET vote_count = vote_count - ? WHERE id = ?`, voteType, contentID)
-			}
-			if err != nil {
-				return err
-			}
-		} else {
-			// Change vote type
-			_, err = tx.Exec(`UPDATE votes SET vote_type = ? WHERE user_id = ? AND content_id = ? AND content_type = ?`,
-				voteType, userID, contentID, contentType)
-			if err != nil {
-				return err
-			}
+	// Commit transaction
+	return tx.Commit()
+}
 
-			// Update vote counts based on content type
-			switch contentType {
-			case "post":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE posts SET upvotes = upvotes + 1, downvotes = downvotes - 1 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE posts SET upvotes = upvotes - 1, downvotes = downvotes + 1 WHERE id = ?`, contentID)
-				}
-			case "group_post":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE group_posts SET upvotes = upvotes + 1, downvotes = downvotes - 1 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE group_posts SET upvotes = upvotes - 1, downvotes = downvotes + 1 WHERE id = ?`, contentID)
-				}
-			case "group_post_comment":
-				if voteType == 1 {
-					_, err = tx.Exec(`UPDATE group_post_comments SET upvotes = upvotes + 1, downvotes = downvotes - 1, vote_count = vote_count + 2 WHERE id = ?`, contentID)
-				} else {
-					_, err = tx.Exec(`UPDATE group_post_comments SET upvotes = upvotes - 1, downvotes = downvotes + 1, vote_count = vote_count - 2 WHERE id = ?`, contentID)
-				}
-			case "comment":
-				_, err = tx.Exec(`UPDATE comments SET vote_count = vote_count + ? WHERE id = ?`, voteType*2, contentID)
-			}
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		// Create new vote
-		_, err = tx.Exec(`INSERT INTO votes (user_id, content_id, content_type, vote_type) VALUES (?, ?, ?, ?)`,
-			userID, contentID, contentType, voteType)
-		if err != nil {
-			return err
-		}
+// updateVoteCounts recalculates the vote count columns of a piece of content from its votes
+func updateVoteCounts(tx *sql.Tx, contentID int64, contentType string) error {
+	countQuery := `SELECT COUNT(*) FROM votes WHERE content_id = ? AND content_type = ? AND vote_type = ?`
 
-		// Update vote counts based on content type
-		switch contentType {
-		case "post":
-			if voteType == 1 {
-				_, err = tx.Exec(`UPDATE posts SET upvotes = upvotes + 1 WHERE id = ?`, contentID)
-			} else {
-				_, err = tx.Exec(`UPDATE posts SET downvotes = downvotes + 1 WHERE id = ?`, contentID)
-			}
-		case "group_post":
-			if voteType == 1 {
-				_, err = tx.Exec(`UPDATE group_posts SET upvotes = upvotes + 1 WHERE id = ?`, contentID)
-			} else {
-				_, err = tx.Exec(`UPDATE group_posts SET downvotes = downvotes + 1 WHERE id = ?`, contentID)
-			}
-		case "group_post_comment":
-			if voteType == 1 {
-				_, err = tx.Exec(`UPDATE group_post_comments SET upvotes = upvotes + 1, vote_count = vote_count + 1 WHERE id = ?`, contentID)
-			} else {
-				_, err = tx.Exec(`UPDATE group_post_comments SET downvotes = downvotes + 1, vote_count = vote_count - 1 WHERE id = ?`, contentID)
-			}
-		case "comment":
-			_, err = tx.Exec(`UPDATE comments SET vote_count = vote_count + ? WHERE id = ?`, voteType, contentID)
-		}
-		if err != nil {
-			return err
-		}
+	var upvotes, downvotes int
+	if err := tx.QueryRow(countQuery, contentID, contentType, 1).Scan(&upvotes); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(countQuery, contentID, contentType, -1).Scan(&downvotes); err != nil {
+		return err
 	}
 
-	// Commit transaction
-	return tx.Commit()
+	var err error
+	switch contentType {
+	case "post":
+		_, err = tx.Exec(`UPDATE posts SET upvotes = ?, downvotes = ? WHERE id = ?`, upvotes, downvotes, contentID)
+	case "group_post":
+		_, err = tx.Exec(`UPDATE group_posts SET upvotes = ?, downvotes = ? WHERE id = ?`, upvotes, downvotes, contentID)
+	case "group_post_comment":
+		_, err = tx.Exec(`UPDATE group_post_comments SET upvotes = ?, downvotes = ?, vote_count = ? WHERE id = ?`,
+			upvotes, downvotes, upvotes-downvotes, contentID)
+	case "comment":
+		_, err = tx.Exec(`UPDATE comments SET vote_count = ? WHERE id = ?`, upvotes-downvotes, contentID)
+	}
+	return err
 }
 
 // GetUserVote returns a user's vote for content (post or comment)
@@ -1682,6 +2631,97 @@ func (db *DB) GetUserVote(userID int, contentID int64, contentType string) (int,
 	return voteType, nil
 }
 
+// GetUserVotedContent returns the posts and group posts a user voted on with the given
+// vote type, most recent vote first. Content the user can no longer see, such as posts
+// whose audience changed or group posts in groups they left, is left out.
+func (db *DB) GetUserVotedContent(userID int, voteType int, limit, offset int) ([]map[string]interface{}, error) {
+	condition, args := db.homeFeedCondition(userID)
+
+	query := `
+		SELECT 'post' AS content_type, p.id, p.title, p.content, p.image_url, p.created_at,
+			v.vote_type, v.created_at AS voted_at, u.id, u.first_name, u.last_name, u.avatar,
+			0 AS group_id, '' AS group_name
+		FROM votes v
+		JOIN posts p ON v.content_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE v.user_id = ? AND v.content_type = 'post' AND v.vote_type = ?
+			AND COALESCE(p.is_published, 1) = 1 AND COALESCE(p.is_deleted, 0) = 0
+			AND (p.privacy = 'public' OR ` + condition + `)
+		UNION ALL
+		SELECT 'group_post' AS content_type, gp.id, '' AS title, gp.content, gp.image_path, gp.created_at,
+			v.vote_type, v.created_at AS voted_at, u.id, u.first_name, u.last_name, u.avatar,
+			g.id AS group_id, g.name AS group_name
+		FROM votes v
+		JOIN group_posts gp ON v.content_id = gp.id
+		JOIN groups g ON gp.group_id = g.id
+		JOIN users u ON gp.author_id = u.id
+		WHERE v.user_id = ? AND v.content_type = 'group_post' AND v.vote_type = ?
+			AND COALESCE(gp.is_draft, 0) = 0 AND COALESCE(gp.is_deleted, 0) = 0
+			AND EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = gp.group_id AND gm.user_id = v.user_id)
+		ORDER BY voted_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	queryArgs := []interface{}{userID, voteType}
+	queryArgs = append(queryArgs, args...)
+	queryArgs = append(queryArgs, userID, voteType, limit, offset)
+
+	rows, err := db.Query(query, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voted content: %v", err)
+	}
+	defer rows.Close()
+
+	items := []map[string]interface{}{}
+	for rows.Next() {
+		var (
+			contentType, title, content, createdAt, votedAt string
+			firstName, lastName, groupName                  string
+			contentID, authorID, groupID                    int64
+			vote                                            int
+			imageURL, avatar                                sql.NullString
+		)
+
+		err := rows.Scan(&contentType, &contentID, &title, &content, &imageURL, &createdAt,
+			&vote, &votedAt, &authorID, &firstName, &lastName, &avatar, &groupID, &groupName)
+		if err != nil {
+			return nil, err
+		}
+
+		item := map[string]interface{}{
+			"content_type": contentType,
+			"content_id":   contentID,
+			"title":        title,
+			"content":      content,
+			"created_at":   createdAt,
+			"vote_type":    vote,
+			"voted_at":     votedAt,
+			"author": map[string]interface{}{
+				"id":         authorID,
+				"first_name": firstName,
+				"last_name":  lastName,
+			},
+		}
+
+		if imageURL.Valid {
+			item["image_url"] = imageURL.String
+		}
+
+		if avatar.Valid {
+			item["author"].(map[string]interface{})["avatar"] = avatar.String
+		}
+
+		if contentType == "group_post" {
+			item["group_id"] = groupID
+			item["group_name"] = groupName
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
 // For backward compatibility - uses the generalized Vote function
 func (db *DB) VotePost(userID int, postID int64, voteType int) error {
 	return db.Vote(userID, postID, "post", voteType)
@@ -1712,45 +2752,46 @@ func (db *DB) GetCommentsByPostIDWithUserVotes(postID int64, userID int) ([]map[
 	return comments, nil
 }
 
-// SearchUsers searches for users based on the provided search term
-func (db *DB) SearchUsers(searchTerm string) ([]map[string]interface{}, error) {
+// SearchUsers finds users whose name, nickname or email contains the query (case-insensitive).
+// Exact matches rank first, then first name or nickname prefixes, then last name prefixes,
+// so "jo" ranks John above Bojo. The viewer, users the viewer blocked and users who blocked
+// the viewer are left out
+func (db *DB) SearchUsers(searchTerm string, viewerID int64, limit int) ([]map[string]interface{}, error) {
 	query := `
 		SELECT 
 			id, email, first_name, last_name, avatar, nickname, about_me, is_public 
 		FROM 
 			users 
 		WHERE 
-			LOWER(first_name) LIKE ? OR 
-			LOWER(last_name) LIKE ? OR 
-			LOWER(first_name || ' ' || last_name) LIKE ? OR
-			LOWER(nickname) LIKE ? OR
-			LOWER(email) LIKE ?
+			(
+				LOWER(first_name) LIKE ?1 ESCAPE '\' OR 
+				LOWER(last_name) LIKE ?1 ESCAPE '\' OR 
+				LOWER(first_name || ' ' || last_name) LIKE ?1 ESCAPE '\' OR
+				LOWER(nickname) LIKE ?1 ESCAPE '\' OR
+				LOWER(email) LIKE ?1 ESCAPE '\'
+			)
+			AND id != ?4
+			AND id NOT IN (SELECT blocked_id FROM blocked_users WHERE blocker_id = ?4)
+			AND id NOT IN (SELECT blocker_id FROM blocked_users WHERE blocked_id = ?4)
 		ORDER BY
 			CASE 
-				WHEN LOWER(first_name) = LOWER(?) THEN 1
-				WHEN LOWER(last_name) = LOWER(?) THEN 2
-				WHEN LOWER(nickname) = LOWER(?) THEN 3
+				WHEN LOWER(first_name) = ?3 OR LOWER(last_name) = ?3 OR
+					LOWER(nickname) = ?3 OR LOWER(first_name || ' ' || last_name) = ?3 THEN 1
+				WHEN LOWER(first_name) LIKE ?2 ESCAPE '\' OR LOWER(nickname) LIKE ?2 ESCAPE '\' THEN 2
+				WHEN LOWER(last_name) LIKE ?2 ESCAPE '\' THEN 3
 				ELSE 4
 			END,
 			first_name ASC,
 			last_name ASC
-		LIMIT 20
+		LIMIT ?5
 	`
 
-	// Remove '%' from searchTerm for exact match in ORDER BY clause
-	exactTerm := strings.TrimPrefix(strings.TrimSuffix(searchTerm, "%"), "%")
-
-	rows, err := db.Query(
-		query,
-		searchTerm,
-		searchTerm,
-		searchTerm,
-		searchTerm,
-		searchTerm,
-		exactTerm,
-		exactTerm,
-		exactTerm,
-	)
+	// The prefix pattern drops the leading wildcard of the contains pattern
+	contains := likePattern(searchTerm)
+	prefix := strings.TrimPrefix(contains, "%")
+	exactTerm := strings.ToLower(searchTerm)
+
+	rows, err := db.Query(query, contains, prefix, exactTerm, viewerID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1800,6 +2841,83 @@ func (db *DB) SearchUsers(searchTerm string) ([]map[string]interface{}, error) {
 	return users, nil
 }
 
+// GetSuggestedUsers returns public users the user doesn't follow yet, people followed by the
+// user's followings first, then people sharing the most groups with the user.
+// Blocked users in either direction are left out. Each suggestion includes a short reason
+func (db *DB) GetSuggestedUsers(userID int64, limit int) ([]map[string]interface{}, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.avatar, u.nickname,
+			(SELECT COUNT(*) FROM followers f1
+			 JOIN followers f2 ON f2.follower_id = f1.following_id
+			 WHERE f1.follower_id = ?1 AND f2.following_id = u.id) AS followed_by_count,
+			(SELECT COUNT(*) FROM group_members gm1
+			 JOIN group_members gm2 ON gm2.group_id = gm1.group_id
+			 WHERE gm1.user_id = ?1 AND gm2.user_id = u.id) AS shared_group_count
+		FROM users u
+		WHERE u.is_public = 1 AND u.id != ?1
+			AND u.id NOT IN (SELECT following_id FROM followers WHERE follower_id = ?1)
+			AND u.id NOT IN (SELECT blocked_id FROM blocked_users WHERE blocker_id = ?1)
+			AND u.id NOT IN (SELECT blocker_id FROM blocked_users WHERE blocked_id = ?1)
+		ORDER BY followed_by_count DESC, shared_group_count DESC, u.created_at DESC
+		LIMIT ?2
+	`
+
+	rows, err := db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggested users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []map[string]interface{}{}
+
+	for rows.Next() {
+		var id int64
+		var firstName, lastName string
+		var avatar, nickname sql.NullString
+		var followedByCount, sharedGroupCount int
+
+		if err := rows.Scan(&id, &firstName, &lastName, &avatar, &nickname, &followedByCount, &sharedGroupCount); err != nil {
+			return nil, err
+		}
+
+		user := map[string]interface{}{
+			"id":                 id,
+			"first_name":         firstName,
+			"last_name":          lastName,
+			"followed_by_count":  followedByCount,
+			"shared_group_count": sharedGroupCount,
+			"reason":             suggestionReason(followedByCount, sharedGroupCount),
+		}
+
+		if avatar.Valid {
+			user["avatar"] = avatar.String
+		}
+		if nickname.Valid {
+			user["nickname"] = nickname.String
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// suggestionReason explains why a user was suggested
+func suggestionReason(followedByCount, sharedGroupCount int) string {
+	switch {
+	case followedByCount == 1:
+		return "Followed by 1 person you follow"
+	case followedByCount > 1:
+		return fmt.Sprintf("Followed by %d people you follow", followedByCount)
+	case sharedGroupCount == 1:
+		return "In 1 group with you"
+	case sharedGroupCount > 1:
+		return fmt.Sprintf("In %d groups with you", sharedGroupCount)
+	default:
+		return "New to the network"
+	}
+}
+
 // CancelFollowRequest cancels a follow request created by the follower
 func (db *DB) CancelFollowRequest(followerID, followingID int64) error {
 	// Check if follow_requests table exists
@@ -1828,6 +2946,195 @@ func (db *DB) CancelFollowRequest(followerID, followingID int64) error {
 	return nil
 }
 
+// BlockUser records that blockerID has blocked blockedID and, in the same
+// transaction, removes follows, pending follow requests and close friend
+// entries between the two users in both directions
+func (db *DB) BlockUser(blockerID, blockedID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO blocked_users (blocker_id, blocked_id) VALUES (?, ?)`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to block user: %v", err)
+	}
+
+	deletions := []struct {
+		query string
+		desc  string
+	}{
+		{`DELETE FROM followers WHERE (follower_id = ?1 AND following_id = ?2) OR (follower_id = ?2 AND following_id = ?1)`, "follows"},
+		{`DELETE FROM follow_requests WHERE (requester_id = ?1 AND requested_id = ?2) OR (requester_id = ?2 AND requested_id = ?1)`, "follow requests"},
+		{`DELETE FROM close_friends WHERE (user_id = ?1 AND friend_id = ?2) OR (user_id = ?2 AND friend_id = ?1)`, "close friends"},
+	}
+
+	// follow_requests is only created on the first request
+	lazyTables := map[string]string{"follow requests": "follow_requests"}
+
+	for _, deletion := range deletions {
+		if table, ok := lazyTables[deletion.desc]; ok {
+			var exists int
+			err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists)
+			if err != nil {
+				return fmt.Errorf("failed to check for %s table: %v", table, err)
+			}
+			if exists == 0 {
+				continue
+			}
+		}
+
+		if _, err := tx.Exec(deletion.query, blockerID, blockedID); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", deletion.desc, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// UnblockUser removes a block created by blockerID
+func (db *DB) UnblockUser(blockerID, blockedID int64) error {
+	query := `DELETE FROM blocked_users WHERE blocker_id = ? AND blocked_id = ?`
+	result, err := db.Exec(query, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user is not blocked")
+	}
+
+	return nil
+}
+
+// IsBlocked checks if blockerID has blocked blockedID
+func (db *DB) IsBlocked(blockerID, blockedID int64) (bool, error) {
+	query := `SELECT 1 FROM blocked_users WHERE blocker_id = ? AND blocked_id = ?`
+	var exists int
+	err := db.QueryRow(query, blockerID, blockedID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AddCloseFriend adds friendID to userID's close friends list
+func (db *DB) AddCloseFriend(userID, friendID int) error {
+	query := `INSERT OR IGNORE INTO close_friends (user_id, friend_id) VALUES (?, ?)`
+	_, err := db.Exec(query, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to add close friend: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveCloseFriend removes friendID from userID's close friends list
+func (db *DB) RemoveCloseFriend(userID, friendID int) error {
+	query := `DELETE FROM close_friends WHERE user_id = ? AND friend_id = ?`
+	result, err := db.Exec(query, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to remove close friend: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user is not a close friend")
+	}
+
+	return nil
+}
+
+// GetCloseFriends returns the users on a user's close friends list
+func (db *DB) GetCloseFriends(userID int) ([]map[string]interface{}, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.avatar
+		FROM close_friends cf
+		JOIN users u ON cf.friend_id = u.id
+		WHERE cf.user_id = ?
+		ORDER BY u.first_name, u.last_name
+	`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friends := []map[string]interface{}{}
+
+	for rows.Next() {
+		var id int
+		var firstName, lastName string
+		var avatar sql.NullString
+
+		err := rows.Scan(&id, &firstName, &lastName, &avatar)
+		if err != nil {
+			return nil, err
+		}
+
+		friend := map[string]interface{}{
+			"id":         id,
+			"first_name": firstName,
+			"last_name":  lastName,
+		}
+
+		if avatar.Valid {
+			friend["avatar"] = avatar.String
+		}
+
+		friends = append(friends, friend)
+	}
+
+	return friends, rows.Err()
+}
+
+// GetCloseFriendFollowerIDs returns the IDs of close friends who still follow the user,
+// which is the audience used for private posts shared with close friends
+func (db *DB) GetCloseFriendFollowerIDs(userID int) ([]int, error) {
+	query := `
+		SELECT cf.friend_id
+		FROM close_friends cf
+		JOIN followers f ON f.follower_id = cf.friend_id AND f.following_id = cf.user_id
+		WHERE cf.user_id = ?
+	`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get close friends: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan close friend: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // CheckFollowRequestExistsById checks if a follow request exists by its ID
 func (db *DB) CheckFollowRequestExistsById(requestID int64) (bool, error) {
 	// Check if follow_requests table exists
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestGetGroupsFromFollowingsIncludesPostCount(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "user")
+	friend := createTestUser(t, db, "friend")
+
+	groupID, err := db.CreateGroup(&Group{Name: "Friends", CreatorID: int64(friend), Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := db.CreateGroupPost(&GroupPost{GroupID: groupID, AuthorID: int64(friend), Content: "Hello"}); err != nil {
+			t.Fatalf("Failed to create group post: %v", err)
+		}
+	}
+	if err := db.FollowUser(user, friend); err != nil {
+		t.Fatalf("Failed to follow: %v", err)
+	}
+
+	groups, err := db.GetGroupsFromFollowings(int64(user), 10, 0)
+	if err != nil {
+		t.Fatalf("GetGroupsFromFollowings returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("Got %d suggested groups, want 1", len(groups))
+	}
+	if groups[0].PostCount != 2 || groups[0].FollowingMemberCount != 1 {
+		t.Errorf("Got post count %d and following members %d, want 2 and 1", groups[0].PostCount, groups[0].FollowingMemberCount)
+	}
+	if groups[0].LastActivityAt == nil {
+		t.Error("Last activity is missing")
+	}
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetUpcomingEventsForUser(t *testing.T) {
+	db := newTestDB(t)
+	member := int64(createTestUser(t, db, "member"))
+	outsider := int64(createTestUser(t, db, "outsider"))
+
+	groupID, err := db.CreateGroup(&Group{Name: "Book club", CreatorID: member, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	otherGroupID, err := db.CreateGroup(&Group{Name: "Other club", CreatorID: outsider, Privacy: "public"})
+	if err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	createEvent := func(groupID int64, title string, date time.Time, recurrence string) int64 {
+		t.Helper()
+		eventID, err := db.CreateGroupEvent(&GroupEvent{
+			GroupID:    groupID,
+			CreatorID:  member,
+			Title:      title,
+			EventDate:  date,
+			Recurrence: recurrence,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create event %s: %v", title, err)
+		}
+		return eventID
+	}
+
+	createEvent(groupID, "Past", now.AddDate(0, 0, -3), "none")
+	soonID := createEvent(groupID, "Soon", now.Add(2*time.Hour), "none")
+	createEvent(groupID, "Later", now.AddDate(0, 0, 10), "none")
+	createEvent(groupID, "Weekly", now.AddDate(0, 0, -13).Add(-time.Hour), "weekly")
+	createEvent(otherGroupID, "Not mine", now.Add(time.Hour), "none")
+
+	if err := db.RespondToEvent(soonID, member, "going", ""); err != nil {
+		t.Fatalf("Failed to respond to event: %v", err)
+	}
+
+	events, err := db.GetUpcomingEventsForUser(member, 3)
+	if err != nil {
+		t.Fatalf("GetUpcomingEventsForUser returned error: %v", err)
+	}
+
+	// The weekly series started in the past, its next occurrence is in a day
+	wantTitles := []string{"Soon", "Weekly", "Weekly"}
+	if len(events) != len(wantTitles) {
+		t.Fatalf("Got %d events, want %d", len(events), len(wantTitles))
+	}
+	for i, event := range events {
+		if event.Title != wantTitles[i] {
+			t.Errorf("Event %d is %q, want %q", i, event.Title, wantTitles[i])
+		}
+		if event.GroupName != "Book club" {
+			t.Errorf("Event %d has group name %q, want %q", i, event.GroupName, "Book club")
+		}
+		if event.EventDate.Before(now) {
+			t.Errorf("Event %d starts at %v, which has passed", i, event.EventDate)
+		}
+		if i > 0 && event.EventDate.Before(events[i-1].EventDate) {
+			t.Errorf("Event %d is out of order", i)
+		}
+	}
+
+	if events[0].UserResponse != "going" || events[0].GoingCount != 1 {
+		t.Errorf("Got response %q with %d going, want going with 1", events[0].UserResponse, events[0].GoingCount)
+	}
+	if events[1].OccurrenceDate == "" {
+		t.Error("Recurring occurrence has no occurrence date")
+	}
+
+	all, err := db.GetUpcomingEventsForUser(member, 0)
+	if err != nil {
+		t.Fatalf("GetUpcomingEventsForUser returned error: %v", err)
+	}
+	for _, event := range all {
+		if event.Title == "Past" || event.Title == "Not mine" {
+			t.Errorf("Got event %q, which should not be listed", event.Title)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"testing"
+)
+
+func TestUpdatePostKeepsAudienceUnlessReplaced(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+	follower := createTestUser(t, db, "follower")
+
+	postID, err := db.CreatePost(owner, "Title", "Content", "", "private", []int{follower})
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	// A nil audience leaves the access rows untouched
+	if err := db.UpdatePost(postID, "New title", "Content", "private", "", nil); err != nil {
+		t.Fatalf("UpdatePost returned error: %v", err)
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM post_access WHERE post_id = ?`, postID); count != 1 {
+		t.Errorf("Got %d access rows after a title edit, want 1", count)
+	}
+
+	// An empty audience replaces it
+	if err := db.UpdatePost(postID, "New title", "Content", "private", "", []int{}); err != nil {
+		t.Fatalf("UpdatePost returned error: %v", err)
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM post_access WHERE post_id = ?`, postID); count != 0 {
+		t.Errorf("Got %d access rows after clearing the audience, want 0", count)
+	}
+}
+
+func TestPostThumbnailClearedWhenImageChanges(t *testing.T) {
+	db := newTestDB(t)
+	owner := createTestUser(t, db, "owner")
+
+	postID, err := db.CreatePost(owner, "Title", "Content", "/uploads/posts/a.png", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if err := db.SetPostThumbnail(postID, "/uploads/posts/a_thumb.png"); err != nil {
+		t.Fatalf("SetPostThumbnail returned error: %v", err)
+	}
+
+	post, err := db.GetPost(postID)
+	if err != nil {
+		t.Fatalf("Failed to get post: %v", err)
+	}
+	if got := post["thumbnail_url"]; got != "/uploads/posts/a_thumb.png" {
+		t.Errorf("Thumbnail is %v, want /uploads/posts/a_thumb.png", got)
+	}
+
+	// Editing the text keeps the thumbnail
+	if err := db.UpdatePost(postID, "New title", "Content", "public", "/uploads/posts/a.png", nil); err != nil {
+		t.Fatalf("UpdatePost returned error: %v", err)
+	}
+	if post, _ := db.GetPost(postID); post["thumbnail_url"] != "/uploads/posts/a_thumb.png" {
+		t.Errorf("Thumbnail is %v after a text edit, want it kept", post["thumbnail_url"])
+	}
+
+	// Replacing the image drops it
+	if err := db.UpdatePost(postID, "New title", "Content", "public", "/uploads/posts/b.png", nil); err != nil {
+		t.Fatalf("UpdatePost returned error: %v", err)
+	}
+	if post, _ := db.GetPost(postID); post["thumbnail_url"] != nil {
+		t.Errorf("Thumbnail is %v after replacing the image, want none", post["thumbnail_url"])
+	}
+}
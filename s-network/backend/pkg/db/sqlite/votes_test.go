@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDB creates a database in a temporary directory with all tables initialized
+func newTestDB(t testing.TB) *DB {
+	t.Helper()
+
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// createTestUser inserts a user with a unique email and returns its ID
+func createTestUser(t testing.TB, db *DB, name string) int {
+	t.Helper()
+
+	userID, err := db.CreateUser(fmt.Sprintf("%s@example.com", name), "password", name, "Test", "2000-01-01", "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to create user %s: %v", name, err)
+	}
+
+	return int(userID)
+}
+
+func getPostVoteCounts(t *testing.T, db *DB, postID int64) (int, int) {
+	t.Helper()
+
+	var upvotes, downvotes int
+	err := db.QueryRow(`SELECT upvotes, downvotes FROM posts WHERE id = ?`, postID).Scan(&upvotes, &downvotes)
+	if err != nil {
+		t.Fatalf("Failed to get vote counts: %v", err)
+	}
+
+	return upvotes, downvotes
+}
+
+func TestVotePost(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	postID, err := db.CreatePost(author, "Title", "Content", "", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	steps := []struct {
+		name          string
+		userID        int
+		voteType      int
+		wantUpvotes   int
+		wantDownvotes int
+		wantUserVote  int
+	}{
+		{name: "New upvote", userID: alice, voteType: 1, wantUpvotes: 1, wantDownvotes: 0, wantUserVote: 1},
+		{name: "Second user downvotes", userID: bob, voteType: -1, wantUpvotes: 1, wantDownvotes: 1, wantUserVote: -1},
+		{name: "Switch upvote to downvote", userID: alice, voteType: -1, wantUpvotes: 0, wantDownvotes: 2, wantUserVote: -1},
+		{name: "Toggle off downvote", userID: alice, voteType: -1, wantUpvotes: 0, wantDownvotes: 1, wantUserVote: 0},
+		{name: "Switch downvote to upvote", userID: bob, voteType: 1, wantUpvotes: 1, wantDownvotes: 0, wantUserVote: 1},
+		{name: "Toggle off upvote", userID: bob, voteType: 1, wantUpvotes: 0, wantDownvotes: 0, wantUserVote: 0},
+	}
+
+	for _, step := range steps {
+		if err := db.Vote(step.userID, postID, "post", step.voteType); err != nil {
+			t.Fatalf("%s: Vote returned error: %v", step.name, err)
+		}
+
+		upvotes, downvotes := getPostVoteCounts(t, db, postID)
+		if upvotes != step.wantUpvotes || downvotes != step.wantDownvotes {
+			t.Errorf("%s: got %d upvotes and %d downvotes, want %d and %d",
+				step.name, upvotes, downvotes, step.wantUpvotes, step.wantDownvotes)
+		}
+
+		userVote, err := db.GetUserVote(step.userID, postID, "post")
+		if err != nil {
+			t.Fatalf("%s: GetUserVote returned error: %v", step.name, err)
+		}
+		if userVote != step.wantUserVote {
+			t.Errorf("%s: got user vote %d, want %d", step.name, userVote, step.wantUserVote)
+		}
+	}
+}
+
+func TestVoteRepairsDriftedCounts(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	alice := createTestUser(t, db, "alice")
+
+	postID, err := db.CreatePost(author, "Title", "Content", "", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	// Simulate counts that drifted from the votes table
+	if _, err := db.Exec(`UPDATE posts SET upvotes = 5, downvotes = 3 WHERE id = ?`, postID); err != nil {
+		t.Fatalf("Failed to set vote counts: %v", err)
+	}
+
+	if err := db.Vote(alice, postID, "post", 1); err != nil {
+		t.Fatalf("Vote returned error: %v", err)
+	}
+
+	upvotes, downvotes := getPostVoteCounts(t, db, postID)
+	if upvotes != 1 || downvotes != 0 {
+		t.Errorf("got %d upvotes and %d downvotes, want 1 and 0", upvotes, downvotes)
+	}
+}
+
+func TestVoteComment(t *testing.T) {
+	db := newTestDB(t)
+	author := createTestUser(t, db, "author")
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	postID, err := db.CreatePost(author, "Title", "Content", "", "public", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	commentID, err := db.AddComment(postID, int64(author), "Comment", "")
+	if err != nil {
+		t.Fatalf("Failed to create comment: %v", err)
+	}
+
+	steps := []struct {
+		name          string
+		userID        int
+		voteType      int
+		wantVoteCount int
+	}{
+		{name: "New upvote", userID: alice, voteType: 1, wantVoteCount: 1},
+		{name: "Second user upvotes", userID: bob, voteType: 1, wantVoteCount: 2},
+		{name: "Switch upvote to downvote", userID: alice, voteType: -1, wantVoteCount: 0},
+		{name: "Toggle off downvote", userID: alice, voteType: -1, wantVoteCount: 1},
+		{name: "Toggle off upvote", userID: bob, voteType: 1, wantVoteCount: 0},
+	}
+
+	for _, step := range steps {
+		if err := db.Vote(step.userID, commentID, "comment", step.voteType); err != nil {
+			t.Fatalf("%s: Vote returned error: %v", step.name, err)
+		}
+
+		var voteCount int
+		err := db.QueryRow(`SELECT vote_count FROM comments WHERE id = ?`, commentID).Scan(&voteCount)
+		if err != nil {
+			t.Fatalf("%s: Failed to get vote count: %v", step.name, err)
+		}
+		if voteCount != step.wantVoteCount {
+			t.Errorf("%s: got vote count %d, want %d", step.name, voteCount, step.wantVoteCount)
+		}
+	}
+}
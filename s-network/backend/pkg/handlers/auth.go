@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"golang.org/x/crypto/bcrypt"
 
@@ -89,6 +91,8 @@ func createAuthToken(userID int, tokenType string) (string, error) {
 		expiryDuration = 30 * 24 * time.Hour // 30 days
 	case "api":
 		expiryDuration = 90 * 24 * time.Hour // 90 days
+	case "password_reset":
+		expiryDuration = time.Hour // 1 hour
 	default:
 		expiryDuration = 24 * time.Hour // 1 day default
 	}
@@ -164,6 +168,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 				"image/jpg":  true,
 				"image/png":  true,
 				"image/gif":  true,
+				"image/webp": true,
 			}
 
 			contentType := header.Header.Get("Content-Type")
@@ -171,7 +176,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusBadRequest)
 				json.NewEncoder(w).Encode(map[string]string{
-					"error": "Invalid file type. Only JPEG, PNG, and GIF are allowed.",
+					"error": "Invalid file type. Only " + supportedImageFormats + " are allowed.",
 				})
 				return
 			}
@@ -609,11 +614,30 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 	// Remove password from response
 	delete(user, "password")
 
+	addProfileCounts(user, userID)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(user)
 }
 
+// addProfileCounts adds follower, following and post counts to a user map.
+// These are plain aggregates, so they are shown regardless of privacy.
+func addProfileCounts(user map[string]interface{}, userID int) {
+	followers, following, err := db.GetFollowCounts(userID)
+	if err != nil {
+		fmt.Printf("\033[33m[WARNING] Failed to get follow counts for user %d: %v\033[0m\n", userID, err)
+	}
+	user["followers_count"] = followers
+	user["following_count"] = following
+
+	postsCount, err := db.GetPostCount(userID)
+	if err != nil {
+		fmt.Printf("\033[33m[WARNING] Failed to get post count for user %d: %v\033[0m\n", userID, err)
+	}
+	user["posts_count"] = postsCount
+}
+
 // AuthMiddleware checks if the user is authenticated
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -835,11 +859,13 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 			ext = ".png"
 		case "image/gif":
 			ext = ".gif"
+		case "image/webp":
+			ext = ".webp"
 		default:
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Unsupported image format",
+				"error": "Unsupported image format. Only " + supportedImageFormats + " images are allowed",
 			})
 			return
 		}
@@ -920,11 +946,13 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 			ext = ".png"
 		case "image/gif":
 			ext = ".gif"
+		case "image/webp":
+			ext = ".webp"
 		default:
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Unsupported image format",
+				"error": "Unsupported image format. Only " + supportedImageFormats + " images are allowed",
 			})
 			return
 		}
@@ -1017,36 +1045,801 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetCurrentUser returns the currently logged-in user's information
-func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	// Get session
-	session, err := store.Get(r, SessionCookieName)
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// DeleteAvatar removes the current user's avatar so the default one is shown again
+func DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	removeProfileImage(w, r, "avatar", "avatars")
+}
+
+// DeleteBanner removes the current user's banner
+func DeleteBanner(w http.ResponseWriter, r *http.Request) {
+	removeProfileImage(w, r, "banner", "banners")
+}
+
+// removeProfileImage clears a profile image column and deletes the uploaded file from disk
+func removeProfileImage(w http.ResponseWriter, r *http.Request, field, subdir string) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	// Check if user is authenticated
-	auth, ok := session.Values["authenticated"].(bool)
-	if !ok || !auth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	// Check authentication
+	session, _ := store.Get(r, SessionCookieName)
+	sessionID, ok := session.Values["session_id"].(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	// Get session from database
+	dbSession, err := db.GetSession(sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session expired or invalid",
+		})
 		return
 	}
 
 	// Get user ID from session
-	userID, ok := session.Values["user_id"].(int)
+	userID := dbSession["user_id"].(int)
+
+	currentUser, err := db.GetUserById(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to retrieve current profile",
+		})
+		return
+	}
+
+	// Cleared to an empty string like accounts registered without an avatar
+	err = db.UpdateUser(userID, map[string]interface{}{field: ""})
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to update profile: " + err.Error(),
+		})
+		return
+	}
+
+	// Only delete files this user uploaded, never the bundled defaults
+	if oldPath, ok := currentUser[field].(string); ok {
+		prefix := utils.GetUploadURL("", subdir)
+		filename := filepath.Base(oldPath)
+		if strings.HasPrefix(oldPath, prefix) && !strings.HasPrefix(filename, "default") {
+			fullPath := filepath.Join(utils.GetUploadSubdir(subdir), filename)
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("\033[33m[WARNING] Failed to remove %s %s: %v\033[0m\n", field, fullPath, err)
+			}
+		}
+	}
+
+	user, err := db.GetUserById(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to retrieve updated profile",
+		})
+		return
+	}
+
+	// Remove password from response
+	delete(user, "password")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Profile " + field + " removed successfully",
+		"user":    user,
+	})
+}
+
+// ChangePassword updates the current user's password and signs out their other sessions
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Check authentication
+	session, _ := store.Get(r, SessionCookieName)
+	sessionID, ok := session.Values["session_id"].(string)
 	if !ok {
-		http.Error(w, "Invalid session data", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	// Get session from database
+	dbSession, err := db.GetSession(sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session expired or invalid",
+		})
+		return
+	}
+
+	// Get user ID from session
+	userID := dbSession["user_id"].(int)
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Current password and new password are required",
+		})
 		return
 	}
 
-	// Get user data from database
 	user, err := db.GetUserById(userID)
 	if err != nil {
-		http.Error(w, "Failed to get user data", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Verify the current password
+	err = bcrypt.CompareHashAndPassword([]byte(user["password"].(string)), []byte(req.CurrentPassword))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Current password is incorrect",
+		})
+		return
+	}
+
+	// Validate password strength
+	passwordValidation := utils.ValidatePassword(req.NewPassword)
+	if !passwordValidation.IsValid {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":           "Password does not meet security requirements: " + strings.Join(passwordValidation.Errors, ", "),
+			"password_errors": passwordValidation.Errors,
+		})
+		return
+	}
+
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to process password",
+		})
+		return
+	}
+
+	err = db.UpdateUserPassword(userID, string(hashedPassword))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to update password",
+		})
+		return
+	}
+
+	// Kick out any other (possibly stolen) sessions, keeping this one signed in
+	err = db.DeleteOtherSessions(userID, sessionID)
+	if err != nil {
+		fmt.Printf("\033[33m[WARNING] Failed to delete other sessions for user %d: %v\033[0m\n", userID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password changed successfully",
+	})
+}
+
+// GetUserSessions lists the current user's active sessions
+func GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Check authentication
+	session, _ := store.Get(r, SessionCookieName)
+	sessionID, ok := session.Values["session_id"].(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	// Get session from database
+	dbSession, err := db.GetSession(sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session expired or invalid",
+		})
+		return
+	}
+
+	// Get user ID from session
+	userID := dbSession["user_id"].(int)
+
+	sessions, err := db.GetSessionsByUserID(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	if sessions == nil {
+		sessions = []sqlite.Session{}
+	}
+
+	// Mark the session making this request so the UI can label it
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].ID == sessionID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession signs out one of the current user's sessions, identified by its handle
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Check authentication
+	session, _ := store.Get(r, SessionCookieName)
+	sessionID, ok := session.Values["session_id"].(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
 		return
 	}
 
+	// Get session from database
+	dbSession, err := db.GetSession(sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session expired or invalid",
+		})
+		return
+	}
+
+	// Get user ID from session
+	userID := dbSession["user_id"].(int)
+
+	// Sessions are addressed by the handle from GetUserSessions, never the cookie value.
+	// Only the caller's own sessions are searched
+	handle := mux.Vars(r)["id"]
+	sessions, err := db.GetSessionsByUserID(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to get sessions",
+		})
+		return
+	}
+
+	targetID := ""
+	for _, s := range sessions {
+		if s.Handle == handle {
+			targetID = s.ID
+			break
+		}
+	}
+	if targetID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session not found",
+		})
+		return
+	}
+
+	err = db.DeleteSession(targetID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to revoke session",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Session revoked successfully",
+		"is_current": targetID == sessionID,
+	})
+}
+
+// PasswordResetMailer delivers a password reset token to the account's email. It is set
+// at startup when SMTP is configured. While it is nil no reset tokens are issued
+var PasswordResetMailer func(email, token string) error
+
+// NewSMTPPasswordResetMailer returns a PasswordResetMailer that emails the token through
+// the given SMTP server. When resetURL is set the email links to it with the token as the
+// token query parameter, otherwise it contains only the token
+func NewSMTPPasswordResetMailer(config utils.SMTPConfig, resetURL string) func(email, token string) error {
+	return func(email, token string) error {
+		instructions := "Use this token to reset your password: " + token
+		if resetURL != "" {
+			instructions = "Open this link to reset your password: " + resetURL + "?token=" + url.QueryEscape(token)
+		}
+
+		body := "We received a request to reset the password for your account.\r\n\r\n" +
+			instructions + "\r\n\r\n" +
+			"The link expires in one hour. If you did not request a reset, you can ignore this email.\r\n"
+
+		return config.SendMail(email, "Reset your password", body)
+	}
+}
+
+// LogPasswordResetMailer is a development-only mailer that prints reset tokens to the
+// server log. Anyone who can read the log can reset the account, never use it in production
+func LogPasswordResetMailer(email, token string) error {
+	fmt.Printf("\033[36m[DEV] Password reset token for %s: %s\033[0m\n", email, token)
+	return nil
+}
+
+// RequestPasswordReset creates a password reset token for the account with the given email.
+// It responds the same way whether or not the email exists to avoid user enumeration.
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Email is required",
+		})
+		return
+	}
+
+	user, err := db.GetUserByEmail(email)
+	if err == nil && user != nil {
+		if PasswordResetMailer == nil {
+			fmt.Printf("\033[33m[WARNING] No password reset mailer configured, not creating a token for user %d\033[0m\n", user["id"].(int))
+		} else if token, err := createAuthToken(user["id"].(int), "password_reset"); err != nil {
+			fmt.Printf("\033[33m[WARNING] Failed to create password reset token for user %d: %v\033[0m\n", user["id"].(int), err)
+		} else if err := PasswordResetMailer(email, token); err != nil {
+			fmt.Printf("\033[33m[WARNING] Failed to send password reset token for user %d: %v\033[0m\n", user["id"].(int), err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If an account with that email exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password using a password reset token
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if req.Token == "" || req.NewPassword == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Token and new password are required",
+		})
+		return
+	}
+
+	// Validate the token
+	token, err := db.GetAuthToken(req.Token)
+	if err != nil || token["token_type"] != "password_reset" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid or expired reset token",
+		})
+		return
+	}
+
+	// Check the expiry here as well since expires_at is stored in RFC3339 format
+	expiresAt, err := time.Parse(time.RFC3339, token["expires_at"].(string))
+	if err != nil || time.Now().After(expiresAt) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Invalid or expired reset token",
+		})
+		return
+	}
+
+	// Validate password strength
+	passwordValidation := utils.ValidatePassword(req.NewPassword)
+	if !passwordValidation.IsValid {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":           "Password does not meet security requirements: " + strings.Join(passwordValidation.Errors, ", "),
+			"password_errors": passwordValidation.Errors,
+		})
+		return
+	}
+
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to process password",
+		})
+		return
+	}
+
+	userID := token["user_id"].(int)
+	err = db.UpdateUserPassword(userID, string(hashedPassword))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to update password",
+		})
+		return
+	}
+
+	// Reset tokens are single use
+	err = db.DeleteAuthToken(req.Token)
+	if err != nil {
+		fmt.Printf("\033[33m[WARNING] Failed to delete password reset token for user %d: %v\033[0m\n", userID, err)
+	}
+
+	// Sign out existing sessions since the old password may have been compromised
+	err = db.DeleteSessionsByUserID(userID)
+	if err != nil {
+		fmt.Printf("\033[33m[WARNING] Failed to delete sessions for user %d: %v\033[0m\n", userID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password has been reset successfully",
+	})
+}
+
+// DeleteAccount permanently deletes the current user's account after confirming their password.
+// Groups the user created are deleted as well rather than transferred
+func DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	// Handle preflight OPTIONS request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Check authentication
+	session, _ := store.Get(r, SessionCookieName)
+	sessionID, ok := session.Values["session_id"].(string)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	// Get session from database
+	dbSession, err := db.GetSession(sessionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Session expired or invalid",
+		})
+		return
+	}
+
+	// Get user ID from session
+	userID := dbSession["user_id"].(int)
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Password is required to delete your account",
+		})
+		return
+	}
+
+	user, err := db.GetUserById(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Re-confirm the password before doing anything irreversible
+	err = bcrypt.CompareHashAndPassword([]byte(user["password"].(string)), []byte(req.Password))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Incorrect password",
+		})
+		return
+	}
+
+	deletedGroups, err := db.GetCreatedGroupIDs(int64(userID))
+	if err != nil {
+		fmt.Printf("\033[31m[ERROR] Failed to get groups created by user %d: %v\033[0m\n", userID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to delete account",
+		})
+		return
+	}
+	if deletedGroups == nil {
+		deletedGroups = []int64{}
+	}
+
+	err = db.DeleteUser(int64(userID))
+	if err != nil {
+		fmt.Printf("\033[31m[ERROR] Failed to delete user %d: %v\033[0m\n", userID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to delete account",
+		})
+		return
+	}
+
+	// Clear the cookie, the session row was removed with the user
+	session.Options.MaxAge = -1
+	session.Save(r, w)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "Account deleted successfully",
+		"deleted_groups": deletedGroups,
+		"groups_note":    "Groups you created were deleted together with their posts, events and chats",
+	})
+}
+
+// exportPageSize is how many rows are fetched per query when building an account export
+const exportPageSize = 100
+
+// ExportAccountData sends the current user's own data as a downloadable JSON file.
+// Other users only appear by id, name and avatar
+func ExportAccountData(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	export, err := buildAccountExport(userID)
+	if err != nil {
+		fmt.Printf("\033[31m[ERROR] Failed to export data for user %d: %v\033[0m\n", userID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to export account data",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("account_export_%d_%s.json", userID, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(export)
+}
+
+// buildAccountExport collects everything that belongs to a user into one bundle
+func buildAccountExport(userID int) (map[string]interface{}, error) {
+	profile, err := db.GetUserById(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %v", err)
+	}
+	delete(profile, "password")
+
+	posts := []map[string]interface{}{}
+	for page := 1; ; page++ {
+		batch, err := db.GetUserVisiblePosts(int64(userID), int64(userID), page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, batch...)
+		if len(batch) < exportPageSize {
+			break
+		}
+	}
+
+	scheduledPosts, err := db.GetScheduledPosts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled posts: %v", err)
+	}
+
+	comments, err := db.GetUserComments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*sqlite.Group{}
+	for offset := 0; ; offset += exportPageSize {
+		batch, err := db.GetUserGroups(int64(userID), exportPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get groups: %v", err)
+		}
+		groups = append(groups, batch...)
+		if len(batch) < exportPageSize {
+			break
+		}
+	}
+
+	followers, err := db.GetUserFollowers(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %v", err)
+	}
+
+	following, err := db.GetUserFollowing(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %v", err)
+	}
+
+	messages, err := db.GetSentMessages(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	groupMessages, err := db.GetSentGroupMessages(int64(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"exported_at":     time.Now().UTC(),
+		"profile":         profile,
+		"posts":           posts,
+		"scheduled_posts": scheduledPosts,
+		"comments":        comments,
+		"groups":          groups,
+		"followers":       followers,
+		"following":       following,
+		"messages":        messages,
+		"group_messages":  groupMessages,
+	}, nil
+}
+
+// GetCurrentUser returns the currently logged-in user's information
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	// Get session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Check if user is authenticated
+	auth, ok := session.Values["authenticated"].(bool)
+	if !ok || !auth {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user ID from session
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Invalid session data", http.StatusInternalServerError)
+		return
+	}
+
+	// Get user data from database
+	user, err := db.GetUserById(userID)
+	if err != nil {
+		http.Error(w, "Failed to get user data", http.StatusInternalServerError)
+		return
+	}
+
+	addProfileCounts(user, userID)
+
 	// Return user data
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
@@ -24,6 +24,9 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// typingDebounce is the minimum interval between repeated typing events from a client
+const typingDebounce = 2 * time.Second
+
 // Client represents a connected WebSocket client
 type Client struct {
 	ID             int64
@@ -32,6 +35,11 @@ type Client struct {
 	Send           chan []byte
 	ConversationID int64
 	IsGroup        bool
+
+	// Last typing state sent by this client, used for debouncing
+	lastTypingAt           time.Time
+	lastTypingState        bool
+	lastTypingConversation int64
 }
 
 // ChatHub maintains the set of active clients and broadcasts messages
@@ -69,6 +77,7 @@ type ChatMessage struct {
 	Content        string          `json:"content"`
 	Timestamp      string          `json:"timestamp"`
 	IsGroup        bool            `json:"is_group"`
+	IsTyping       bool            `json:"is_typing,omitempty"`
 	Payload        json.RawMessage `json:"payload,omitempty"`
 }
 
@@ -123,7 +132,7 @@ func (h *ChatHub) Run() {
 
 		case message := <-h.broadcast:
 			// Store the message in database
-			messageID, err := h.storeMessage(message)
+			messageID, isDirect, err := h.storeMessage(message)
 			if err != nil {
 				log.Printf("Error storing message: %v", err)
 				continue
@@ -149,6 +158,28 @@ func (h *ChatHub) Run() {
 				"is_group":        message.IsGroup,
 			})
 
+			participants, err := h.db.GetConversationParticipants(message.ConversationID)
+			if err != nil {
+				log.Printf("Error getting participants for conversation %d: %v", message.ConversationID, err)
+			}
+
+			// Recipients of direct messages whose connection received the message are marked as delivered.
+			// Participants who muted the conversation don't get the global unread notification
+			recipients := make(map[int64]bool)
+			muted := make(map[int64]bool)
+			for _, participant := range participants {
+				if participant.UserID == message.SenderID {
+					continue
+				}
+				if isDirect {
+					recipients[participant.UserID] = true
+				}
+				if participant.Muted {
+					muted[participant.UserID] = true
+				}
+			}
+			delivered := make(map[int64]bool)
+
 			// Send to all clients in the conversation
 			h.mutex.Lock()
 			clients := h.conversations[message.ConversationID]
@@ -159,6 +190,9 @@ func (h *ChatHub) Run() {
 				select {
 				case client.Send <- messageData:
 					sentCount++
+					if recipients[client.UserID] {
+						delivered[client.UserID] = true
+					}
 				default:
 					log.Printf("Failed to send message to client %d, removing", client.UserID)
 					close(client.Send)
@@ -170,10 +204,13 @@ func (h *ChatHub) Run() {
 
 			// Also send to globally registered users (but not the sender)
 			for client := range h.clients {
-				if client.ConversationID == 0 && client.UserID != message.SenderID {
+				if client.ConversationID == 0 && client.UserID != message.SenderID && !muted[client.UserID] {
 					select {
 					case client.Send <- messageData:
 						sentCount++
+						if recipients[client.UserID] {
+							delivered[client.UserID] = true
+						}
 						log.Printf("Sent global notification to user %d", client.UserID)
 					default:
 						log.Printf("Failed to send global notification to client %d, removing", client.UserID)
@@ -187,9 +224,15 @@ func (h *ChatHub) Run() {
 			log.Printf("Successfully sent message to %d clients (conversation + global)", sentCount)
 			h.mutex.Unlock()
 
-			// Create notifications for offline users if not a group chat
-			if !message.IsGroup {
-				h.createMessageNotifications(message)
+			for userID := range delivered {
+				if err := h.db.UpsertMessageStatus(messageID, userID, sqlite.MessageStatusDelivered); err != nil {
+					log.Printf("Error marking message %d delivered to user %d: %v", messageID, userID, err)
+				}
+			}
+
+			// Let direct message recipients who aren't viewing the conversation know about it
+			if isDirect {
+				h.notifyDirectMessageRecipients(message, messageID)
 			}
 		}
 	}
@@ -221,12 +264,12 @@ func (h *ChatHub) removeClientFromUser(client *Client) {
 	}
 }
 
-// storeMessage stores a message in the database
-func (h *ChatHub) storeMessage(message *ChatMessage) (int64, error) {
+// storeMessage stores a message in the database and reports whether it was saved as a direct message
+func (h *ChatHub) storeMessage(message *ChatMessage) (int64, bool, error) {
 	// Get conversation info to determine if it's a group
 	conversation, err := h.db.GetConversation(message.ConversationID)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
 	if conversation != nil && conversation.IsGroup && conversation.GroupID != nil {
@@ -237,7 +280,8 @@ func (h *ChatHub) storeMessage(message *ChatMessage) (int64, error) {
 			Content:   message.Content,
 			IsDeleted: false,
 		}
-		return h.db.CreateGroupMessage(groupMessage)
+		messageID, err := h.db.CreateGroupMessage(groupMessage)
+		return messageID, false, err
 	} else {
 		// Save as direct message
 		chatMessage := &sqlite.ChatMessage{
@@ -245,12 +289,27 @@ func (h *ChatHub) storeMessage(message *ChatMessage) (int64, error) {
 			SenderID:       message.SenderID,
 			Content:        message.Content,
 		}
-		return h.db.CreateMessage(chatMessage)
+		messageID, err := h.db.CreateMessage(chatMessage)
+		return messageID, true, err
+	}
+}
+
+// messagePreviewLength is the number of characters of a message shown in notifications
+const messagePreviewLength = 100
+
+// messagePreview shortens message content for notifications
+func messagePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= messagePreviewLength {
+		return content
 	}
+	return string(runes[:messagePreviewLength]) + "..."
 }
 
-// createMessageNotifications creates notifications for offline users
-func (h *ChatHub) createMessageNotifications(message *ChatMessage) {
+// notifyDirectMessageRecipients tells the recipients of a direct message who aren't viewing the
+// conversation about it. Connected recipients get a new_message_notification event with a preview,
+// offline recipients get a persistent message notification. Participants who muted the conversation are skipped
+func (h *ChatHub) notifyDirectMessageRecipients(message *ChatMessage, messageID int64) {
 	// Get conversation participants
 	participants, err := h.db.GetConversationParticipants(message.ConversationID)
 	if err != nil {
@@ -267,27 +326,55 @@ func (h *ChatHub) createMessageNotifications(message *ChatMessage) {
 
 	senderName := fmt.Sprintf("%s %s", sender["first_name"], sender["last_name"])
 
+	notificationData, err := json.Marshal(map[string]interface{}{
+		"type":            "new_message_notification",
+		"conversation_id": message.ConversationID,
+		"message_id":      messageID,
+		"sender_id":       message.SenderID,
+		"sender_name":     senderName,
+		"sender_avatar":   sender["avatar"],
+		"preview":         messagePreview(message.Content),
+		"timestamp":       message.Timestamp,
+	})
+	if err != nil {
+		log.Printf("Error marshaling message notification: %v", err)
+		return
+	}
+
 	for _, participant := range participants {
-		// Skip the sender
-		if participant.UserID == message.SenderID {
+		// Skip the sender and participants who muted the conversation
+		if participant.UserID == message.SenderID || participant.Muted {
 			continue
 		}
 
-		// Check if user is online in this conversation
-		userIsOnline := false
+		// Check whether the user is connected and whether any connection is viewing this conversation
 		h.mutex.Lock()
 		userClients := h.users[participant.UserID]
+		connected := len(userClients) > 0
+		focused := false
 		for _, client := range userClients {
 			if client.ConversationID == message.ConversationID {
-				userIsOnline = true
+				focused = true
 				break
 			}
 		}
+
+		if connected && !focused {
+			for _, client := range userClients {
+				select {
+				case client.Send <- notificationData:
+				default:
+					// Notifications are best-effort, drop if the client is backed up
+				}
+			}
+		}
 		h.mutex.Unlock()
 
-		// Create notification for offline users
-		if !userIsOnline {
-			h.db.CreateMessageNotification(participant.UserID, message.SenderID, message.ConversationID, senderName)
+		// Create a persistent notification for offline users
+		if !connected {
+			if _, err := h.db.CreateMessageNotification(participant.UserID, message.SenderID, message.ConversationID, senderName); err != nil {
+				log.Printf("Error creating message notification for user %d: %v", participant.UserID, err)
+			}
 		}
 	}
 }
@@ -333,6 +420,59 @@ func (h *ChatHub) SendNotificationToUser(userID int64, notification map[string]i
 	log.Printf("Sent notification to %d clients for user %d", sentCount, userID)
 }
 
+// BroadcastToParticipants sends an event to every connection of the conversation's
+// participants except the given user. Only clients viewing this conversation or
+// registered globally receive it
+func (h *ChatHub) BroadcastToParticipants(conversationID, excludeUserID int64, event map[string]interface{}) {
+	participants, err := h.db.GetConversationParticipants(conversationID)
+	if err != nil {
+		log.Printf("Error getting participants for conversation %d: %v", conversationID, err)
+		return
+	}
+
+	isParticipant := make(map[int64]bool)
+	for _, participant := range participants {
+		isParticipant[participant.UserID] = true
+	}
+
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error marshaling conversation event: %v", err)
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for client := range h.clients {
+		// Skip the excluded user's own connections and anyone outside the conversation
+		if client.UserID == excludeUserID || !isParticipant[client.UserID] {
+			continue
+		}
+
+		if client.ConversationID != conversationID && client.ConversationID != 0 {
+			continue
+		}
+
+		select {
+		case client.Send <- eventData:
+		default:
+			// Events are best-effort, drop if the client is backed up
+		}
+	}
+}
+
+// BroadcastTyping sends a typing event to the other participants of a conversation.
+// Typing state is never stored
+func (h *ChatHub) BroadcastTyping(conversationID, userID int64, isTyping bool) {
+	h.BroadcastToParticipants(conversationID, userID, map[string]interface{}{
+		"type":            "typing",
+		"conversation_id": conversationID,
+		"user_id":         userID,
+		"is_typing":       isTyping,
+	})
+}
+
 // ServeWs handles websocket requests from the peer.
 func ServeWs(hub *ChatHub, w http.ResponseWriter, r *http.Request) {
 	// First check session authentication
@@ -444,7 +584,9 @@ func (c *Client) readPump(hub *ChatHub) {
 			}
 
 			// Set conversation ID to 0 to indicate global registration
+			hub.mutex.Lock()
 			c.ConversationID = 0
+			hub.mutex.Unlock()
 
 			// Send registration confirmation
 			response := map[string]interface{}{
@@ -475,11 +617,9 @@ func (c *Client) readPump(hub *ChatHub) {
 					hub.mutex.Unlock()
 				}
 
-				// Set new conversation
-				c.ConversationID = chatMessage.ConversationID
-
-				// Add to new conversation
+				// Set new conversation and add to it
 				hub.mutex.Lock()
+				c.ConversationID = chatMessage.ConversationID
 				hub.conversations[c.ConversationID] = append(hub.conversations[c.ConversationID], c)
 				clientCount := len(hub.conversations[c.ConversationID])
 				log.Printf("Added user %d to conversation %d (total clients: %d)", c.UserID, c.ConversationID, clientCount)
@@ -530,9 +670,51 @@ func (c *Client) readPump(hub *ChatHub) {
 			// Set group flag based on conversation type
 			chatMessage.IsGroup = conversation != nil && conversation.IsGroup
 
+			if conversation != nil {
+				canSend, err := canSendToConversation(c.UserID, conversation)
+				if err != nil {
+					log.Printf("Error checking direct message policy for user %d in conversation %d: %v", c.UserID, chatMessage.ConversationID, err)
+					continue
+				}
+				if !canSend {
+					log.Printf("Direct message policy denied user %d in conversation %d", c.UserID, chatMessage.ConversationID)
+					continue
+				}
+			}
+
 			// Send to hub for broadcasting
 			log.Printf("Sending message to hub for broadcasting: user %d, conversation %d, isGroup: %t", c.UserID, chatMessage.ConversationID, chatMessage.IsGroup)
 			hub.broadcast <- &chatMessage
+
+		case "typing":
+			// Use conversation ID from client if not provided in message
+			if chatMessage.ConversationID == 0 {
+				chatMessage.ConversationID = c.ConversationID
+			}
+
+			if chatMessage.ConversationID == 0 {
+				continue
+			}
+
+			// Debounce: ignore repeats of the same state within the debounce window
+			if chatMessage.ConversationID == c.lastTypingConversation &&
+				chatMessage.IsTyping == c.lastTypingState &&
+				time.Since(c.lastTypingAt) < typingDebounce {
+				continue
+			}
+
+			// Verify access to conversation
+			hasAccess, err := canAccessConversation(c.UserID, chatMessage.ConversationID)
+			if err != nil || !hasAccess {
+				log.Printf("Access denied to conversation %d for user %d", chatMessage.ConversationID, c.UserID)
+				continue
+			}
+
+			c.lastTypingAt = time.Now()
+			c.lastTypingState = chatMessage.IsTyping
+			c.lastTypingConversation = chatMessage.ConversationID
+
+			hub.BroadcastTyping(chatMessage.ConversationID, c.UserID, chatMessage.IsTyping)
 		}
 	}
 }
@@ -610,6 +792,37 @@ func SendFollowNotification(userID int64, senderID int64, notificationType strin
 	chatHub.SendNotificationToUser(userID, notification)
 }
 
+// SendGroupInvitation pushes a group_invitation event to the invitee if they're connected,
+// so they see the invitation without refetching
+func SendGroupInvitation(inviteeID, inviterID, invitationID, groupID int64, groupName, inviterName string) {
+	if chatHub == nil {
+		log.Printf("Chat hub not initialized, cannot send group invitation")
+		return
+	}
+
+	var inviterAvatar interface{}
+	if inviter, err := db.GetUserById(int(inviterID)); err == nil && inviter != nil {
+		inviterAvatar = inviter["avatar"]
+	}
+
+	// Same shape as SendGroupNotification plus the invitation details
+	notification := map[string]interface{}{
+		"type":          "group_invitation",
+		"sender_id":     inviterID,
+		"sender_name":   inviterName,
+		"sender_avatar": inviterAvatar,
+		"content":       inviterName + " invited you to join " + groupName,
+		"reference_id":  groupID,
+		"invitation_id": invitationID,
+		"group_id":      groupID,
+		"group_name":    groupName,
+		"inviter_name":  inviterName,
+		"created_at":    time.Now().Format(time.RFC3339),
+	}
+
+	chatHub.SendNotificationToUser(inviteeID, notification)
+}
+
 // SendGroupNotification sends a group notification via WebSocket
 func SendGroupNotification(userID int64, senderID int64, notificationType string, content string, referenceID int64) {
 	if chatHub == nil {
@@ -3,19 +3,49 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"s-network/backend/pkg/db/sqlite"
+	"s-network/backend/pkg/utils"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
 // Global chat hub
 var chatHub *ChatHub
 
+// messageEditWindow is how long after sending a message its author may still edit it
+const messageEditWindow = 24 * time.Hour
+
+// maxChatAttachmentSize is the largest file that can be attached to a message
+const maxChatAttachmentSize = 10 << 20 // 10 MB
+
+// chatAttachmentExtensions lists the non-image file types that can be attached to messages.
+// Types a browser would render as a page or script, such as .html and .svg, are left out.
+var chatAttachmentExtensions = map[string]bool{
+	".pdf":  true,
+	".txt":  true,
+	".csv":  true,
+	".doc":  true,
+	".docx": true,
+	".xls":  true,
+	".xlsx": true,
+	".ppt":  true,
+	".pptx": true,
+	".zip":  true,
+	".mp3":  true,
+	".mp4":  true,
+}
+
 // Use the sqlite ChatConversation type directly to avoid redefining it
 type ChatConversation = sqlite.ChatConversation
 
@@ -109,6 +139,11 @@ func GetConversations(w http.ResponseWriter, r *http.Request) {
 			if err != nil {
 				log.Printf("❌ Error getting messages for conversation %d: %v", conv.ID, err)
 			} else if len(messages) > 0 {
+				content := messages[0].Content
+				if messages[0].IsDeleted {
+					content = ""
+				}
+
 				// Get sender info
 				sender, err := db.GetUserById(int(messages[0].SenderID))
 				if err != nil {
@@ -116,7 +151,7 @@ func GetConversations(w http.ResponseWriter, r *http.Request) {
 				} else {
 					lastMessage = map[string]interface{}{
 						"id":        messages[0].ID,
-						"content":   messages[0].Content,
+						"content":   content,
 						"timestamp": messages[0].CreatedAt,
 						"sender": map[string]interface{}{
 							"id":         messages[0].SenderID,
@@ -214,6 +249,14 @@ func GetConversations(w http.ResponseWriter, r *http.Request) {
 
 		}
 
+		muted := false
+		for _, p := range participants {
+			if p.UserID == int64(userID) {
+				muted = p.Muted
+				break
+			}
+		}
+
 		// Build conversation data
 		conversationData := map[string]interface{}{
 			"id":           conv.ID,
@@ -223,6 +266,7 @@ func GetConversations(w http.ResponseWriter, r *http.Request) {
 			"group_id":     conv.GroupID,
 			"last_message": lastMessage,
 			"unread_count": unreadCount,
+			"muted":        muted,
 			"participants": participantDetails,
 			"updated_at":   conv.UpdatedAt,
 			"created_at":   conv.CreatedAt,
@@ -367,7 +411,26 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("🔍 GetMessages: Pagination - limit: %d, offset: %d", limit, offset)
+	// ?before= pages back through history from a message ID or RFC3339 timestamp.
+	// Offset is ignored when it is given
+	var cursor *sqlite.MessageCursor
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		if beforeID, err := strconv.ParseInt(beforeStr, 10, 64); err == nil && beforeID > 0 {
+			cursor = &sqlite.MessageCursor{BeforeID: beforeID}
+		} else if before, err := time.Parse(time.RFC3339, beforeStr); err == nil {
+			cursor = &sqlite.MessageCursor{Before: before}
+		} else {
+			http.Error(w, "before must be a message ID or an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
+	log.Printf("🔍 GetMessages: Pagination - limit: %d, offset: %d, cursor: %v", limit, offset, cursor)
+
+	// The oldest message fetched, used as the cursor for the next page
+	var oldestID int64
+	var oldestAt time.Time
+	fetched := 0
 
 	// Process messages based on conversation type
 	result := make([]map[string]interface{}, 0)
@@ -375,7 +438,12 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 	if conversation.IsGroup && conversation.GroupID != nil {
 		log.Printf("🔍 GetMessages: Processing GROUP messages for group %d", *conversation.GroupID)
 		// Handle group messages
-		groupMessages, err := db.GetGroupMessages(*conversation.GroupID, limit, offset)
+		var groupMessages []*sqlite.GroupMessage
+		if cursor != nil {
+			groupMessages, err = db.GetGroupMessagesBefore(*conversation.GroupID, *cursor, limit)
+		} else {
+			groupMessages, err = db.GetGroupMessages(*conversation.GroupID, limit, offset)
+		}
 		if err != nil {
 			log.Printf("❌ GetMessages: Error fetching group messages - %v", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -384,6 +452,15 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("🔍 GetMessages: Found %d group messages", len(groupMessages))
 
+		fetched = len(groupMessages)
+		if fetched > 0 {
+			oldest := groupMessages[0]
+			if cursor != nil {
+				oldest = groupMessages[fetched-1]
+			}
+			oldestID, oldestAt = oldest.ID, oldest.CreatedAt
+		}
+
 		for _, msg := range groupMessages {
 			// Get sender info
 			sender, err := db.GetUserById(int(msg.SenderID))
@@ -392,14 +469,22 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
+			// Deleted messages are kept as tombstones so replies still have something to point at
+			content := msg.Content
+			if msg.IsDeleted {
+				content = ""
+			}
+
 			// Format message
 			messageData := map[string]interface{}{
 				"id":              msg.ID,
 				"conversation_id": conversationID,
-				"content":         msg.Content,
+				"content":         content,
 				"is_deleted":      msg.IsDeleted,
+				"is_announcement": msg.IsAnnouncement,
 				"created_at":      msg.CreatedAt,
 				"timestamp":       msg.CreatedAt,
+				"edited_at":       msg.EditedAt,
 				"sender": map[string]interface{}{
 					"id":         msg.SenderID,
 					"first_name": sender["first_name"],
@@ -409,7 +494,7 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Add attachments if any
-			if len(msg.Attachments) > 0 {
+			if len(msg.Attachments) > 0 && !msg.IsDeleted {
 				attachments := make([]map[string]interface{}, 0)
 				for _, att := range msg.Attachments {
 					attachments = append(attachments, map[string]interface{}{
@@ -428,7 +513,12 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 	} else {
 		log.Printf("🔍 GetMessages: Processing DIRECT messages for conversation %d", conversationID)
 		// Handle direct messages
-		messages, err := db.GetConversationMessages(conversationID, limit, offset)
+		var messages []*sqlite.ChatMessage
+		if cursor != nil {
+			messages, err = db.GetConversationMessagesBefore(conversationID, *cursor, limit)
+		} else {
+			messages, err = db.GetConversationMessages(conversationID, limit, offset)
+		}
 		if err != nil {
 			log.Printf("❌ GetMessages: Error fetching direct messages - %v", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -437,6 +527,24 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("🔍 GetMessages: Found %d direct messages", len(messages))
 
+		fetched = len(messages)
+		if fetched > 0 {
+			oldest := messages[0]
+			if cursor != nil {
+				oldest = messages[fetched-1]
+			}
+			oldestID, oldestAt = oldest.ID, oldest.CreatedAt
+		}
+
+		// Messages fetched here reached the user even if they weren't online when they were sent
+		messageIDs := make([]int64, 0, len(messages))
+		for _, msg := range messages {
+			messageIDs = append(messageIDs, msg.ID)
+		}
+		if err := db.MarkMessagesDelivered(int64(userID), messageIDs); err != nil {
+			log.Printf("❌ GetMessages: Error marking messages delivered - %v", err)
+		}
+
 		for _, msg := range messages {
 			// Get sender info
 			sender, err := db.GetUserById(int(msg.SenderID))
@@ -451,14 +559,29 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 			}
 			log.Printf("🔍 GetMessages: Processing message %d from user %d: %s", msg.ID, msg.SenderID, contentPreview)
 
+			// Deleted messages are kept as tombstones so replies still have something to point at
+			content := msg.Content
+			if msg.IsDeleted {
+				content = ""
+			}
+
+			// Aggregate delivery state across the message's recipients
+			msg.Status, err = db.GetMessageStatus(msg.ID)
+			if err != nil {
+				log.Printf("Error getting status of message %d: %v", msg.ID, err)
+				msg.Status = sqlite.MessageStatusSent
+			}
+
 			// Format message
 			messageData := map[string]interface{}{
 				"id":              msg.ID,
 				"conversation_id": msg.ConversationID,
-				"content":         msg.Content,
+				"content":         content,
 				"is_deleted":      msg.IsDeleted,
 				"created_at":      msg.CreatedAt,
 				"timestamp":       msg.CreatedAt,
+				"edited_at":       msg.EditedAt,
+				"status":          msg.Status,
 				"sender": map[string]interface{}{
 					"id":         msg.SenderID,
 					"first_name": sender["first_name"],
@@ -468,7 +591,7 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Add attachments if any
-			if len(msg.Attachments) > 0 {
+			if len(msg.Attachments) > 0 && !msg.IsDeleted {
 				attachments := make([]map[string]interface{}, 0)
 				for _, att := range msg.Attachments {
 					attachments = append(attachments, map[string]interface{}{
@@ -485,8 +608,8 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 			result = append(result, messageData)
 		}
 
-		// Update last read message for direct conversations
-		if len(result) > 0 {
+		// Update last read message for direct conversations. Older pages don't move the read marker
+		if len(result) > 0 && cursor == nil {
 			lastMsgID := result[len(result)-1]["id"].(int64)
 			err := db.UpdateLastReadMessage(conversationID, int64(userID), lastMsgID)
 			if err != nil {
@@ -498,11 +621,410 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 	log.Printf("✅ GetMessages: Returning %d messages for conversation %d", len(result), conversationID)
 
 	w.Header().Set("Content-Type", "application/json")
+	// next_before is nil once the start of the history has been reached. Offset pages are
+	// sorted oldest first, so only pages past the first one have older messages
+	hasOlder := fetched > 0 && offset > 0
+	if cursor != nil {
+		hasOlder = fetched == limit
+	}
+
+	var nextBefore, nextBeforeID interface{}
+	if hasOlder {
+		nextBefore = oldestAt.UTC().Format(time.RFC3339)
+		nextBeforeID = oldestID
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"messages": result,
-		"count":    len(result),
-		"offset":   offset,
-		"limit":    limit,
+		"messages":       result,
+		"count":          len(result),
+		"offset":         offset,
+		"limit":          limit,
+		"next_before":    nextBefore,
+		"next_before_id": nextBeforeID,
+	})
+}
+
+// GetUnreadSummary returns the user's total unread message count and a
+// per-conversation breakdown for the chat badge
+func GetUnreadSummary(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conversations, total, err := db.GetUnreadMessageSummary(int64(userID))
+	if err != nil {
+		log.Printf("Error getting unread summary: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_unread":  total,
+		"conversations": conversations,
+	})
+}
+
+// MuteConversation lets a participant mute or unmute a conversation.
+// Messages still arrive but no longer count towards the unread badge or trigger notifications
+func MuteConversation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	conversationIDStr := vars["id"]
+	conversationID, err := strconv.ParseInt(conversationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestData struct {
+		Muted *bool `json:"muted"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.Muted == nil {
+		http.Error(w, "Muted is required", http.StatusBadRequest)
+		return
+	}
+
+	err = db.SetConversationMuted(conversationID, int64(userID), *requestData.Muted)
+	if err != nil {
+		if err.Error() == "participant not found" {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+		log.Printf("Error updating conversation mute setting: %v", err)
+		http.Error(w, "Failed to update mute setting", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"conversation_id": conversationID,
+		"muted":           *requestData.Muted,
+	})
+}
+
+// MarkConversationRead records the latest message the user has seen in a conversation
+func MarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	conversationIDStr := vars["id"]
+	conversationID, err := strconv.ParseInt(conversationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid conversation ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user has access to this conversation
+	hasAccess, err := canAccessConversation(int64(userID), conversationID)
+	if err != nil || !hasAccess {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	// Message ID is optional, default to the latest message in the conversation
+	var requestData struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	messageID := requestData.MessageID
+	if messageID > 0 {
+		message, err := db.GetMessage(messageID)
+		if err != nil || message == nil || message.ConversationID != conversationID {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		messageID, err = db.GetLatestMessageID(conversationID)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if messageID > 0 {
+		err = db.UpdateLastReadMessage(conversationID, int64(userID), messageID)
+		if err != nil {
+			log.Printf("Error updating last read message: %v", err)
+			http.Error(w, "Failed to mark conversation as read", http.StatusInternalServerError)
+			return
+		}
+
+		if err := db.MarkMessagesRead(conversationID, int64(userID), messageID); err != nil {
+			log.Printf("Error updating message statuses: %v", err)
+		}
+
+		// Let the other participants know the messages were seen
+		if chatHub != nil {
+			go chatHub.BroadcastToParticipants(conversationID, int64(userID), map[string]interface{}{
+				"type":                 "read_receipt",
+				"conversation_id":      conversationID,
+				"user_id":              userID,
+				"last_read_message_id": messageID,
+				"timestamp":            time.Now().Format(time.RFC3339),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":              "Conversation marked as read",
+		"conversation_id":      conversationID,
+		"last_read_message_id": messageID,
+	})
+}
+
+// EditMessage updates the content of a message sent by the current user.
+// Group chat messages are addressed with ?is_group=true
+func EditMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	messageIDStr := vars["id"]
+	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestData struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(requestData.Content) == "" {
+		http.Error(w, "Message content is required", http.StatusBadRequest)
+		return
+	}
+
+	isGroup := r.URL.Query().Get("is_group") == "true"
+
+	var conversationID int64
+	var senderID int64
+	var createdAt time.Time
+	var isDeleted bool
+
+	if isGroup {
+		message, err := db.GetGroupMessage(messageID)
+		if err != nil || message == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+
+		if !db.IsGroupMember(message.GroupID, int64(userID)) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		conversation, err := db.GetGroupConversation(message.GroupID)
+		if err != nil || conversation == nil {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		conversationID = conversation.ID
+		senderID = message.SenderID
+		createdAt = message.CreatedAt
+		isDeleted = message.IsDeleted
+	} else {
+		message, err := db.GetMessage(messageID)
+		if err != nil || message == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+
+		hasAccess, err := canAccessConversation(int64(userID), message.ConversationID)
+		if err != nil || !hasAccess {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		conversationID = message.ConversationID
+		senderID = message.SenderID
+		createdAt = message.CreatedAt
+		isDeleted = message.IsDeleted
+	}
+
+	if senderID != int64(userID) {
+		http.Error(w, "You can only edit your own messages", http.StatusForbidden)
+		return
+	}
+
+	if isDeleted {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+
+	if time.Since(createdAt) > messageEditWindow {
+		http.Error(w, "Messages can only be edited within 24 hours of being sent", http.StatusForbidden)
+		return
+	}
+
+	if isGroup {
+		err = db.EditGroupMessage(messageID, int64(userID), requestData.Content)
+	} else {
+		err = db.EditChatMessage(messageID, int64(userID), requestData.Content)
+	}
+	if err != nil {
+		log.Printf("Error editing message %d: %v", messageID, err)
+		http.Error(w, "Failed to edit message", http.StatusInternalServerError)
+		return
+	}
+
+	editedAt := time.Now()
+
+	// Push the new content to everyone in the conversation, including the author's other tabs
+	if chatHub != nil {
+		go chatHub.BroadcastToParticipants(conversationID, 0, map[string]interface{}{
+			"type":            "message_edited",
+			"conversation_id": conversationID,
+			"message_id":      messageID,
+			"is_group":        isGroup,
+			"content":         requestData.Content,
+			"edited_at":       editedAt.Format(time.RFC3339),
+			"timestamp":       editedAt.Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":         "Message updated successfully",
+		"id":              messageID,
+		"conversation_id": conversationID,
+		"content":         requestData.Content,
+		"edited_at":       editedAt,
+	})
+}
+
+// DeleteChatMessage soft-deletes a message sent by the current user.
+// Group chat messages are addressed with ?is_group=true
+func DeleteChatMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	messageIDStr := vars["id"]
+	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	isGroup := r.URL.Query().Get("is_group") == "true"
+
+	var conversationID int64
+	var senderID int64
+	var isDeleted bool
+
+	if isGroup {
+		message, err := db.GetGroupMessage(messageID)
+		if err != nil || message == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+
+		if !db.IsGroupMember(message.GroupID, int64(userID)) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		conversation, err := db.GetGroupConversation(message.GroupID)
+		if err != nil || conversation == nil {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+
+		conversationID = conversation.ID
+		senderID = message.SenderID
+		isDeleted = message.IsDeleted
+	} else {
+		message, err := db.GetMessage(messageID)
+		if err != nil || message == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+
+		hasAccess, err := canAccessConversation(int64(userID), message.ConversationID)
+		if err != nil || !hasAccess {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		conversationID = message.ConversationID
+		senderID = message.SenderID
+		isDeleted = message.IsDeleted
+	}
+
+	if senderID != int64(userID) {
+		http.Error(w, "You can only delete your own messages", http.StatusForbidden)
+		return
+	}
+
+	if isDeleted {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+
+	if isGroup {
+		err = db.MarkGroupMessageAsDeleted(messageID)
+	} else {
+		err = db.MarkMessageAsDeleted(messageID)
+	}
+	if err != nil {
+		log.Printf("Error deleting message %d: %v", messageID, err)
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+
+	// Let participants replace the message with a tombstone
+	if chatHub != nil {
+		go chatHub.BroadcastToParticipants(conversationID, 0, map[string]interface{}{
+			"type":            "message_deleted",
+			"conversation_id": conversationID,
+			"message_id":      messageID,
+			"is_group":        isGroup,
+			"timestamp":       time.Now().Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":         "Message deleted successfully",
+		"id":              messageID,
+		"conversation_id": conversationID,
 	})
 }
 
@@ -542,8 +1064,13 @@ func CreateConversation(w http.ResponseWriter, r *http.Request) {
 	if !requestData.IsGroup {
 		otherUserID := requestData.Participants[0]
 		canMessage, err := canMessageUser(int64(userID), otherUserID)
-		if err != nil || !canMessage {
-			http.Error(w, "You cannot message this user", http.StatusForbidden)
+		if err != nil {
+			log.Printf("Error checking direct message policy: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !canMessage {
+			http.Error(w, directMessageNotAllowedMessage(), http.StatusForbidden)
 			return
 		}
 
@@ -690,37 +1217,108 @@ func CreateConversation(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// canMessageUser checks if a user can message another user
-func canMessageUser(senderID, recipientID int64) (bool, error) {
-	// Get recipient's profile
-	recipient, err := db.GetUserById(int(recipientID))
+// CreateDirectConversation opens the direct conversation between the current user and
+// another user, creating it if it doesn't exist yet
+func CreateDirectConversation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
 	if err != nil {
-		return false, err
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// If recipient has a public profile, anyone can message them
-	isPublic, ok := recipient["is_public"].(bool)
-	if ok && isPublic {
-		return true, nil
+	var requestData struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.UserID <= 0 {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.UserID == int64(userID) {
+		http.Error(w, "You cannot message yourself", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := db.GetUserById(int(requestData.UserID)); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	canMessage, err := canMessageUser(int64(userID), requestData.UserID)
+	if err != nil {
+		log.Printf("Error checking direct message policy: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !canMessage {
+		http.Error(w, directMessageNotAllowedMessage(), http.StatusForbidden)
+		return
 	}
 
-	// Check if sender follows recipient
-	isFollowing, err := db.IsFollowing(int(senderID), int(recipientID))
+	conversationID, err := db.GetOrCreateDirectConversation(int64(userID), requestData.UserID)
 	if err != nil {
+		log.Printf("Error getting direct conversation: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"conversation_id": conversationID,
+	})
+}
+
+// canMessageUser checks if a user can message another user. Blocking in either
+// direction prevents messaging, otherwise the follow relationship must satisfy
+// the direct message policy
+func canMessageUser(senderID, recipientID int64) (bool, error) {
+	if blocked, err := db.IsBlocked(recipientID, senderID); err != nil || blocked {
 		return false, err
 	}
+	if blocked, err := db.IsBlocked(senderID, recipientID); err != nil || blocked {
+		return false, err
+	}
+
+	return db.CanDirectMessage(senderID, recipientID)
+}
 
-	if isFollowing {
+// directMessageNotAllowedMessage explains the direct message policy to a user who was rejected by it
+func directMessageNotAllowedMessage() string {
+	if sqlite.DirectMessagePolicy == sqlite.DirectMessagePolicyMutual {
+		return "You can only message users who follow you and whom you follow"
+	}
+	return "You can only message users you follow or who follow you"
+}
+
+// canSendToConversation applies the direct message policy to an existing conversation.
+// Group conversations are always allowed
+func canSendToConversation(senderID int64, conversation *ChatConversation) (bool, error) {
+	if conversation.IsGroup {
 		return true, nil
 	}
 
-	// Check if recipient follows sender
-	isFollowedBy, err := db.IsFollowing(int(recipientID), int(senderID))
+	participants, err := db.GetConversationParticipants(conversation.ID)
 	if err != nil {
 		return false, err
 	}
 
-	return isFollowedBy, nil
+	for _, participant := range participants {
+		if participant.UserID == senderID {
+			continue
+		}
+		canMessage, err := canMessageUser(senderID, participant.UserID)
+		if err != nil || !canMessage {
+			return false, err
+		}
+	}
+
+	return true, nil
 }
 
 // WebSocketHandler handles WebSocket connections
@@ -736,14 +1334,83 @@ func RegisterChatRoutes(router *mux.Router) {
 	// API routes (these will have authentication middleware applied)
 	router.HandleFunc("/conversations", GetConversations).Methods("GET", "OPTIONS")
 	router.HandleFunc("/conversations", CreateConversation).Methods("POST", "OPTIONS")
+	router.HandleFunc("/conversations/direct", CreateDirectConversation).Methods("POST", "OPTIONS")
+	router.HandleFunc("/conversations/unread-summary", GetUnreadSummary).Methods("GET", "OPTIONS")
 	router.HandleFunc("/conversations/{id}", GetConversation).Methods("GET", "OPTIONS")
 	router.HandleFunc("/conversations/{id}/messages", GetMessages).Methods("GET", "OPTIONS")
 	// Add POST handler for sending messages
 	router.HandleFunc("/conversations/{id}/messages", SendMessage).Methods("POST", "OPTIONS")
+	router.HandleFunc("/conversations/{id}/read", MarkConversationRead).Methods("POST", "OPTIONS")
+	router.HandleFunc("/conversations/{id}/mute", MuteConversation).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/messages/search", SearchMessages).Methods("GET", "OPTIONS")
+	router.HandleFunc("/messages/{id}", EditMessage).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/messages/{id}", DeleteChatMessage).Methods("DELETE", "OPTIONS")
 	// Debug endpoint
 	router.HandleFunc("/conversations/{id}/debug", DebugConversation).Methods("GET", "OPTIONS")
 }
 
+// Number of message search results returned when no limit is given, and the most that can be requested
+const (
+	defaultMessageSearchLimit = 20
+	maxMessageSearchLimit     = 50
+)
+
+// SearchMessages finds messages containing a query across the user's conversations
+func SearchMessages(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := defaultMessageSearchLimit
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+	if limit > maxMessageSearchLimit {
+		limit = maxMessageSearchLimit
+	}
+
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	// The query only matches conversations the user participates in, the same rule
+	// canAccessConversation applies, so pages are never cut short by filtering afterwards
+	messages, err := db.SearchMessages(int64(userID), query, limit, offset)
+	if err != nil {
+		log.Printf("❌ SearchMessages: Failed to search messages for user %d - %v", userID, err)
+		http.Error(w, "Failed to search messages", http.StatusInternalServerError)
+		return
+	}
+	if messages == nil {
+		messages = []*sqlite.MessageSearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"messages": messages,
+		"query":    query,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
 // RegisterChatWebSocketRoutes registers WebSocket routes on the main router
 func RegisterChatWebSocketRoutes(router *mux.Router) {
 	// WebSocket endpoint (no authentication middleware needed, authentication handled in websocket handler)
@@ -788,21 +1455,58 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("🔍 SendMessage: Conversation %d details - IsGroup: %t, GroupID: %v", conversationID, conversation.IsGroup, conversation.GroupID)
 
-	// Parse request body
+	// Direct messages must still satisfy the follow policy, e.g. after an unfollow
+	canSend, err := canSendToConversation(int64(userID), conversation)
+	if err != nil {
+		log.Printf("❌ SendMessage: Error checking direct message policy for user %d in conversation %d: %v", userID, conversationID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !canSend {
+		log.Printf("❌ SendMessage: Direct message policy denied user %d in conversation %d", userID, conversationID)
+		http.Error(w, directMessageNotAllowedMessage(), http.StatusForbidden)
+		return
+	}
+
+	// Parse request body. Multipart requests may carry file attachments
 	var req struct {
 		Content string `json:"content"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var files []*multipart.FileHeader
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxChatAttachmentSize); err != nil {
+			log.Printf("❌ SendMessage: Unable to parse form - %v", err)
+			http.Error(w, "Unable to parse form", http.StatusBadRequest)
+			return
+		}
+		req.Content = r.FormValue("content")
+		files = r.MultipartForm.File["attachments"]
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("❌ SendMessage: Invalid request body - %v", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if req.Content == "" {
+	if req.Content == "" && len(files) == 0 {
 		log.Printf("❌ SendMessage: Empty message content")
 		http.Error(w, "Message content cannot be empty", http.StatusBadRequest)
 		return
 	}
 
+	// Save attachments before the message so an invalid file rejects the whole message
+	var savedFiles []*sqlite.ChatAttachment
+	for _, fileHeader := range files {
+		attachment, err := saveChatAttachment(fileHeader)
+		if err != nil {
+			log.Printf("❌ SendMessage: Invalid attachment %s - %v", fileHeader.Filename, err)
+			for _, saved := range savedFiles {
+				removeChatUpload(saved.FileURL)
+			}
+			http.Error(w, "Invalid attachment: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		savedFiles = append(savedFiles, attachment)
+	}
+
 	contentPreview := req.Content
 	if len(contentPreview) > 100 {
 		contentPreview = contentPreview[:100] + "..."
@@ -828,6 +1532,26 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("✅ SendMessage: Group message saved with ID %d", messageID)
+
+		for _, saved := range savedFiles {
+			_, err := db.AddGroupMessageAttachment(&sqlite.GroupMessageAttachment{
+				MessageID: messageID,
+				FileURL:   saved.FileURL,
+				FileType:  saved.FileType,
+				FileName:  saved.FileName,
+				FileSize:  saved.FileSize,
+			})
+			if err != nil {
+				log.Printf("❌ SendMessage: Failed to save attachment %s - %v", saved.FileName, err)
+				// Hide the message rather than deliver it without its attachments
+				db.MarkGroupMessageAsDeleted(messageID)
+				for _, saved := range savedFiles {
+					removeChatUpload(saved.FileURL)
+				}
+				http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
+				return
+			}
+		}
 	} else {
 		log.Printf("🔍 SendMessage: Saving as DIRECT message to conversation %d", conversationID)
 		// Save as direct message
@@ -845,17 +1569,149 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("✅ SendMessage: Direct message saved with ID %d", messageID)
+
+		for _, saved := range savedFiles {
+			saved.MessageID = messageID
+			if _, err := db.AddAttachment(saved); err != nil {
+				log.Printf("❌ SendMessage: Failed to save attachment %s - %v", saved.FileName, err)
+				// Hide the message rather than deliver it without its attachments
+				db.MarkMessageAsDeleted(messageID)
+				for _, saved := range savedFiles {
+					removeChatUpload(saved.FileURL)
+				}
+				http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if chatHub != nil {
+			go chatHub.notifyDirectMessageRecipients(&ChatMessage{
+				Type:           "chat_message",
+				ConversationID: conversationID,
+				SenderID:       int64(userID),
+				Content:        req.Content,
+				Timestamp:      msg.CreatedAt.Format(time.RFC3339),
+			}, messageID)
+		}
 	}
 
 	log.Printf("✅ SendMessage: Message successfully sent - ID: %d, User: %d, Conversation: %d", messageID, userID, conversationID)
 
+	attachments := make([]map[string]interface{}, 0, len(savedFiles))
+	for _, saved := range savedFiles {
+		attachments = append(attachments, map[string]interface{}{
+			"file_url":  saved.FileURL,
+			"file_type": saved.FileType,
+			"file_name": saved.FileName,
+			"file_size": saved.FileSize,
+		})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":     "ok",
-		"message_id": messageID,
+		"status":      "ok",
+		"message_id":  messageID,
+		"attachments": attachments,
 	})
 }
 
+// saveChatAttachment validates an uploaded message attachment and stores it under uploads/chat.
+// Images must pass ValidateImageFile and are saved with the extension of their detected type;
+// other files must have an extension from chatAttachmentExtensions.
+func saveChatAttachment(fileHeader *multipart.FileHeader) (*sqlite.ChatAttachment, error) {
+	if fileHeader.Size == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if fileHeader.Size > maxChatAttachmentSize {
+		return nil, fmt.Errorf("file too large. Maximum size is 10MB")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file")
+	}
+	defer file.Close()
+
+	mimeType, err := GetImageMimeType(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var ext string
+	if strings.HasPrefix(mimeType, "image/") {
+		if err := ValidateImageFile(file, fileHeader); err != nil {
+			return nil, err
+		}
+		ext = imageExtension(mimeType)
+	} else if name := strings.ToLower(filepath.Ext(fileHeader.Filename)); chatAttachmentExtensions[name] {
+		ext = name
+	}
+	if ext == "" {
+		return nil, fmt.Errorf("file type not allowed")
+	}
+
+	uploadsDir := utils.GetUploadSubdir("chat")
+	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory")
+	}
+
+	filename := uuid.New().String() + ext
+	dst, err := os.Create(filepath.Join(uploadsDir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save file")
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, file); err != nil {
+		os.Remove(dst.Name())
+		return nil, fmt.Errorf("failed to save file")
+	}
+
+	return &sqlite.ChatAttachment{
+		FileURL:  utils.GetUploadURL(filename, "chat"),
+		FileType: mimeType,
+		FileName: filepath.Base(fileHeader.Filename),
+		FileSize: fileHeader.Size,
+	}, nil
+}
+
+// removeChatUpload deletes a previously uploaded chat attachment from disk
+func removeChatUpload(fileURL string) {
+	prefix := utils.GetUploadURL("", "chat")
+	if !strings.HasPrefix(fileURL, prefix) {
+		return
+	}
+
+	fullPath := filepath.Join(utils.GetUploadSubdir("chat"), filepath.Base(fileURL))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing chat attachment %s: %v", fullPath, err)
+	}
+}
+
+// UploadsHandler serves the uploads directory. Browsers must not sniff the content type,
+// and chat attachments other than images are always downloaded instead of shown inline.
+func UploadsHandler(uploadsPath string) http.Handler {
+	fileServer := http.FileServer(http.Dir(uploadsPath))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if strings.HasPrefix(strings.TrimPrefix(r.URL.Path, "/"), "chat/") && !isImageUpload(r.URL.Path) {
+			w.Header().Set("Content-Disposition", "attachment")
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// isImageUpload reports whether a stored file has one of the extensions used for saved images
+func isImageUpload(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, imageExt := range imageExtensions {
+		if ext == imageExt {
+			return true
+		}
+	}
+	return false
+}
+
 // DebugConversation provides debug information about a conversation
 func DebugConversation(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
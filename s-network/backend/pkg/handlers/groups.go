@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"s-network/backend/pkg/db/sqlite"
 	"s-network/backend/pkg/utils"
@@ -46,7 +47,15 @@ func GetGroups(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userIDPtr := int64(userID)
-	groups, err := db.GetGroups(limit, offset, &userIDPtr)
+
+	// Filter by name/description when a search query is provided
+	var groups []*sqlite.Group
+	searchQuery := strings.TrimSpace(r.URL.Query().Get("q"))
+	if searchQuery != "" {
+		groups, err = db.SearchGroups(searchQuery, limit, offset, &userIDPtr)
+	} else {
+		groups, err = db.GetGroups(limit, offset, &userIDPtr)
+	}
 	if err != nil {
 		log.Printf("Error fetching groups: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -62,6 +71,133 @@ func GetGroups(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetMyGroups retrieves the groups the current user is a member of
+func GetMyGroups(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	groups, err := db.GetUserGroups(int64(userID), limit, offset)
+	if err != nil {
+		log.Printf("Error fetching user groups: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"groups": groups,
+		"count":  len(groups),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetSuggestedGroups returns public groups that people the user follows are members of
+func GetSuggestedGroups(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	groups, err := db.GetGroupsFromFollowings(int64(userID), limit, offset)
+	if err != nil {
+		log.Printf("Error fetching suggested groups: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"groups": groups,
+		"count":  len(groups),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetPopularGroups returns public groups the user hasn't joined, most actively joined first
+func GetPopularGroups(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	userIDPtr := int64(userID)
+	groups, err := db.GetPopularGroups(limit, offset, &userIDPtr)
+	if err != nil {
+		log.Printf("Error fetching popular groups: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"groups": groups,
+		"count":  len(groups),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
 // GetGroup retrieves a specific group by ID
 func GetGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
@@ -113,6 +249,54 @@ func GetGroup(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(group)
 }
 
+// GetGroupMembershipStatus returns the current user's relationship with a group. Unlike GetGroup
+// it works for private groups the user can't access, so the client can pick the right join action
+func GetGroupMembershipStatus(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := db.GetGroup(groupID)
+	if err != nil {
+		log.Printf("Error fetching group: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group_id":         groupID,
+		"privacy":          group.Privacy,
+		"is_joined":        db.IsGroupMember(groupID, int64(userID)),
+		"is_pending":       db.HasPendingInvitation(groupID, int64(userID)),
+		"has_join_request": db.HasPendingJoinRequest(groupID, int64(userID)),
+		"user_role":        db.GetUserRoleInGroup(groupID, int64(userID)),
+		"is_creator":       group.CreatorID == int64(userID),
+		"is_banned":        db.IsBannedFromGroup(groupID, int64(userID)),
+	})
+}
+
+// Allowed length of group names, in characters
+const (
+	minGroupNameLength = 3
+	maxGroupNameLength = 100
+)
+
 // CreateGroup creates a new group
 func CreateGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
@@ -127,7 +311,8 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 		Description string  `json:"description"`
 		Privacy     string  `json:"privacy"`
 		Avatar      string  `json:"avatar"`
-		MemberIDs   []int64 `json:"member_ids"` // Optional member IDs to invite
+		MemberIDs   []int64 `json:"member_ids"`  // Optional member IDs to invite
+		MaxMembers  *int    `json:"max_members"` // Optional member limit, omitted means unlimited
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -139,9 +324,26 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[CreateGroup] userID=%v, requestData=%+v", userID, requestData)
 
 	// Validate input
-	if requestData.Name == "" {
-		log.Printf("[CreateGroup] Group name is required")
-		http.Error(w, "Group name is required", http.StatusBadRequest)
+	requestData.Name = strings.TrimSpace(requestData.Name)
+	nameLength := utf8.RuneCountInString(requestData.Name)
+	if nameLength < minGroupNameLength || nameLength > maxGroupNameLength {
+		log.Printf("[CreateGroup] Invalid group name length: %d", nameLength)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("Group name must be between %d and %d characters", minGroupNameLength, maxGroupNameLength),
+			"field": "name",
+		})
+		return
+	}
+
+	if db.CreatorHasGroupNamed(int64(userID), requestData.Name) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You already have a group with this name",
+			"field": "name",
+		})
 		return
 	}
 
@@ -149,12 +351,19 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 		requestData.Privacy = "public" // Default to public
 	}
 
+	// The creator always counts as the first member
+	if requestData.MaxMembers != nil && *requestData.MaxMembers < 1 {
+		http.Error(w, "max_members must be at least 1", http.StatusBadRequest)
+		return
+	}
+
 	group := &sqlite.Group{
 		Name:        requestData.Name,
 		Description: requestData.Description,
 		CreatorID:   int64(userID),
 		Privacy:     requestData.Privacy,
 		Avatar:      requestData.Avatar,
+		MaxMembers:  requestData.MaxMembers,
 	}
 
 	groupID, err := db.CreateGroup(group)
@@ -163,6 +372,7 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create group", http.StatusInternalServerError)
 		return
 	}
+	group.ID = groupID
 
 	// Create group chat conversation
 	_, err = db.GetOrCreateGroupConversation(groupID)
@@ -232,13 +442,17 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 				}
 
 				// Send real-time notification
-				SendGroupNotification(memberID, int64(userID), "group_invitation",
-					inviterName+" invited you to join "+requestData.Name, groupID)
+				SendGroupInvitation(memberID, int64(userID), invitationID, groupID, requestData.Name, inviterName)
 
 				log.Printf("[CreateGroup] Successfully sent invitation %d to user %d for private group", invitationID, memberID)
 
 			} else {
-				// For public groups, add directly as member
+				// For public groups, add directly as member while there is room
+				if hasRoom, err := groupHasRoomFor(group, 1); err != nil || !hasRoom {
+					log.Printf("[CreateGroup] Warning: Group %d is full, skipping user %d", groupID, memberID)
+					continue
+				}
+
 				err = db.AddGroupMember(groupID, memberID, "member")
 				if err != nil {
 					log.Printf("[CreateGroup] Error adding member %d: %v", memberID, err)
@@ -264,9 +478,11 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 					IsRead:      false,
 				}
 
-				_, err = db.CreateNotification(notification)
-				if err != nil {
-					log.Printf("[CreateGroup] Warning: Could not create group addition notification for user %d: %v", memberID, err)
+				if db.ShouldNotify(memberID, sqlite.NotificationCategoryGroupInvites) {
+					_, err = db.CreateNotification(notification)
+					if err != nil {
+						log.Printf("[CreateGroup] Warning: Could not create group addition notification for user %d: %v", memberID, err)
+					}
 				}
 
 				// Send real-time notification
@@ -296,8 +512,8 @@ func CreateGroup(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// JoinGroup allows a user to join a public group
-func JoinGroup(w http.ResponseWriter, r *http.Request) {
+// UpdateGroup allows the group creator to edit a group's details
+func UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -312,49 +528,118 @@ func JoinGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if group exists and is public
+	var requestData struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Privacy     string `json:"privacy"`
+
+		// Left unchanged when omitted
+		Avatar              *string `json:"avatar"`
+		AutoApproveRequests *bool   `json:"auto_approve_requests"`
+
+		// Left unchanged when omitted, 0 removes the limit
+		MaxMembers *int `json:"max_members"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate input
+	if strings.TrimSpace(requestData.Name) == "" {
+		http.Error(w, "Group name is required", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.Privacy != "public" && requestData.Privacy != "private" {
+		http.Error(w, "Privacy must be either public or private", http.StatusBadRequest)
+		return
+	}
+
+	// Get group to check permissions
 	group, err := db.GetGroup(groupID)
 	if err != nil || group == nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	if group.Privacy != "public" {
-		http.Error(w, "Cannot join private group directly", http.StatusForbidden)
+	if group.CreatorID != int64(userID) {
+		http.Error(w, "Only group creator can update the group", http.StatusForbidden)
 		return
 	}
 
-	// Check if user is already a member
-	if db.IsGroupMember(groupID, int64(userID)) {
-		http.Error(w, "Already a member", http.StatusConflict)
-		return
+	wasPrivate := group.Privacy == "private"
+	wasAutoApproving := group.AutoApproveRequests
+
+	group.Name = requestData.Name
+	group.Description = requestData.Description
+	group.Privacy = requestData.Privacy
+	if requestData.Avatar != nil {
+		group.Avatar = *requestData.Avatar
+	}
+	if requestData.AutoApproveRequests != nil {
+		group.AutoApproveRequests = *requestData.AutoApproveRequests
+	}
+	if requestData.MaxMembers != nil {
+		if *requestData.MaxMembers == 0 {
+			group.MaxMembers = nil
+		} else {
+			members, err := db.GetGroupMembers(groupID)
+			if err != nil {
+				http.Error(w, "Failed to get group members", http.StatusInternalServerError)
+				return
+			}
+			if *requestData.MaxMembers < len(members) {
+				http.Error(w, fmt.Sprintf("max_members can't be lower than the current member count (%d)", len(members)), http.StatusBadRequest)
+				return
+			}
+			group.MaxMembers = requestData.MaxMembers
+		}
 	}
 
-	// Add user as member
-	err = db.AddGroupMember(groupID, int64(userID), "member")
+	err = db.UpdateGroup(group)
 	if err != nil {
-		log.Printf("Error adding group member: %v", err)
-		http.Error(w, "Failed to join group", http.StatusInternalServerError)
+		log.Printf("Error updating group: %v", err)
+		http.Error(w, "Failed to update group", http.StatusInternalServerError)
 		return
 	}
 
-	// Add user to group chat conversation
-	err = db.AddMemberToGroupConversation(groupID, int64(userID))
-	if err != nil {
-		log.Printf("Error adding user to group conversation: %v", err)
-		// Don't fail if chat addition fails
+	// If the group became public or started auto-approving, accept all pending join requests
+	if (wasPrivate && group.Privacy == "public") || (!wasAutoApproving && group.AutoApproveRequests) {
+		approvedUserIDs, err := db.AutoApproveJoinRequests(groupID)
+		if err != nil {
+			log.Printf("Error auto-approving join requests: %v", err)
+			// Don't fail the update if auto-approval fails
+		}
+
+		for _, approvedUserID := range approvedUserIDs {
+			err = db.AddMemberToGroupConversation(groupID, approvedUserID)
+			if err != nil {
+				log.Printf("Error adding user to group conversation: %v", err)
+			}
+
+			SendGroupNotification(approvedUserID, int64(userID), "group_join_accepted",
+				fmt.Sprintf("Your request to join '%s' was accepted", group.Name), groupID)
+		}
 	}
 
-	// No notification needed for JoinGroup since the user is joining voluntarily
+	updatedGroup, err := db.GetGroup(groupID)
+	if err != nil {
+		log.Printf("Error fetching updated group: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Successfully joined group",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group":   updatedGroup,
+		"message": "Group updated successfully",
 	})
 }
 
-// LeaveGroup allows a user to leave a group
-func LeaveGroup(w http.ResponseWriter, r *http.Request) {
+// UpdateGroupAvatar uploads a new avatar for a group and removes the previous one
+func UpdateGroupAvatar(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -362,55 +647,60 @@ func LeaveGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vars := mux.Vars(r)
-	groupIDStr := vars["id"]
-	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	groupID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid group ID", http.StatusBadRequest)
 		return
 	}
 
-	// Check if user is a member
-	if !db.IsGroupMember(groupID, int64(userID)) {
-		http.Error(w, "Not a member of this group", http.StatusBadRequest)
-		return
-	}
-
-	// Get group to check if user is creator
 	group, err := db.GetGroup(groupID)
 	if err != nil || group == nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	// Creator cannot leave, they must transfer ownership or delete the group
-	if group.CreatorID == int64(userID) {
-		http.Error(w, "Group creator cannot leave. Transfer ownership or delete the group.", http.StatusBadRequest)
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can update the avatar", http.StatusForbidden)
 		return
 	}
 
-	// Remove user from group
-	err = db.RemoveGroupMember(groupID, int64(userID))
-	if err != nil {
-		log.Printf("Error removing group member: %v", err)
-		http.Error(w, "Failed to leave group", http.StatusInternalServerError)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
-	// Remove user from group chat conversation
-	err = db.RemoveMemberFromGroupConversation(groupID, int64(userID))
+	image, status, err := saveUploadedImage(r, "avatar", "groups")
 	if err != nil {
-		log.Printf("Error removing user from group conversation: %v", err)
-		// Don't fail if chat removal fails
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if image == nil {
+		http.Error(w, "Avatar file is required", http.StatusBadRequest)
+		return
+	}
+
+	avatarPath := image.URL
+	if err := db.UpdateGroupAvatar(groupID, avatarPath); err != nil {
+		log.Printf("Error updating group avatar: %v", err)
+		os.Remove(filepath.Join(image.Dir, image.Filename))
+		http.Error(w, "Failed to update group avatar", http.StatusInternalServerError)
+		return
+	}
+
+	// Only remove the old avatar once the new one is saved
+	if group.Avatar != "" && group.Avatar != avatarPath {
+		removeGroupUpload(group.Avatar)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Successfully left group",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"avatar":  avatarPath,
+		"message": "Group avatar updated successfully",
 	})
 }
 
-// InviteToGroup invites a user to join a group
-func InviteToGroup(w http.ResponseWriter, r *http.Request) {
+// JoinGroup allows a user to join a public group
+func JoinGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -425,98 +715,59 @@ func InviteToGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is a member of the group
-	if !db.IsGroupMember(groupID, int64(userID)) {
-		http.Error(w, "Access denied", http.StatusForbidden)
+	// Check if group exists and is public
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	var requestData struct {
-		UserID int64 `json:"user_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if group.Privacy != "public" {
+		http.Error(w, "Cannot join private group directly", http.StatusForbidden)
 		return
 	}
 
-	// Check if target user exists
-	targetUser, err := db.GetUserById(int(requestData.UserID))
-	if err != nil || targetUser == nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	if db.IsBannedFromGroup(groupID, int64(userID)) {
+		http.Error(w, "You are banned from this group", http.StatusForbidden)
 		return
 	}
 
 	// Check if user is already a member
-	if db.IsGroupMember(groupID, requestData.UserID) {
-		http.Error(w, "User is already a member", http.StatusConflict)
-		return
-	}
-
-	// Check if invitation already exists
-	if db.HasPendingInvitation(groupID, requestData.UserID) {
-		http.Error(w, "Invitation already sent", http.StatusConflict)
-		return
-	}
-
-	// Get group information for notification
-	group, err := db.GetGroup(groupID)
-	if err != nil || group == nil {
-		http.Error(w, "Group not found", http.StatusNotFound)
+	if db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Already a member", http.StatusConflict)
 		return
 	}
 
-	// Get inviter information for notification
-	inviter, err := db.GetUserById(int(userID))
-	if err != nil {
-		log.Printf("Error getting inviter info: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	if !checkGroupCapacity(w, group, 1) {
 		return
 	}
 
-	inviterName := inviter["first_name"].(string) + " " + inviter["last_name"].(string)
-
-	// Create invitation
-	invitation := &sqlite.GroupInvitation{
-		GroupID:   groupID,
-		InviterID: int64(userID),
-		InviteeID: requestData.UserID,
-	}
-
-	_, err = db.CreateGroupInvitation(invitation)
+	// Add user as member
+	err = db.AddGroupMember(groupID, int64(userID), "member")
 	if err != nil {
-		log.Printf("Error creating group invitation: %v", err)
-		http.Error(w, "Failed to send invitation", http.StatusInternalServerError)
+		log.Printf("Error adding group member: %v", err)
+		http.Error(w, "Failed to join group", http.StatusInternalServerError)
 		return
 	}
 
-	// Create notification for the invited user
-	_, err = db.CreateGroupInviteNotification(requestData.UserID, int64(userID), groupID, group.Name, inviterName)
-	if err != nil {
-		log.Printf("Error creating notification for invitation: %v", err)
-		// Don't fail the invitation if notification creation fails
-	}
-
-	// Send real-time notification
-	SendGroupNotification(requestData.UserID, int64(userID), "group_invitation",
-		inviterName+" invited you to join "+group.Name, groupID)
-
-	// Add user to group chat
+	// Add user to group chat conversation
 	err = db.AddMemberToGroupConversation(groupID, int64(userID))
 	if err != nil {
 		log.Printf("Error adding user to group conversation: %v", err)
+		// Don't fail if chat addition fails
 	}
 
-	// No need to mark notification as read here since invitation was just sent
+	// No notification needed for JoinGroup since the user is joining voluntarily
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Invitation sent successfully",
+		"message": "Successfully joined group",
 	})
 }
 
-// RequestToJoinGroup creates a request to join a private group
-func RequestToJoinGroup(w http.ResponseWriter, r *http.Request) {
+// LeaveGroup allows a user to leave a group. With ?delete_content=true the user's
+// posts and comments in the group are permanently deleted as well
+func LeaveGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -531,121 +782,111 @@ func RequestToJoinGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var requestData struct {
-		Message string `json:"message"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// Check if user is a member
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Not a member of this group", http.StatusBadRequest)
 		return
 	}
 
-	// Check if group exists
+	// Get group to check if user is creator
 	group, err := db.GetGroup(groupID)
 	if err != nil || group == nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	// Check if user is already a member
-	if db.IsGroupMember(groupID, int64(userID)) {
-		http.Error(w, "Already a member", http.StatusConflict)
+	// Creator cannot leave, they must transfer ownership or delete the group
+	if group.CreatorID == int64(userID) {
+		http.Error(w, "Group creator cannot leave. Transfer ownership or delete the group.", http.StatusBadRequest)
 		return
 	}
 
-	// Check if request already exists
-	if db.HasPendingJoinRequest(groupID, int64(userID)) {
-		http.Error(w, "Join request already sent", http.StatusConflict)
-		return
-	}
+	// Optionally delete everything the member posted in the group. This is permanent
+	deleteContent := r.URL.Query().Get("delete_content") == "true"
+	var deleted *sqlite.DeletedGroupContent
+	if deleteContent {
+		deleted, err = db.LeaveGroupAndDeleteContent(groupID, int64(userID))
+		if err != nil {
+			log.Printf("Error deleting group content of user %d: %v", userID, err)
+			http.Error(w, "Failed to delete your group content", http.StatusInternalServerError)
+			return
+		}
 
-	// Create join request
-	joinRequest := &sqlite.GroupJoinRequest{
-		GroupID: groupID,
-		UserID:  int64(userID),
-		Message: requestData.Message,
+		for _, image := range deleted.Images.Posts {
+			removeGroupUpload(image)
+		}
+		// Group post comments share the comments upload directory
+		for _, image := range deleted.Images.Comments {
+			removeCommentUpload(image)
+		}
+	} else {
+		// Remove user from group
+		err = db.RemoveGroupMember(groupID, int64(userID))
+		if err != nil {
+			log.Printf("Error removing group member: %v", err)
+			http.Error(w, "Failed to leave group", http.StatusInternalServerError)
+			return
+		}
 	}
 
-	_, err = db.CreateJoinRequest(joinRequest)
+	// Remove user from group chat conversation
+	err = db.RemoveMemberFromGroupConversation(groupID, int64(userID))
 	if err != nil {
-		log.Printf("Error creating join request: %v", err)
-		http.Error(w, "Failed to send join request", http.StatusInternalServerError)
-		return
+		log.Printf("Error removing user from group conversation: %v", err)
+		// Don't fail if chat removal fails
+	}
+
+	response := map[string]interface{}{
+		"message": "Successfully left group",
+	}
+	if deleteContent {
+		response["deleted_posts"] = deleted.Posts
+		response["deleted_comments"] = deleted.Comments
+		response["warning"] = "Your posts and comments in this group, including replies to them, were permanently deleted"
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Join request sent successfully",
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
-// AcceptInvitation allows a user to accept a group invitation
-func AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+// LeaveAllGroups removes the current user from every group they are a member of.
+// Groups they created are kept and listed so they can be transferred or deleted first
+func LeaveAllGroups(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	vars := mux.Vars(r)
-	invitationIDStr := vars["id"]
-	invitationID, err := strconv.ParseInt(invitationIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
-		return
-	}
-
-	// Get invitation details
-	invitations, err := db.GetUserInvitations(int64(userID), "pending")
+	left, owned, err := db.LeaveAllGroups(int64(userID))
 	if err != nil {
-		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		log.Printf("Error leaving all groups for user %d: %v", userID, err)
+		http.Error(w, "Failed to leave groups", http.StatusInternalServerError)
 		return
 	}
 
-	var invitation *sqlite.GroupInvitation
-	for _, inv := range invitations {
-		if inv.ID == invitationID {
-			invitation = inv
-			break
-		}
-	}
-
-	if invitation == nil {
-		http.Error(w, "Invitation not found", http.StatusNotFound)
-		return
+	if left == nil {
+		left = []int64{}
 	}
-
-	// Accept invitation
-	err = db.UpdateInvitationStatus(invitationID, "accepted")
-	if err != nil {
-		http.Error(w, "Failed to accept invitation", http.StatusInternalServerError)
-		return
+	if owned == nil {
+		owned = []int64{}
 	}
 
-	// Add user to group
-	err = db.AddGroupMember(invitation.GroupID, int64(userID), "member")
-	if err != nil {
-		http.Error(w, "Failed to join group", http.StatusInternalServerError)
-		return
+	response := map[string]interface{}{
+		"message":      fmt.Sprintf("Left %d group(s)", len(left)),
+		"left_groups":  left,
+		"owned_groups": owned,
 	}
-
-	// Add user to group chat
-	err = db.AddMemberToGroupConversation(invitation.GroupID, int64(userID))
-	if err != nil {
-		log.Printf("Error adding user to group conversation: %v", err)
+	if len(owned) > 0 {
+		response["warning"] = "Groups you created were not left. Transfer ownership or delete them."
 	}
 
-	// Delete related notification since invitation is processed
-	deleteGroupInvitationNotification(int64(userID), invitation.GroupID)
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Invitation accepted successfully",
-	})
+	json.NewEncoder(w).Encode(response)
 }
 
-// RejectInvitation allows a user to reject a group invitation
-func RejectInvitation(w http.ResponseWriter, r *http.Request) {
+// TransferGroupOwnership hands ownership of a group to another member (creator only)
+func TransferGroupOwnership(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -653,71 +894,69 @@ func RejectInvitation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vars := mux.Vars(r)
-	invitationIDStr := vars["id"]
-	invitationID, err := strconv.ParseInt(invitationIDStr, 10, 64)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
 		return
 	}
 
-	// Verify invitation belongs to user
-	invitations, err := db.GetUserInvitations(int64(userID), "pending")
-	if err != nil {
-		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
-		return
+	var requestData struct {
+		NewOwnerID int64 `json:"new_owner_id"`
 	}
 
-	var foundInvitation *sqlite.GroupInvitation
-	for _, inv := range invitations {
-		if inv.ID == invitationID {
-			foundInvitation = inv
-			break
-		}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	if foundInvitation == nil {
-		http.Error(w, "Invitation not found", http.StatusNotFound)
+	// Get group to check permissions
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	// Reject invitation
-	err = db.UpdateInvitationStatus(invitationID, "rejected")
-	if err != nil {
-		http.Error(w, "Failed to reject invitation", http.StatusInternalServerError)
+	// Only the current creator can transfer ownership
+	if group.CreatorID != int64(userID) {
+		http.Error(w, "Only group creator can transfer ownership", http.StatusForbidden)
 		return
 	}
 
-	// Delete related notification since invitation is processed
-	deleteGroupInvitationNotification(int64(userID), foundInvitation.GroupID)
+	if requestData.NewOwnerID == group.CreatorID {
+		http.Error(w, "You already own this group", http.StatusBadRequest)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Invitation rejected successfully",
-	})
-}
+	// New owner must already be a member of the group
+	if !db.IsGroupMember(groupID, requestData.NewOwnerID) {
+		http.Error(w, "New owner must be a member of this group", http.StatusBadRequest)
+		return
+	}
 
-// GetUserInvitations retrieves all invitations for the current user
-func GetUserInvitations(w http.ResponseWriter, r *http.Request) {
-	userID, err := getUserIDFromSession(r)
+	err = db.TransferGroupOwnership(groupID, int64(userID), requestData.NewOwnerID)
 	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		log.Printf("Error transferring group ownership: %v", err)
+		http.Error(w, "Failed to transfer ownership", http.StatusInternalServerError)
 		return
 	}
 
-	invitations, err := db.GetUserInvitations(int64(userID), "pending")
+	updatedGroup, err := db.GetGroup(groupID)
 	if err != nil {
-		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		log.Printf("Error fetching updated group: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"invitations": invitations,
+		"message": "Ownership transferred successfully",
+		"group":   updatedGroup,
 	})
 }
 
-// AcceptJoinRequest allows group creator to accept a join request
-func AcceptJoinRequest(w http.ResponseWriter, r *http.Request) {
+// InviteToGroup invites a user to join a group
+func InviteToGroup(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -725,63 +964,1592 @@ func AcceptJoinRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vars := mux.Vars(r)
-	requestIDStr := vars["id"]
-	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get join request details
-	var groupID int64
-	var requesterID int64
-	query := `SELECT group_id, user_id FROM group_join_requests WHERE id = ? AND status = 'pending'`
-	err = db.QueryRow(query, requestID).Scan(&groupID, &requesterID)
-	if err != nil {
-		http.Error(w, "Join request not found", http.StatusNotFound)
+	// Check if user is a member of the group
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
 		return
 	}
 
-	// Check if user is group creator
-	group, err := db.GetGroup(groupID)
-	if err != nil || group == nil {
-		http.Error(w, "Group not found", http.StatusNotFound)
+	var requestData struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if group.CreatorID != int64(userID) {
-		http.Error(w, "Only group creator can accept join requests", http.StatusForbidden)
+	// Check if target user exists
+	targetUser, err := db.GetUserById(int(requestData.UserID))
+	if err != nil || targetUser == nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Users who blocked the inviter can't be invited by them
+	blocked, err := db.IsBlocked(requestData.UserID, int64(userID))
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "You cannot invite this user", http.StatusForbidden)
+		return
+	}
+
+	// Banned users could never accept the invitation
+	if db.IsBannedFromGroup(groupID, requestData.UserID) {
+		http.Error(w, "This user is banned from the group", http.StatusForbidden)
+		return
+	}
+
+	// Check if user is already a member
+	if db.IsGroupMember(groupID, requestData.UserID) {
+		http.Error(w, "User is already a member", http.StatusConflict)
+		return
+	}
+
+	// Check if invitation already exists
+	if db.HasPendingInvitation(groupID, requestData.UserID) {
+		http.Error(w, "Invitation already sent", http.StatusConflict)
+		return
+	}
+
+	// Get group information for notification
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	// Get inviter information for notification
+	inviter, err := db.GetUserById(int(userID))
+	if err != nil {
+		log.Printf("Error getting inviter info: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	inviterName := inviter["first_name"].(string) + " " + inviter["last_name"].(string)
+
+	// Create invitation
+	invitation := &sqlite.GroupInvitation{
+		GroupID:   groupID,
+		InviterID: int64(userID),
+		InviteeID: requestData.UserID,
+	}
+
+	invitationID, err := db.CreateGroupInvitation(invitation)
+	if err != nil {
+		log.Printf("Error creating group invitation: %v", err)
+		http.Error(w, "Failed to send invitation", http.StatusInternalServerError)
+		return
+	}
+
+	// Create notification for the invited user
+	_, err = db.CreateGroupInviteNotification(requestData.UserID, int64(userID), groupID, group.Name, inviterName)
+	if err != nil {
+		log.Printf("Error creating notification for invitation: %v", err)
+		// Don't fail the invitation if notification creation fails
+	}
+
+	// Send real-time notification
+	SendGroupInvitation(requestData.UserID, int64(userID), invitationID, groupID, group.Name, inviterName)
+
+	// Add user to group chat
+	err = db.AddMemberToGroupConversation(groupID, int64(userID))
+	if err != nil {
+		log.Printf("Error adding user to group conversation: %v", err)
+	}
+
+	// No need to mark notification as read here since invitation was just sent
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Invitation sent successfully",
+	})
+}
+
+// maxBulkInvites limits how many identifiers a single bulk invitation request can contain
+const maxBulkInvites = 50
+
+// BulkInviteToGroup invites several users to a group by nickname or email.
+// Each identifier gets its own result so the client can show what happened to it.
+// Emails that weren't invited all get the same status and no user ID, so the
+// endpoint can't be used to find out which addresses are registered
+func BulkInviteToGroup(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var requestData struct {
+		Identifiers []string `json:"identifiers"` // Nicknames or email addresses
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(requestData.Identifiers) == 0 {
+		http.Error(w, "At least one identifier is required", http.StatusBadRequest)
+		return
+	}
+	if len(requestData.Identifiers) > maxBulkInvites {
+		http.Error(w, fmt.Sprintf("Cannot invite more than %d users at once", maxBulkInvites), http.StatusBadRequest)
+		return
+	}
+
+	// Get group information for notification
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	// Get inviter information for notification
+	inviter, err := db.GetUserById(int(userID))
+	if err != nil {
+		log.Printf("Error getting inviter info: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	inviterName := inviter["first_name"].(string) + " " + inviter["last_name"].(string)
+
+	results := make([]map[string]interface{}, 0, len(requestData.Identifiers))
+	invitedCount := 0
+	for _, identifier := range requestData.Identifiers {
+		identifier = strings.TrimSpace(identifier)
+		result := map[string]interface{}{"identifier": identifier}
+		results = append(results, result)
+
+		// Identifiers with an @ after the first character are emails, anything else is a nickname
+		var targetUser map[string]interface{}
+		byEmail := strings.Contains(strings.TrimPrefix(identifier, "@"), "@")
+		if byEmail {
+			targetUser, _ = db.GetUserByEmail(identifier)
+		} else if nickname := strings.TrimPrefix(identifier, "@"); nickname != "" {
+			targetUser, _ = db.GetUserByNickname(nickname)
+		}
+
+		// Email results only tell whether an invitation was sent
+		skip := func(status string) {
+			if byEmail {
+				status = "not_invited"
+			}
+			result["status"] = status
+		}
+
+		if targetUser == nil {
+			skip("not_found")
+			continue
+		}
+
+		targetID := int64(targetUser["id"].(int))
+		if !byEmail {
+			result["user_id"] = targetID
+		}
+
+		// Check if user is already a member
+		if db.IsGroupMember(groupID, targetID) {
+			skip("skipped_member")
+			continue
+		}
+
+		// Check if invitation already exists
+		if db.HasPendingInvitation(groupID, targetID) {
+			skip("skipped_pending")
+			continue
+		}
+
+		// Users who blocked the inviter can't be invited by them, and banned users
+		// could never accept
+		blocked, err := db.IsBlocked(targetID, int64(userID))
+		if err != nil || blocked || db.IsBannedFromGroup(groupID, targetID) {
+			skip("not_allowed")
+			continue
+		}
+
+		// Create invitation
+		invitation := &sqlite.GroupInvitation{
+			GroupID:   groupID,
+			InviterID: int64(userID),
+			InviteeID: targetID,
+		}
+
+		invitationID, err := db.CreateGroupInvitation(invitation)
+		if err != nil {
+			log.Printf("Error creating group invitation for user %d: %v", targetID, err)
+			skip("failed")
+			continue
+		}
+
+		// Create notification for the invited user
+		_, err = db.CreateGroupInviteNotification(targetID, int64(userID), groupID, group.Name, inviterName)
+		if err != nil {
+			log.Printf("Error creating notification for invitation: %v", err)
+			// Don't fail the invitation if notification creation fails
+		}
+
+		// Send real-time notification
+		SendGroupInvitation(targetID, int64(userID), invitationID, groupID, group.Name, inviterName)
+
+		result["status"] = "invited"
+		invitedCount++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": fmt.Sprintf("Invitations sent to %d user(s)", invitedCount),
+		"results": results,
+	})
+}
+
+// RequestToJoinGroup creates a request to join a private group
+func RequestToJoinGroup(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestData struct {
+		Message string `json:"message"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Check if group exists
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if db.IsBannedFromGroup(groupID, int64(userID)) {
+		http.Error(w, "You are banned from this group", http.StatusForbidden)
+		return
+	}
+
+	// Check if user is already a member
+	if db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Already a member", http.StatusConflict)
+		return
+	}
+
+	// Check if request already exists
+	if db.HasPendingJoinRequest(groupID, int64(userID)) {
+		http.Error(w, "Join request already sent", http.StatusConflict)
+		return
+	}
+
+	// Groups that auto-approve requests add the user right away
+	if group.AutoApproveRequests {
+		if !checkGroupCapacity(w, group, 1) {
+			return
+		}
+
+		err = db.AddGroupMember(groupID, int64(userID), "member")
+		if err != nil {
+			log.Printf("Error adding group member: %v", err)
+			http.Error(w, "Failed to join group", http.StatusInternalServerError)
+			return
+		}
+
+		// Add user to group chat conversation
+		err = db.AddMemberToGroupConversation(groupID, int64(userID))
+		if err != nil {
+			log.Printf("Error adding user to group conversation: %v", err)
+			// Don't fail if chat addition fails
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Successfully joined group",
+			"status":  "joined",
+		})
+		return
+	}
+
+	// Create join request
+	joinRequest := &sqlite.GroupJoinRequest{
+		GroupID: groupID,
+		UserID:  int64(userID),
+		Message: requestData.Message,
+	}
+
+	_, err = db.CreateJoinRequest(joinRequest)
+	if err != nil {
+		log.Printf("Error creating join request: %v", err)
+		http.Error(w, "Failed to send join request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Join request sent successfully",
+		"status":  "pending",
+	})
+}
+
+// CancelJoinRequest withdraws the current user's pending request to join a group
+func CancelJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteJoinRequest(groupID, int64(userID))
+	if err != nil {
+		if err.Error() == "join request not found" {
+			http.Error(w, "No pending join request", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error cancelling join request: %v", err)
+		http.Error(w, "Failed to cancel join request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Join request cancelled successfully",
+	})
+}
+
+// AcceptInvitation allows a user to accept a group invitation
+func AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	invitationIDStr := vars["id"]
+	invitationID, err := strconv.ParseInt(invitationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get invitation details
+	invitation, err := db.GetGroupInvitation(invitationID)
+	if err != nil {
+		http.Error(w, "Failed to get invitation", http.StatusInternalServerError)
+		return
+	}
+
+	if invitation == nil || invitation.InviteeID != int64(userID) || invitation.Status != "pending" {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	}
+
+	group, err := db.GetGroup(invitation.GroupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if db.IsBannedFromGroup(invitation.GroupID, int64(userID)) {
+		http.Error(w, "You are banned from this group", http.StatusForbidden)
+		return
+	}
+
+	if !checkGroupCapacity(w, group, 1) {
+		return
+	}
+
+	// Accept invitation
+	err = db.UpdateInvitationStatus(invitationID, "accepted")
+	if err != nil {
+		http.Error(w, "Failed to accept invitation", http.StatusInternalServerError)
+		return
+	}
+
+	// Add user to group
+	err = db.AddGroupMember(invitation.GroupID, int64(userID), "member")
+	if err != nil {
+		http.Error(w, "Failed to join group", http.StatusInternalServerError)
+		return
+	}
+
+	// Add user to group chat
+	err = db.AddMemberToGroupConversation(invitation.GroupID, int64(userID))
+	if err != nil {
+		log.Printf("Error adding user to group conversation: %v", err)
+	}
+
+	// Delete related notification since invitation is processed
+	deleteGroupInvitationNotification(int64(userID), invitation.GroupID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Invitation accepted successfully",
+	})
+}
+
+// RejectInvitation allows a user to reject a group invitation
+func RejectInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	invitationIDStr := vars["id"]
+	invitationID, err := strconv.ParseInt(invitationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	// Verify invitation belongs to user
+	foundInvitation, err := db.GetGroupInvitation(invitationID)
+	if err != nil {
+		http.Error(w, "Failed to get invitation", http.StatusInternalServerError)
+		return
+	}
+
+	if foundInvitation == nil || foundInvitation.InviteeID != int64(userID) || foundInvitation.Status != "pending" {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	}
+
+	// Reject invitation
+	err = db.UpdateInvitationStatus(invitationID, "rejected")
+	if err != nil {
+		http.Error(w, "Failed to reject invitation", http.StatusInternalServerError)
+		return
+	}
+
+	// Delete related notification since invitation is processed
+	deleteGroupInvitationNotification(int64(userID), foundInvitation.GroupID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Invitation rejected successfully",
+	})
+}
+
+// GetGroupInvitations lists the group's pending outgoing invitations for the creator and admins
+func GetGroupInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is group creator or admin
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can view invitations", http.StatusForbidden)
+		return
+	}
+
+	invitations, err := db.GetGroupPendingInvitations(groupID)
+	if err != nil {
+		log.Printf("Error getting group invitations: %v", err)
+		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invitations": invitations,
+	})
+}
+
+// CancelGroupInvitation withdraws a pending invitation. Only the inviter or the group creator can cancel it
+func CancelGroupInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupID, err := strconv.ParseInt(vars["groupId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	invitationID, err := strconv.ParseInt(vars["invitationId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	// Accepted and rejected invitations can't be cancelled anymore
+	invitation, err := db.GetGroupInvitation(invitationID)
+	if err != nil {
+		http.Error(w, "Failed to get invitation", http.StatusInternalServerError)
+		return
+	}
+	if invitation == nil || invitation.GroupID != groupID || invitation.Status != "pending" {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+		return
+	}
+
+	if invitation.InviterID != int64(userID) && group.CreatorID != int64(userID) {
+		http.Error(w, "Only the inviter or group creator can cancel this invitation", http.StatusForbidden)
+		return
+	}
+
+	err = db.DeleteGroupInvitation(invitationID)
+	if err != nil {
+		if err.Error() == "invitation not found" {
+			http.Error(w, "Invitation not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error cancelling group invitation: %v", err)
+		http.Error(w, "Failed to cancel invitation", http.StatusInternalServerError)
+		return
+	}
+
+	// The invitee's notification points to an invitation that no longer exists
+	deleteGroupInvitationNotification(invitation.InviteeID, groupID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Invitation cancelled successfully",
+	})
+}
+
+// GetUserInvitations retrieves a page of the current user's invitations.
+// Pending invitations are returned unless status asks for accepted or rejected history
+func GetUserInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "accepted" && status != "rejected" {
+		http.Error(w, "Status must be pending, accepted or rejected", http.StatusBadRequest)
+		return
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	invitations, err := db.GetUserInvitations(int64(userID), status, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		return
+	}
+
+	total, err := db.CountUserInvitations(int64(userID), status)
+	if err != nil {
+		log.Printf("Error counting invitations: %v", err)
+		http.Error(w, "Failed to get invitations", http.StatusInternalServerError)
+		return
+	}
+
+	if invitations == nil {
+		invitations = []*sqlite.GroupInvitation{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invitations": invitations,
+		"status":      status,
+		"limit":       limit,
+		"offset":      offset,
+		"total":       total,
+		"has_more":    offset+len(invitations) < total,
+	})
+}
+
+// GetMyJoinRequests lists the join requests the current user has sent, optionally filtered by status
+func GetMyJoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "pending" && status != "accepted" && status != "rejected" {
+		http.Error(w, "Status must be pending, accepted or rejected", http.StatusBadRequest)
+		return
+	}
+
+	requests, err := db.GetUserJoinRequests(int64(userID), status)
+	if err != nil {
+		log.Printf("Error getting join requests for user %d: %v", userID, err)
+		http.Error(w, "Failed to get join requests", http.StatusInternalServerError)
+		return
+	}
+
+	if requests == nil {
+		requests = []*sqlite.GroupJoinRequest{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requests": requests,
+	})
+}
+
+// GetMyUpcomingEvents returns upcoming events from all the current user's groups
+func GetMyUpcomingEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	events, err := db.GetUpcomingEventsForUser(int64(userID), limit)
+	if err != nil {
+		log.Printf("Error getting upcoming events for user %d: %v", userID, err)
+		http.Error(w, "Failed to get upcoming events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// AcceptJoinRequest allows group admins to accept a join request
+func AcceptJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	requestIDStr := vars["id"]
+	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get join request details
+	var groupID int64
+	var requesterID int64
+	query := `SELECT group_id, user_id FROM group_join_requests WHERE id = ? AND status = 'pending'`
+	err = db.QueryRow(query, requestID).Scan(&groupID, &requesterID)
+	if err != nil {
+		http.Error(w, "Join request not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is group creator or admin
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can accept join requests", http.StatusForbidden)
+		return
+	}
+
+	if !checkGroupCapacity(w, group, 1) {
 		return
 	}
 
 	// Accept join request
 	err = db.UpdateJoinRequestStatus(requestID, "accepted")
 	if err != nil {
-		http.Error(w, "Failed to accept join request", http.StatusInternalServerError)
+		http.Error(w, "Failed to accept join request", http.StatusInternalServerError)
+		return
+	}
+
+	// Add user to group
+	err = db.AddGroupMember(groupID, requesterID, "member")
+	if err != nil {
+		http.Error(w, "Failed to add user to group", http.StatusInternalServerError)
+		return
+	}
+
+	// Add user to group chat
+	err = db.AddMemberToGroupConversation(groupID, requesterID)
+	if err != nil {
+		log.Printf("Error adding user to group conversation: %v", err)
+	}
+
+	notifyJoinRequestDecision(requesterID, int64(userID), group, true)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Join request accepted successfully",
+	})
+}
+
+// notifyJoinRequestDecision tells the requester that their join request was accepted or rejected
+func notifyJoinRequestDecision(requesterID, adminID int64, group *sqlite.Group, accepted bool) {
+	notificationType := "join_request_rejected"
+	content := fmt.Sprintf("Your request to join %s was declined", group.Name)
+	if accepted {
+		notificationType = "join_request_accepted"
+		content = fmt.Sprintf("Your request to join %s was accepted", group.Name)
+	}
+
+	if db.ShouldNotify(requesterID, sqlite.NotificationCategoryGroupInvites) {
+		_, err := db.CreateNotification(&sqlite.Notification{
+			ReceiverID:  requesterID,
+			SenderID:    adminID,
+			Type:        notificationType,
+			Content:     content,
+			ReferenceID: group.ID,
+			IsRead:      false,
+		})
+		if err != nil {
+			log.Printf("Error creating %s notification for user %d: %v", notificationType, requesterID, err)
+		}
+	}
+
+	// Send real-time notification
+	SendGroupNotification(requesterID, adminID, notificationType, content, group.ID)
+}
+
+// RejectJoinRequest allows group admins to reject a join request
+func RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	requestIDStr := vars["id"]
+	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get join request details
+	var groupID int64
+	var requesterID int64
+	query := `SELECT group_id, user_id FROM group_join_requests WHERE id = ? AND status = 'pending'`
+	err = db.QueryRow(query, requestID).Scan(&groupID, &requesterID)
+	if err != nil {
+		http.Error(w, "Join request not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is group creator or admin
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can reject join requests", http.StatusForbidden)
+		return
+	}
+
+	// Reject join request
+	err = db.UpdateJoinRequestStatus(requestID, "rejected")
+	if err != nil {
+		http.Error(w, "Failed to reject join request", http.StatusInternalServerError)
+		return
+	}
+
+	notifyJoinRequestDecision(requesterID, int64(userID), group, false)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Join request rejected successfully",
+	})
+}
+
+// GetGroupJoinRequests retrieves all join requests for a group
+func GetGroupJoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is group creator or admin
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can view join requests", http.StatusForbidden)
+		return
+	}
+
+	requests, err := db.GetGroupJoinRequests(groupID, "pending")
+	if err != nil {
+		http.Error(w, "Failed to get join requests", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"requests": requests,
+	})
+}
+
+// GetGroupReports lists reports on posts and comments in a group for its admins
+func GetGroupReports(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is group creator or admin
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can view reports", http.StatusForbidden)
+		return
+	}
+
+	reports, err := db.GetGroupReports(groupID)
+	if err != nil {
+		http.Error(w, "Failed to get reports", http.StatusInternalServerError)
+		return
+	}
+
+	if reports == nil {
+		reports = []*sqlite.Report{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reports": reports,
+	})
+}
+
+// CreateGroupPost creates a new post in a group
+func CreateGroupPost(w http.ResponseWriter, r *http.Request) {
+	log.Printf("=== CreateGroupPost Handler Start ===")
+
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		log.Printf("CreateGroupPost: getUserIDFromSession error: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	log.Printf("CreateGroupPost: User ID: %d", userID)
+
+	vars := mux.Vars(r)
+
+	// Event discussion posts are created through /groups/events/{eventId}/posts,
+	// where the group comes from the event
+	var event *sqlite.GroupEvent
+	if eventIDStr, ok := vars["eventId"]; ok {
+		event, err = getDiscussionEvent(eventIDStr, userID)
+		if err != nil {
+			http.Error(w, "Event not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	groupIDStr := vars["id"]
+	if event != nil {
+		groupIDStr = strconv.FormatInt(event.GroupID, 10)
+	}
+	log.Printf("CreateGroupPost: Group ID string: %s", groupIDStr)
+
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		log.Printf("CreateGroupPost: ParseInt error: %v", err)
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	log.Printf("CreateGroupPost: Parsed Group ID: %d", groupID)
+
+	// Check if user is a member of the group
+	log.Printf("CreateGroupPost: Checking if user %d is member of group %d", userID, groupID)
+	isMember := db.IsGroupMember(groupID, int64(userID))
+	log.Printf("CreateGroupPost: Is member check result: %t", isMember)
+
+	if !isMember {
+		log.Printf("CreateGroupPost: Access denied - user %d is not a member of group %d", userID, groupID)
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	// Parse multipart form for file uploads
+	log.Printf("CreateGroupPost: Parsing multipart form")
+	err = r.ParseMultipartForm(10 << 20) // 10 MB max
+	if err != nil {
+		log.Printf("CreateGroupPost: ParseMultipartForm error: %v", err)
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	content := r.FormValue("content")
+	log.Printf("CreateGroupPost: Content: %s", content)
+	isDraft := r.FormValue("draft") == "true"
+
+	// An optional event_id attaches the post to an event in the same group
+	if eventIDStr := r.FormValue("event_id"); event == nil && eventIDStr != "" {
+		event, err = getDiscussionEvent(eventIDStr, userID)
+		if err != nil || event.GroupID != groupID {
+			http.Error(w, "Event not found in this group", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if content == "" {
+		log.Printf("CreateGroupPost: Content is empty")
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	// Handle file upload
+	var imagePath, thumbnailPath string
+	log.Printf("CreateGroupPost: Checking for image file")
+	file, handler, err := r.FormFile("image")
+	if err == nil && handler != nil && handler.Filename != "" {
+		log.Printf("CreateGroupPost: Image file found: %s (size: %d bytes)", handler.Filename, handler.Size)
+		defer file.Close()
+
+		// Only validate if there's actually a file with content
+		if handler.Size > 0 {
+			// Validate image file format (JPEG, PNG, GIF, WebP only)
+			log.Printf("CreateGroupPost: Validating image file")
+			if err := ValidateImageFile(file, handler); err != nil {
+				log.Printf("CreateGroupPost: ValidateImageFile error: %v", err)
+				http.Error(w, "Invalid image file: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := ValidateImageDimensions(file); err != nil {
+				log.Printf("CreateGroupPost: ValidateImageDimensions error: %v", err)
+				http.Error(w, "Invalid image file: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Create uploads directory if it doesn't exist
+			uploadsDir := utils.GetUploadSubdir("groups")
+			log.Printf("CreateGroupPost: Creating uploads directory: %s", uploadsDir)
+			err = os.MkdirAll(uploadsDir, 0755)
+			if err != nil {
+				log.Printf("CreateGroupPost: MkdirAll error: %v", err)
+				http.Error(w, "Failed to create upload directory", http.StatusInternalServerError)
+				return
+			}
+
+			// Generate a unique filename with proper extension based on content type
+			log.Printf("CreateGroupPost: Getting image MIME type")
+			mimeType, err := GetImageMimeType(file)
+			if err != nil {
+				log.Printf("CreateGroupPost: GetImageMimeType error: %v", err)
+				http.Error(w, "Failed to determine image type", http.StatusBadRequest)
+				return
+			}
+			log.Printf("CreateGroupPost: Image MIME type: %s", mimeType)
+
+			var ext string
+			switch mimeType {
+			case "image/jpeg":
+				ext = ".jpg"
+			case "image/png":
+				ext = ".png"
+			case "image/gif":
+				ext = ".gif"
+			case "image/webp":
+				ext = ".webp"
+			default:
+				log.Printf("CreateGroupPost: Unsupported image format: %s", mimeType)
+				http.Error(w, "Unsupported image format. Only "+supportedImageFormats+" images are allowed", http.StatusBadRequest)
+				return
+			}
+
+			filename := uuid.New().String() + ext
+			imagePath = utils.GetUploadURL(filename, "groups")
+			log.Printf("CreateGroupPost: Image path: %s", imagePath)
+
+			// Create the file
+			fullPath := filepath.Join(uploadsDir, filename)
+			log.Printf("CreateGroupPost: Creating file: %s", fullPath)
+			dst, err := os.Create(fullPath)
+			if err != nil {
+				log.Printf("CreateGroupPost: os.Create error: %v", err)
+				http.Error(w, "Failed to save image", http.StatusInternalServerError)
+				return
+			}
+			defer dst.Close()
+
+			// Copy the file content
+			log.Printf("CreateGroupPost: Copying file content")
+			if _, err = io.Copy(dst, file); err != nil {
+				log.Printf("CreateGroupPost: io.Copy error: %v", err)
+				http.Error(w, "Failed to save image", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("CreateGroupPost: Image saved successfully")
+
+			// Generate a smaller copy for feeds, falling back to the original if that isn't possible
+			thumbnailPath = imagePath
+			thumbFilename, err := CreateThumbnail(uploadsDir, filename, mimeType)
+			if err != nil {
+				log.Printf("CreateGroupPost: CreateThumbnail error: %v", err)
+			} else if thumbFilename != "" {
+				thumbnailPath = utils.GetUploadURL(thumbFilename, "groups")
+			}
+		} else {
+			log.Printf("CreateGroupPost: Empty image file provided, ignoring")
+		}
+	} else {
+		log.Printf("CreateGroupPost: No image file provided (error: %v)", err)
+	}
+
+	// Create post
+	post := &sqlite.GroupPost{
+		GroupID:   groupID,
+		AuthorID:  int64(userID),
+		Content:      content,
+		ImagePath:    imagePath,
+		ThumbnailURL: thumbnailPath,
+		IsDraft:      isDraft,
+	}
+	if event != nil {
+		post.EventID = &event.ID
+	}
+	log.Printf("CreateGroupPost: Creating post struct: %+v", post)
+
+	log.Printf("CreateGroupPost: Calling db.CreateGroupPost")
+	postID, err := db.CreateGroupPost(post)
+	if err != nil {
+		log.Printf("CreateGroupPost: db.CreateGroupPost error: %v", err)
+		http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("CreateGroupPost: Post created with ID: %d", postID)
+
+	// Get the created post with author details
+	log.Printf("CreateGroupPost: Getting created post details")
+	createdPost, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil {
+		log.Printf("CreateGroupPost: db.GetGroupPost error: %v", err)
+		http.Error(w, "Failed to retrieve created post", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("CreateGroupPost: Retrieved post: %+v", createdPost)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	// Send WebSocket notification to group members about new post. Drafts are announced when published
+	if !isDraft {
+		go broadcastGroupPostCreated(groupID, postID, userID)
+	}
+
+	log.Printf("CreateGroupPost: Sending response")
+	err = json.NewEncoder(w).Encode(createdPost)
+	if err != nil {
+		log.Printf("CreateGroupPost: json.Encode error: %v", err)
+	}
+
+	log.Printf("=== CreateGroupPost Handler End ===")
+}
+
+// getDiscussionEvent loads the event a discussion post belongs to
+func getDiscussionEvent(eventIDStr string, userID int) (*sqlite.GroupEvent, error) {
+	eventID, err := strconv.ParseInt(eventIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	event, err := db.GetGroupEvent(eventID, int64(userID))
+	if err != nil || event == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return event, nil
+}
+
+// maxEventPostsLimit caps how many event discussion posts one request can load
+const maxEventPostsLimit = 50
+
+// GetGroupEventPosts returns the discussion posts of a group event (members only)
+func GetGroupEventPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Add user to group
-	err = db.AddGroupMember(groupID, requesterID, "member")
+	vars := mux.Vars(r)
+	event, err := getDiscussionEvent(vars["eventId"], userID)
 	if err != nil {
-		http.Error(w, "Failed to add user to group", http.StatusInternalServerError)
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	if !db.IsGroupMember(event.GroupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	limit := 20
+	if parsedLimit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+	}
+	if limit > maxEventPostsLimit {
+		limit = maxEventPostsLimit
+	}
+
+	offset := 0
+	if parsedOffset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsedOffset >= 0 {
+		offset = parsedOffset
+	}
+
+	posts, err := db.GetEventPosts(event.ID, limit, offset, int64(userID))
+	if err != nil {
+		log.Printf("Error getting event posts: %v", err)
+		http.Error(w, "Failed to get posts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event_id": event.ID,
+		"group_id": event.GroupID,
+		"posts":    posts,
+	})
+}
+
+// broadcastGroupPostCreated tells group members that a post was published
+func broadcastGroupPostCreated(groupID, postID int64, userID int) {
+	notificationMessage := map[string]interface{}{
+		"type":       "post_created",
+		"post_id":    postID,
+		"group_id":   groupID,
+		"created_by": userID,
+	}
+
+	if err := broadcastToGroupMembers(groupID, notificationMessage); err != nil {
+		log.Printf("Error broadcasting post creation: %v", err)
+	}
+}
+
+// GetGroupPostDrafts returns the current user's unpublished posts in a group
+func GetGroupPostDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	drafts, err := db.GetGroupPostDrafts(groupID, int64(userID))
+	if err != nil {
+		log.Printf("Error getting drafts: %v", err)
+		http.Error(w, "Failed to get drafts", http.StatusInternalServerError)
+		return
+	}
+
+	if drafts == nil {
+		drafts = []*sqlite.GroupPost{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"drafts": drafts,
+	})
+}
+
+// PublishGroupPost publishes one of the current user's drafts and notifies the group
+func PublishGroupPost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postIDStr := vars["postId"]
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	if post.AuthorID != int64(userID) {
+		http.Error(w, "You can only publish your own posts", http.StatusForbidden)
+		return
+	}
+
+	if !post.IsDraft {
+		http.Error(w, "Post is already published", http.StatusBadRequest)
+		return
+	}
+
+	if !db.IsGroupMember(post.GroupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	if err := db.PublishGroupPost(postID); err != nil {
+		if err.Error() == "draft not found" {
+			http.Error(w, "Post is already published", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error publishing post: %v", err)
+		http.Error(w, "Failed to publish post", http.StatusInternalServerError)
+		return
+	}
+
+	publishedPost, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || publishedPost == nil {
+		http.Error(w, "Failed to retrieve published post", http.StatusInternalServerError)
+		return
+	}
+
+	go broadcastGroupPostCreated(post.GroupID, postID, userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(publishedPost)
+}
+
+// EditGroupPost allows the author of a group post to update its content and image
+func EditGroupPost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postIDStr := vars["postId"]
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Only the author can edit the post
+	if post.AuthorID != int64(userID) {
+		http.Error(w, "Only the post author can edit this post", http.StatusForbidden)
+		return
+	}
+
+	// Parse multipart form for file uploads
+	err = r.ParseMultipartForm(10 << 20) // 10 MB max
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	content := strings.TrimSpace(r.FormValue("content"))
+	if content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	oldImagePath := post.ImagePath
+	imagePath := post.ImagePath
+
+	// Allow removing the existing image without uploading a new one
+	if r.FormValue("remove_image") == "true" {
+		imagePath = ""
+	}
+
+	// Handle file upload
+	image, status, err := saveUploadedImage(r, "image", "groups")
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if image != nil {
+		imagePath = image.URL
+	}
+
+	err = db.UpdateGroupPost(postID, content, imagePath)
+	if err != nil {
+		log.Printf("Error updating group post: %v", err)
+		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		return
+	}
+
+	// Clean up the previous image if it was replaced or removed
+	if oldImagePath != "" && oldImagePath != imagePath {
+		removeGroupUpload(oldImagePath)
+	}
+
+	// UpdateGroupPost drops the old thumbnail, so a new image needs its own
+	if image != nil {
+		if err := db.SetGroupPostThumbnail(postID, image.thumbnailURL("groups")); err != nil {
+			log.Printf("Error saving group post thumbnail: %v", err)
+		}
+	}
+
+	updatedPost, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || updatedPost == nil {
+		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
+		return
+	}
+
+	// Send WebSocket notification to group members about the edit
+	go broadcastGroupPostEdited(post.GroupID, postID, userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedPost)
+}
+
+// RemoveGroupPostImage drops the image from a group post without touching its content
+func RemoveGroupPostImage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["postId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Only the author can edit the post
+	if post.AuthorID != int64(userID) {
+		http.Error(w, "Only the post author can edit this post", http.StatusForbidden)
+		return
+	}
+
+	// Nothing to remove, so the post is already in the requested state
+	if post.ImagePath == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(post)
+		return
+	}
+
+	if err := db.ClearGroupPostImage(postID); err != nil {
+		log.Printf("Error clearing group post image: %v", err)
+		http.Error(w, "Failed to remove image", http.StatusInternalServerError)
+		return
+	}
+
+	removeGroupUpload(post.ImagePath)
+
+	updatedPost, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || updatedPost == nil {
+		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
+		return
+	}
+
+	go broadcastGroupPostEdited(post.GroupID, postID, userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedPost)
+}
+
+// broadcastGroupPostEdited tells group members that a post was edited
+func broadcastGroupPostEdited(groupID, postID int64, userID int) {
+	notificationMessage := map[string]interface{}{
+		"type":      "post_edited",
+		"post_id":   postID,
+		"group_id":  groupID,
+		"edited_by": userID,
+	}
+
+	if err := broadcastToGroupMembers(groupID, notificationMessage); err != nil {
+		log.Printf("Error broadcasting post edit: %v", err)
+	}
+}
+
+// removeGroupUpload deletes a previously uploaded group image from disk
+func removeGroupUpload(imagePath string) {
+	prefix := utils.GetUploadURL("", "groups")
+	if !strings.HasPrefix(imagePath, prefix) {
+		return
+	}
+
+	fullPath := filepath.Join(utils.GetUploadSubdir("groups"), filepath.Base(imagePath))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing group image %s: %v", fullPath, err)
+	}
+
+	for _, thumb := range thumbnailFilenames(filepath.Base(imagePath)) {
+		thumbPath := filepath.Join(utils.GetUploadSubdir("groups"), thumb)
+		if err := os.Remove(thumbPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing group thumbnail %s: %v", thumbPath, err)
+		}
+	}
+}
+
+// UpdateGroupPostPin pins or unpins a group post based on the request body
+func UpdateGroupPostPin(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Pinned bool `json:"pinned"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Add user to group chat
-	err = db.AddMemberToGroupConversation(groupID, requesterID)
-	if err != nil {
-		log.Printf("Error adding user to group conversation: %v", err)
+	if requestData.Pinned {
+		PinGroupPost(w, r)
+	} else {
+		UnpinGroupPost(w, r)
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Join request accepted successfully",
-	})
+// PinGroupPost pins a post to the top of the group feed
+func PinGroupPost(w http.ResponseWriter, r *http.Request) {
+	setGroupPostPinned(w, r, true)
 }
 
-// RejectJoinRequest allows group creator to reject a join request
-func RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+// UnpinGroupPost removes a post from the pinned posts
+func UnpinGroupPost(w http.ResponseWriter, r *http.Request) {
+	setGroupPostPinned(w, r, false)
+}
+
+// setGroupPostPinned applies a pin change for the post in the request path
+// (post author, group creator or admin only)
+func setGroupPostPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -789,49 +2557,55 @@ func RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vars := mux.Vars(r)
-	requestIDStr := vars["id"]
-	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	postIDStr := vars["postId"]
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get join request details
-	var groupID int64
-	query := `SELECT group_id FROM group_join_requests WHERE id = ? AND status = 'pending'`
-	err = db.QueryRow(query, requestID).Scan(&groupID)
-	if err != nil {
-		http.Error(w, "Join request not found", http.StatusNotFound)
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Check if user is group creator
-	group, err := db.GetGroup(groupID)
+	group, err := db.GetGroup(post.GroupID)
 	if err != nil || group == nil {
 		http.Error(w, "Group not found", http.StatusNotFound)
 		return
 	}
 
-	if group.CreatorID != int64(userID) {
-		http.Error(w, "Only group creator can reject join requests", http.StatusForbidden)
+	// Check permissions: post author, group creator or admin
+	if post.AuthorID != int64(userID) && group.CreatorID != int64(userID) &&
+		!db.IsGroupAdmin(post.GroupID, int64(userID)) {
+		http.Error(w, "Only the post author or group admins can pin posts", http.StatusForbidden)
 		return
 	}
 
-	// Reject join request
-	err = db.UpdateJoinRequestStatus(requestID, "rejected")
+	err = db.SetGroupPostPinned(postID, pinned)
 	if err != nil {
-		http.Error(w, "Failed to reject join request", http.StatusInternalServerError)
+		if err.Error() == "pin limit reached" {
+			http.Error(w, fmt.Sprintf("A group can have at most %d pinned posts", sqlite.MaxPinnedGroupPosts), http.StatusConflict)
+			return
+		}
+		log.Printf("Error updating pin status: %v", err)
+		http.Error(w, "Failed to update pin status", http.StatusInternalServerError)
+		return
+	}
+
+	updatedPost, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || updatedPost == nil {
+		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Join request rejected successfully",
-	})
+	json.NewEncoder(w).Encode(updatedPost)
 }
 
-// GetGroupJoinRequests retrieves all join requests for a group
-func GetGroupJoinRequests(w http.ResponseWriter, r *http.Request) {
+// GetGroupPosts retrieves all posts for a group
+func GetGroupPosts(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -846,220 +2620,131 @@ func GetGroupJoinRequests(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is group creator
-	group, err := db.GetGroup(groupID)
-	if err != nil || group == nil {
-		http.Error(w, "Group not found", http.StatusNotFound)
+	// Check if user is a member of the group
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
 		return
 	}
 
-	if group.CreatorID != int64(userID) {
-		http.Error(w, "Only group creator can view join requests", http.StatusForbidden)
-		return
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
+
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
 	}
 
-	requests, err := db.GetGroupJoinRequests(groupID, "pending")
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	// Cursor-based pagination takes precedence over offset when provided
+	var beforeID int64
+	if beforeIDStr := r.URL.Query().Get("before_id"); beforeIDStr != "" {
+		parsedBeforeID, err := strconv.ParseInt(beforeIDStr, 10, 64)
+		if err != nil || parsedBeforeID <= 0 {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+		beforeID = parsedBeforeID
+	}
+
+	posts, err := db.GetGroupPosts(groupID, limit, offset, beforeID, int64(userID))
 	if err != nil {
-		http.Error(w, "Failed to get join requests", http.StatusInternalServerError)
+		http.Error(w, "Failed to get posts", http.StatusInternalServerError)
 		return
 	}
 
+	// The next cursor is the smallest post ID in this page
+	var nextCursor *int64
+	for _, post := range posts {
+		if nextCursor == nil || post.ID < *nextCursor {
+			postID := post.ID
+			nextCursor = &postID
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"requests": requests,
+		"posts":       posts,
+		"next_cursor": nextCursor,
 	})
 }
 
-// CreateGroupPost creates a new post in a group
-func CreateGroupPost(w http.ResponseWriter, r *http.Request) {
-	log.Printf("=== CreateGroupPost Handler Start ===")
-
+// SearchGroupPosts searches the posts of a group by content
+func SearchGroupPosts(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
-		log.Printf("CreateGroupPost: getUserIDFromSession error: %v", err)
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	log.Printf("CreateGroupPost: User ID: %d", userID)
 
 	vars := mux.Vars(r)
 	groupIDStr := vars["id"]
-	log.Printf("CreateGroupPost: Group ID string: %s", groupIDStr)
-
 	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
 	if err != nil {
-		log.Printf("CreateGroupPost: ParseInt error: %v", err)
 		http.Error(w, "Invalid group ID", http.StatusBadRequest)
 		return
 	}
-	log.Printf("CreateGroupPost: Parsed Group ID: %d", groupID)
 
 	// Check if user is a member of the group
-	log.Printf("CreateGroupPost: Checking if user %d is member of group %d", userID, groupID)
-	isMember := db.IsGroupMember(groupID, int64(userID))
-	log.Printf("CreateGroupPost: Is member check result: %t", isMember)
-
-	if !isMember {
-		log.Printf("CreateGroupPost: Access denied - user %d is not a member of group %d", userID, groupID)
+	if !db.IsGroupMember(groupID, int64(userID)) {
 		http.Error(w, "Access denied", http.StatusForbidden)
 		return
 	}
 
-	// Parse multipart form for file uploads
-	log.Printf("CreateGroupPost: Parsing multipart form")
-	err = r.ParseMultipartForm(10 << 20) // 10 MB max
-	if err != nil {
-		log.Printf("CreateGroupPost: ParseMultipartForm error: %v", err)
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
-		return
-	}
-
-	content := r.FormValue("content")
-	log.Printf("CreateGroupPost: Content: %s", content)
-
-	if content == "" {
-		log.Printf("CreateGroupPost: Content is empty")
-		http.Error(w, "Content is required", http.StatusBadRequest)
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
 		return
 	}
 
-	// Handle file upload
-	var imagePath string
-	log.Printf("CreateGroupPost: Checking for image file")
-	file, handler, err := r.FormFile("image")
-	if err == nil && handler != nil && handler.Filename != "" {
-		log.Printf("CreateGroupPost: Image file found: %s (size: %d bytes)", handler.Filename, handler.Size)
-		defer file.Close()
-
-		// Only validate if there's actually a file with content
-		if handler.Size > 0 {
-			// Validate image file format (JPEG, PNG, GIF only)
-			log.Printf("CreateGroupPost: Validating image file")
-			if err := ValidateImageFile(file, handler); err != nil {
-				log.Printf("CreateGroupPost: ValidateImageFile error: %v", err)
-				http.Error(w, "Invalid image file: "+err.Error(), http.StatusBadRequest)
-				return
-			}
-
-			// Create uploads directory if it doesn't exist
-			uploadsDir := utils.GetUploadSubdir("groups")
-			log.Printf("CreateGroupPost: Creating uploads directory: %s", uploadsDir)
-			err = os.MkdirAll(uploadsDir, 0755)
-			if err != nil {
-				log.Printf("CreateGroupPost: MkdirAll error: %v", err)
-				http.Error(w, "Failed to create upload directory", http.StatusInternalServerError)
-				return
-			}
-
-			// Generate a unique filename with proper extension based on content type
-			log.Printf("CreateGroupPost: Getting image MIME type")
-			mimeType, err := GetImageMimeType(file)
-			if err != nil {
-				log.Printf("CreateGroupPost: GetImageMimeType error: %v", err)
-				http.Error(w, "Failed to determine image type", http.StatusBadRequest)
-				return
-			}
-			log.Printf("CreateGroupPost: Image MIME type: %s", mimeType)
-
-			var ext string
-			switch mimeType {
-			case "image/jpeg":
-				ext = ".jpg"
-			case "image/png":
-				ext = ".png"
-			case "image/gif":
-				ext = ".gif"
-			default:
-				log.Printf("CreateGroupPost: Unsupported image format: %s", mimeType)
-				http.Error(w, "Unsupported image format", http.StatusBadRequest)
-				return
-			}
-
-			filename := uuid.New().String() + ext
-			imagePath = utils.GetUploadURL(filename, "groups")
-			log.Printf("CreateGroupPost: Image path: %s", imagePath)
-
-			// Create the file
-			fullPath := filepath.Join(uploadsDir, filename)
-			log.Printf("CreateGroupPost: Creating file: %s", fullPath)
-			dst, err := os.Create(fullPath)
-			if err != nil {
-				log.Printf("CreateGroupPost: os.Create error: %v", err)
-				http.Error(w, "Failed to save image", http.StatusInternalServerError)
-				return
-			}
-			defer dst.Close()
+	// Parse pagination parameters
+	limitStr := r.URL.Query().Get("limit")
+	offsetStr := r.URL.Query().Get("offset")
 
-			// Copy the file content
-			log.Printf("CreateGroupPost: Copying file content")
-			if _, err = io.Copy(dst, file); err != nil {
-				log.Printf("CreateGroupPost: io.Copy error: %v", err)
-				http.Error(w, "Failed to save image", http.StatusInternalServerError)
-				return
-			}
-			log.Printf("CreateGroupPost: Image saved successfully")
-		} else {
-			log.Printf("CreateGroupPost: Empty image file provided, ignoring")
+	limit := 20
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
 		}
-	} else {
-		log.Printf("CreateGroupPost: No image file provided (error: %v)", err)
 	}
 
-	// Create post
-	post := &sqlite.GroupPost{
-		GroupID:   groupID,
-		AuthorID:  int64(userID),
-		Content:   content,
-		ImagePath: imagePath,
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
 	}
-	log.Printf("CreateGroupPost: Creating post struct: %+v", post)
 
-	log.Printf("CreateGroupPost: Calling db.CreateGroupPost")
-	postID, err := db.CreateGroupPost(post)
+	posts, err := db.SearchGroupPosts(groupID, query, limit, offset, int64(userID))
 	if err != nil {
-		log.Printf("CreateGroupPost: db.CreateGroupPost error: %v", err)
-		http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		log.Printf("Error searching group posts: %v", err)
+		http.Error(w, "Failed to search posts", http.StatusInternalServerError)
 		return
 	}
-	log.Printf("CreateGroupPost: Post created with ID: %d", postID)
 
-	// Get the created post with author details
-	log.Printf("CreateGroupPost: Getting created post details")
-	createdPost, err := db.GetGroupPost(postID, int64(userID))
-	if err != nil {
-		log.Printf("CreateGroupPost: db.GetGroupPost error: %v", err)
-		http.Error(w, "Failed to retrieve created post", http.StatusInternalServerError)
-		return
+	if posts == nil {
+		posts = []*sqlite.GroupPost{}
 	}
-	log.Printf("CreateGroupPost: Retrieved post: %+v", createdPost)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	// Send WebSocket notification to group members about new post
-	go func() {
-		notificationMessage := map[string]interface{}{
-			"type":       "post_created",
-			"post_id":    postID,
-			"group_id":   groupID,
-			"created_by": userID,
-		}
-
-		if err := broadcastToGroupMembers(groupID, notificationMessage); err != nil {
-			log.Printf("Error broadcasting post creation: %v", err)
-		}
-	}()
-
-	log.Printf("CreateGroupPost: Sending response")
-	err = json.NewEncoder(w).Encode(createdPost)
-	if err != nil {
-		log.Printf("CreateGroupPost: json.Encode error: %v", err)
-	}
-
-	log.Printf("=== CreateGroupPost Handler End ===")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"posts":  posts,
+		"query":  query,
+		"limit":  limit,
+		"offset": offset,
+	})
 }
 
-// GetGroupPosts retrieves all posts for a group
-func GetGroupPosts(w http.ResponseWriter, r *http.Request) {
+// GetGroupActivity returns a single feed of the group's recent posts, events and new members
+func GetGroupActivity(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -1086,7 +2771,7 @@ func GetGroupPosts(w http.ResponseWriter, r *http.Request) {
 
 	limit := 20
 	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
 			limit = parsedLimit
 		}
 	}
@@ -1098,20 +2783,24 @@ func GetGroupPosts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	posts, err := db.GetGroupPosts(groupID, limit, offset, int64(userID))
+	activities, err := db.GetGroupActivity(groupID, limit, offset)
 	if err != nil {
-		http.Error(w, "Failed to get posts", http.StatusInternalServerError)
+		log.Printf("Error getting group activity: %v", err)
+		http.Error(w, "Failed to get group activity", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"posts": posts,
+		"activity": activities,
+		"limit":    limit,
+		"offset":   offset,
 	})
 }
 
-// LikeGroupPost likes or unlikes a group post
-func LikeGroupPost(w http.ResponseWriter, r *http.Request) {
+// ReactGroupPost sets, changes, or removes the user's reaction on a group post.
+// Sending the reaction the user already has removes it. An empty body reacts with "like".
+func ReactGroupPost(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -1126,26 +2815,41 @@ func LikeGroupPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var req struct {
+		Reaction string `json:"reaction"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reaction := strings.ToLower(strings.TrimSpace(req.Reaction))
+	if reaction == "" {
+		reaction = "like"
+	}
+	if !sqlite.GroupPostReactions[reaction] {
+		http.Error(w, "Invalid reaction. Must be one of: like, love, laugh, sad, angry", http.StatusBadRequest)
+		return
+	}
+
 	// Check if post exists
 	post, err := db.GetGroupPost(postID, int64(userID))
-	if err != nil || post == nil {
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
 		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Check if user already liked the post
-	if db.HasUserLikedGroupPost(postID, int64(userID)) {
-		// Unlike the post
+	if post.UserReaction == reaction {
+		// Same reaction again removes it
 		err = db.UnlikeGroupPost(postID, int64(userID))
 		if err != nil {
-			http.Error(w, "Failed to unlike post", http.StatusInternalServerError)
+			http.Error(w, "Failed to remove reaction", http.StatusInternalServerError)
 			return
 		}
 	} else {
-		// Like the post
-		err = db.LikeGroupPost(postID, int64(userID))
+		err = db.SetGroupPostReaction(postID, int64(userID), reaction)
 		if err != nil {
-			http.Error(w, "Failed to like post", http.StatusInternalServerError)
+			http.Error(w, "Failed to react to post", http.StatusInternalServerError)
 			return
 		}
 	}
@@ -1179,6 +2883,7 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 
 	var content string
 	var imagePath string
+	var parentID int64
 
 	// Check if this is a multipart form request (has image)
 	contentType := r.Header.Get("Content-Type")
@@ -1193,6 +2898,15 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 		// Get text content (optional)
 		content = r.FormValue("content")
 
+		// Get the comment being replied to (optional)
+		if parentIDStr := r.FormValue("parent_id"); parentIDStr != "" {
+			parentID, err = strconv.ParseInt(parentIDStr, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid parent comment ID", http.StatusBadRequest)
+				return
+			}
+		}
+
 		// Handle image upload
 		file, header, err := r.FormFile("image")
 		if err != nil && err != http.ErrMissingFile {
@@ -1209,6 +2923,7 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 				"image/jpg":  true,
 				"image/png":  true,
 				"image/gif":  true,
+				"image/webp": true,
 			}
 
 			// Get file type
@@ -1219,9 +2934,9 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			fileType := http.DetectContentType(fileHeader)
+			fileType := detectImageType(fileHeader)
 			if !allowedTypes[fileType] {
-				http.Error(w, "Invalid file type. Only JPEG, PNG, and GIF are allowed", http.StatusBadRequest)
+				http.Error(w, "Invalid file type. Only "+supportedImageFormats+" are allowed", http.StatusBadRequest)
 				return
 			}
 
@@ -1268,7 +2983,8 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Handle JSON request
 		var requestData struct {
-			Content string `json:"content"`
+			Content  string `json:"content"`
+			ParentID int64  `json:"parent_id"` // Optional comment being replied to
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -1277,6 +2993,7 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 		}
 
 		content = requestData.Content
+		parentID = requestData.ParentID
 	}
 
 	// Validate that we have either content or an image
@@ -1287,7 +3004,7 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 
 	// Check if post exists
 	post, err := db.GetGroupPost(postID, int64(userID))
-	if err != nil || post == nil {
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
 		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
@@ -1300,6 +3017,21 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 		ImagePath: imagePath,
 	}
 
+	// Replies must belong to the same post. Threads are one level deep,
+	// so replying to a reply attaches to its top-level comment
+	if parentID > 0 {
+		parent, err := db.GetGroupPostComment(parentID, int64(userID))
+		if err != nil || parent == nil || parent.PostID != postID {
+			http.Error(w, "Parent comment not found on this post", http.StatusBadRequest)
+			return
+		}
+
+		if parent.ParentCommentID != nil {
+			parentID = *parent.ParentCommentID
+		}
+		comment.ParentCommentID = &parentID
+	}
+
 	commentID, err := db.CreateGroupPostComment(comment)
 	if err != nil {
 		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
@@ -1313,14 +3045,20 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Notify mentioned users who are members of the group
+	createdComment.Mentions = notifyMentions(userID, content, func(viewerID int) bool {
+		return db.IsGroupMember(post.GroupID, int64(viewerID))
+	}, "comment", groupMentionNotification, postID)
+
 	// Send WebSocket notification to group members about new comment
 	go func() {
 		notificationMessage := map[string]interface{}{
-			"type":       "comment_created",
-			"comment_id": commentID,
-			"post_id":    postID,
-			"group_id":   post.GroupID,
-			"created_by": userID,
+			"type":              "comment_created",
+			"comment_id":        commentID,
+			"post_id":           postID,
+			"group_id":          post.GroupID,
+			"created_by":        userID,
+			"parent_comment_id": comment.ParentCommentID,
 		}
 
 		if err := broadcastToGroupMembers(post.GroupID, notificationMessage); err != nil {
@@ -1328,6 +3066,8 @@ func CreateGroupPostComment(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	notifyGroupPostAuthor(post, userID, "group_post_comment", "commented on your group post")
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdComment)
@@ -1361,6 +3101,58 @@ func GetGroupPostComments(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// MarkGroupPostRead records that the user has seen all current comments on a group post
+func MarkGroupPostRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postIDStr := vars["postId"]
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(post.GroupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	lastReadCommentID, err := db.MarkGroupPostRead(postID, int64(userID))
+	if err != nil {
+		log.Printf("Error marking group post as read: %v", err)
+		http.Error(w, "Failed to mark post as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":              "Post marked as read",
+		"post_id":              postID,
+		"last_read_comment_id": lastReadCommentID,
+	})
+}
+
+// eventPastGracePeriod is how far in the past an event may start, to allow for clock skew
+// between the client and the server
+const eventPastGracePeriod = 5 * time.Minute
+
+// isEventDateInPast reports whether an event start time is too far in the past to be scheduled
+func isEventDateInPast(eventDate time.Time) bool {
+	return eventDate.Before(time.Now().Add(-eventPastGracePeriod))
+}
+
 // CreateGroupEvent creates a new event in a group
 func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
@@ -1384,10 +3176,14 @@ func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var requestData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Date        string `json:"date"`
-		Time        string `json:"time"`
+		Title         string `json:"title"`
+		Description   string `json:"description"`
+		Date          string `json:"date"`
+		Time          string `json:"time"`
+		Recurrence    string `json:"recurrence"`     // "none", "daily", "weekly" or "monthly"
+		RecurrenceEnd string `json:"recurrence_end"` // Optional last date of a recurring event
+		Location      string `json:"location"`
+		Timezone      string `json:"timezone"` // IANA name, e.g. "Asia/Bahrain", defaults to UTC
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -1400,21 +3196,70 @@ func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse date and time
+	if requestData.Timezone == "" {
+		requestData.Timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(requestData.Timezone)
+	if err != nil {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return
+	}
+
+	// Parse date and time in the event's timezone
 	dateTimeStr := requestData.Date + " " + requestData.Time
-	eventDate, err := time.Parse("2006-01-02 15:04", dateTimeStr)
+	eventDate, err := time.ParseInLocation("2006-01-02 15:04", dateTimeStr, location)
 	if err != nil {
 		http.Error(w, "Invalid date/time format", http.StatusBadRequest)
 		return
 	}
 
+	if isEventDateInPast(eventDate) {
+		http.Error(w, "Event date and time must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.Recurrence == "" {
+		requestData.Recurrence = "none"
+	}
+
+	if requestData.Recurrence != "none" && requestData.Recurrence != "daily" &&
+		requestData.Recurrence != "weekly" && requestData.Recurrence != "monthly" {
+		http.Error(w, "Recurrence must be 'none', 'daily', 'weekly', or 'monthly'", http.StatusBadRequest)
+		return
+	}
+
+	var recurrenceEnd *time.Time
+	if requestData.Recurrence != "none" && requestData.RecurrenceEnd != "" {
+		parsedEnd, err := time.Parse("2006-01-02", requestData.RecurrenceEnd)
+		if err != nil {
+			http.Error(w, "Invalid recurrence end date format", http.StatusBadRequest)
+			return
+		}
+
+		localDay := time.Date(eventDate.Year(), eventDate.Month(), eventDate.Day(), 0, 0, 0, 0, time.UTC)
+		if parsedEnd.Before(localDay) {
+			http.Error(w, "Recurrence end date must not be before the event date", http.StatusBadRequest)
+			return
+		}
+		if parsedEnd.After(localDay.AddDate(sqlite.MaxRecurrenceYears, 0, 0)) {
+			http.Error(w, fmt.Sprintf("Recurrence end date must be within %d years of the event date", sqlite.MaxRecurrenceYears), http.StatusBadRequest)
+			return
+		}
+		recurrenceEnd = &parsedEnd
+	}
+
 	// Create event
 	event := &sqlite.GroupEvent{
-		GroupID:     groupID,
-		CreatorID:   int64(userID),
-		Title:       requestData.Title,
-		Description: requestData.Description,
-		EventDate:   eventDate,
+		GroupID:       groupID,
+		CreatorID:     int64(userID),
+		Title:         requestData.Title,
+		Description:   requestData.Description,
+		EventDate:     eventDate.UTC(),
+		Recurrence:    requestData.Recurrence,
+		RecurrenceEnd: recurrenceEnd,
+		Location:      strings.TrimSpace(requestData.Location),
+		Timezone:      location.String(),
 	}
 
 	eventID, err := db.CreateGroupEvent(event)
@@ -1454,14 +3299,24 @@ func CreateGroupEvent(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		content := fmt.Sprintf("%s %s created a new event \"%s\" in %s", creator["first_name"], creator["last_name"], requestData.Title, group.Name)
+		if event.Location != "" {
+			content += fmt.Sprintf(" at %s", event.Location)
+		}
+
 		// Send notification to all group members except the creator
 		for _, member := range members {
 			if member.UserID != int64(userID) { // Don't notify the creator
+				// Members who muted the group or turned off event notifications still get the WebSocket broadcast below
+				if db.IsGroupMuted(groupID, member.UserID) || !db.ShouldNotify(member.UserID, sqlite.NotificationCategoryEvents) {
+					continue
+				}
+
 				notification := &sqlite.Notification{
 					ReceiverID:  member.UserID,
 					SenderID:    int64(userID),
 					Type:        "event_created",
-					Content:     fmt.Sprintf("%s %s created a new event \"%s\" in %s", creator["first_name"], creator["last_name"], requestData.Title, group.Name),
+					Content:     content,
 					ReferenceID: eventID,
 					IsRead:      false,
 				}
@@ -1544,7 +3399,8 @@ func RespondToGroupEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var requestData struct {
-		Response string `json:"response"` // "going" or "not_going"
+		Response       string `json:"response"`        // "going", "not_going", "maybe" or "remove"
+		OccurrenceDate string `json:"occurrence_date"` // Required for recurring events (YYYY-MM-DD)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -1552,8 +3408,8 @@ func RespondToGroupEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestData.Response != "going" && requestData.Response != "not_going" && requestData.Response != "remove" {
-		http.Error(w, "Response must be 'going', 'not_going', or 'remove'", http.StatusBadRequest)
+	if !sqlite.EventResponses[requestData.Response] && requestData.Response != "remove" {
+		http.Error(w, "Response must be 'going', 'not_going', 'maybe', or 'remove'", http.StatusBadRequest)
 		return
 	}
 
@@ -1564,25 +3420,286 @@ func RespondToGroupEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Responses to recurring events belong to a single occurrence
+	occurrenceDate := ""
+	if event.IsRecurring() {
+		if requestData.OccurrenceDate == "" {
+			http.Error(w, "Occurrence date is required for recurring events", http.StatusBadRequest)
+			return
+		}
+
+		exceptions, err := db.GetEventExceptions(eventID)
+		if err != nil {
+			http.Error(w, "Failed to respond to event", http.StatusInternalServerError)
+			return
+		}
+
+		if !event.OccursOn(requestData.OccurrenceDate) || exceptions[requestData.OccurrenceDate] {
+			http.Error(w, "Event does not occur on the given date", http.StatusBadRequest)
+			return
+		}
+		occurrenceDate = requestData.OccurrenceDate
+	}
+
 	// Respond to event
-	err = db.RespondToEvent(eventID, int64(userID), requestData.Response)
+	err = db.RespondToEvent(eventID, int64(userID), requestData.Response, occurrenceDate)
 	if err != nil {
 		http.Error(w, "Failed to respond to event", http.StatusInternalServerError)
 		return
 	}
 
-	// Get updated event
-	event, err = db.GetGroupEvent(eventID, int64(userID))
-	if err != nil {
-		http.Error(w, "Failed to get updated event", http.StatusInternalServerError)
-		return
-	}
+	// Get updated event
+	if occurrenceDate != "" {
+		event, err = db.GetGroupEventOccurrence(eventID, int64(userID), occurrenceDate)
+	} else {
+		event, err = db.GetGroupEvent(eventID, int64(userID))
+	}
+	if err != nil {
+		http.Error(w, "Failed to get updated event", http.StatusInternalServerError)
+		return
+	}
+
+	// Send the new attendee counts to group members so they update live
+	go func() {
+		notificationMessage := map[string]interface{}{
+			"type":            "event_response_updated",
+			"event_id":        eventID,
+			"group_id":        event.GroupID,
+			"occurrence_date": occurrenceDate,
+			"user_id":         userID,
+			"going":           event.GoingCount,
+			"not_going":       event.NotGoingCount,
+			"maybe":           event.MaybeCount,
+		}
+
+		if err := broadcastToGroupMembers(event.GroupID, notificationMessage); err != nil {
+			log.Printf("Error broadcasting event response update: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
+
+// GetGroupEvent returns a single event with its response counts and the caller's own response.
+// For recurring events ?occurrence_date=YYYY-MM-DD selects the occurrence, defaulting to the next one
+func GetGroupEvent(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventIDStr := vars["eventId"]
+	eventID, err := strconv.ParseInt(eventIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	event, err := db.GetGroupEvent(eventID, int64(userID))
+	if err != nil || event == nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(event.GroupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	if event.IsRecurring() {
+		if requested := r.URL.Query().Get("occurrence_date"); requested != "" {
+			if !event.OccursOn(requested) {
+				http.Error(w, "Event does not occur on the given date", http.StatusBadRequest)
+				return
+			}
+			event, err = db.GetGroupEventOccurrence(eventID, int64(userID), requested)
+		} else {
+			event, err = db.GetNextGroupEventOccurrence(eventID, int64(userID))
+		}
+		if err != nil || event == nil {
+			log.Printf("Error getting event occurrence: %v", err)
+			http.Error(w, "Failed to get event", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Counts are sent explicitly since the event fields omit zero values
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event": event,
+		"counts": map[string]int{
+			"going":     event.GoingCount,
+			"not_going": event.NotGoingCount,
+			"maybe":     event.MaybeCount,
+		},
+		"user_response": event.UserResponse,
+	})
+}
+
+// GetGroupEventAttendees lists the users who responded to an event, grouped by response.
+// For recurring events ?occurrence_date=YYYY-MM-DD selects the occurrence, defaulting to the next one
+func GetGroupEventAttendees(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventIDStr := vars["eventId"]
+	eventID, err := strconv.ParseInt(eventIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	event, err := db.GetGroupEvent(eventID, int64(userID))
+	if err != nil || event == nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(event.GroupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	occurrenceDate := ""
+	if event.IsRecurring() {
+		if requested := r.URL.Query().Get("occurrence_date"); requested != "" {
+			if !event.OccursOn(requested) {
+				http.Error(w, "Event does not occur on the given date", http.StatusBadRequest)
+				return
+			}
+			occurrenceDate = requested
+		} else {
+			next, err := db.GetNextGroupEventOccurrence(eventID, int64(userID))
+			if err != nil || next == nil {
+				log.Printf("Error getting next event occurrence: %v", err)
+				http.Error(w, "Failed to get event attendees", http.StatusInternalServerError)
+				return
+			}
+			occurrenceDate = next.OccurrenceDate
+		}
+	}
+
+	responses, err := db.GetEventResponses(eventID, occurrenceDate)
+	if err != nil {
+		log.Printf("Error getting event responses: %v", err)
+		http.Error(w, "Failed to get event attendees", http.StatusInternalServerError)
+		return
+	}
+
+	attendees := map[string][]*sqlite.GroupEventResponse{
+		"going":     {},
+		"not_going": {},
+		"maybe":     {},
+	}
+	for _, response := range responses {
+		attendees[response.Response] = append(attendees[response.Response], response)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event_id":        eventID,
+		"occurrence_date": occurrenceDate,
+		"going":           attendees["going"],
+		"not_going":       attendees["not_going"],
+		"maybe":           attendees["maybe"],
+	})
+}
+
+// UpdateGroupEventFeature features or unfeatures an event based on the request body
+// (group creator or admin only). A group has at most one featured event
+func UpdateGroupEventFeature(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventIDStr := vars["eventId"]
+	eventID, err := strconv.ParseInt(eventIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestData struct {
+		Featured bool `json:"featured"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event, err := db.GetGroupEvent(eventID, int64(userID))
+	if err != nil {
+		http.Error(w, "Failed to get event", http.StatusInternalServerError)
+		return
+	}
+	if event == nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	group, err := db.GetGroup(event.GroupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(event.GroupID, int64(userID)) {
+		http.Error(w, "Only group admins can feature events", http.StatusForbidden)
+		return
+	}
+
+	err = db.SetGroupEventFeatured(eventID, requestData.Featured)
+	if err != nil {
+		if err.Error() == "event not found" {
+			http.Error(w, "Event not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating featured event: %v", err)
+		http.Error(w, "Failed to update featured event", http.StatusInternalServerError)
+		return
+	}
+
+	updatedEvent, err := db.GetGroupEvent(eventID, int64(userID))
+	if err != nil || updatedEvent == nil {
+		http.Error(w, "Failed to retrieve updated event", http.StatusInternalServerError)
+		return
+	}
+
+	// Let members' UIs move the highlight
+	go func() {
+		notificationMessage := map[string]interface{}{
+			"type":       "event_featured",
+			"event_id":   eventID,
+			"group_id":   event.GroupID,
+			"featured":   requestData.Featured,
+			"updated_by": userID,
+		}
+
+		if err := broadcastToGroupMembers(event.GroupID, notificationMessage); err != nil {
+			log.Printf("Error broadcasting featured event: %v", err)
+		}
+	}()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(event)
+	json.NewEncoder(w).Encode(updatedEvent)
 }
 
-// DeleteGroupEvent deletes an event (creator or group admin only)
+// DeleteGroupEvent deletes an event (creator or group admin only).
+// For recurring events, ?scope=occurrence&date=YYYY-MM-DD removes a single
+// occurrence while the default scope=series removes the whole series
 func DeleteGroupEvent(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
@@ -1620,14 +3737,50 @@ func DeleteGroupEvent(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if group.CreatorID != int64(userID) {
+		if group.CreatorID != int64(userID) && !db.IsGroupAdmin(event.GroupID, int64(userID)) {
 			http.Error(w, "Only event creator or group admin can delete events", http.StatusForbidden)
 			return
 		}
 	}
 
-	// Delete the event
-	err = db.DeleteGroupEvent(eventID)
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		scope = "series"
+	}
+
+	if scope != "series" && scope != "occurrence" {
+		http.Error(w, "Scope must be 'series' or 'occurrence'", http.StatusBadRequest)
+		return
+	}
+
+	occurrenceDate := ""
+	if scope == "occurrence" {
+		if !event.IsRecurring() {
+			http.Error(w, "Only recurring events can delete a single occurrence", http.StatusBadRequest)
+			return
+		}
+
+		occurrenceDate = r.URL.Query().Get("date")
+		if !event.OccursOn(occurrenceDate) {
+			http.Error(w, "Event does not occur on the given date", http.StatusBadRequest)
+			return
+		}
+
+		exceptions, err := db.GetEventExceptions(eventID)
+		if err != nil {
+			http.Error(w, "Failed to delete event", http.StatusInternalServerError)
+			return
+		}
+		if exceptions[occurrenceDate] {
+			http.Error(w, "Event occurrence not found", http.StatusNotFound)
+			return
+		}
+
+		err = db.DeleteGroupEventOccurrence(eventID, occurrenceDate)
+	} else {
+		// Delete the event
+		err = db.DeleteGroupEvent(eventID)
+	}
 	if err != nil {
 		http.Error(w, "Failed to delete event", http.StatusInternalServerError)
 		return
@@ -1640,6 +3793,10 @@ func DeleteGroupEvent(w http.ResponseWriter, r *http.Request) {
 			"event_id":   eventID,
 			"group_id":   event.GroupID,
 			"deleted_by": userID,
+			"scope":      scope,
+		}
+		if occurrenceDate != "" {
+			notificationMessage["occurrence_date"] = occurrenceDate
 		}
 
 		if err := broadcastToGroupMembers(event.GroupID, notificationMessage); err != nil {
@@ -1653,7 +3810,7 @@ func DeleteGroupEvent(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetGroupMembers retrieves all members of a group
+// GetGroupMembers retrieves all members of a group, optionally filtered by role
 func GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
@@ -1675,7 +3832,19 @@ func GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members, err := db.GetGroupMembersWithPending(groupID)
+	// ?role=admin|member lists only confirmed members with that role
+	role := r.URL.Query().Get("role")
+	if role != "" && role != "admin" && role != "member" {
+		http.Error(w, "Role must be either admin or member", http.StatusBadRequest)
+		return
+	}
+
+	var members []*sqlite.GroupMember
+	if role != "" {
+		members, err = db.GetGroupMembersByRole(groupID, role)
+	} else {
+		members, err = db.GetGroupMembersWithPending(groupID)
+	}
 	if err != nil {
 		http.Error(w, "Failed to get group members", http.StatusInternalServerError)
 		return
@@ -1687,6 +3856,46 @@ func GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SearchGroupMembers finds members of a group by name or nickname (members only)
+func SearchGroupMembers(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	members, err := db.SearchGroupMembers(groupID, query)
+	if err != nil {
+		log.Printf("Error searching group members: %v", err)
+		http.Error(w, "Failed to search group members", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"members": members,
+	})
+}
+
 // AddGroupMember adds a member to a group (creator only)
 func AddGroupMember(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
@@ -1747,6 +3956,11 @@ func AddGroupMember(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if db.IsBannedFromGroup(groupID, memberID) {
+			http.Error(w, "A selected user is banned from the group", http.StatusForbidden)
+			return
+		}
+
 		// Check if user is already a member
 		if db.IsGroupMember(groupID, memberID) {
 			http.Error(w, "User is already a member", http.StatusConflict)
@@ -1754,6 +3968,11 @@ func AddGroupMember(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Private groups send invitations, capacity is checked when they are accepted
+	if group.Privacy != "private" && !checkGroupCapacity(w, group, len(userIDsToAdd)) {
+		return
+	}
+
 	// Get inviter information for notifications
 	inviter, err := db.GetUserById(int(userID))
 	if err != nil {
@@ -1802,8 +4021,7 @@ func AddGroupMember(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// Send real-time notification
-			SendGroupNotification(memberID, int64(userID), "group_invitation",
-				inviterName+" invited you to join "+group.Name, groupID)
+			SendGroupInvitation(memberID, int64(userID), invitationID, groupID, group.Name, inviterName)
 
 			sentInvitations = append(sentInvitations, memberID)
 			log.Printf("Successfully sent invitation %d to user %d for private group", invitationID, memberID)
@@ -1836,46 +4054,293 @@ func AddGroupMember(w http.ResponseWriter, r *http.Request) {
 				IsRead:      false,
 			}
 
-			_, err = db.CreateNotification(notification)
-			if err != nil {
-				log.Printf("Warning: Could not create group addition notification: %v", err)
-			}
+			if db.ShouldNotify(memberID, sqlite.NotificationCategoryGroupInvites) {
+				_, err = db.CreateNotification(notification)
+				if err != nil {
+					log.Printf("Warning: Could not create group addition notification: %v", err)
+				}
+			}
+
+			// Send real-time notification
+			SendGroupNotification(memberID, int64(userID), "group_member_added",
+				notificationContent, groupID)
+
+			addedMembers = append(addedMembers, memberID)
+		}
+	}
+
+	// Create appropriate response message
+	var message string
+	if group.Privacy == "private" {
+		if len(sentInvitations) > 0 {
+			message = fmt.Sprintf("Invitations sent to %d user(s)", len(sentInvitations))
+		} else {
+			message = "No invitations were sent"
+		}
+	} else {
+		if len(addedMembers) > 0 {
+			message = fmt.Sprintf("%d member(s) added successfully", len(addedMembers))
+		} else {
+			message = "No members were added"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          message,
+		"group_privacy":    group.Privacy,
+		"added_members":    addedMembers,
+		"sent_invitations": sentInvitations,
+	})
+}
+
+// RemoveGroupMember removes a member from a group (admin/creator only)
+func RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["groupId"]
+	memberIDStr := vars["memberId"]
+
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get group to check permissions
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is the group creator or an admin
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can remove members", http.StatusForbidden)
+		return
+	}
+
+	// Cannot remove the creator
+	if memberID == group.CreatorID {
+		http.Error(w, "Cannot remove group creator", http.StatusBadRequest)
+		return
+	}
+
+	// Check if target user is actually a member
+	if !db.IsGroupMember(groupID, memberID) {
+		http.Error(w, "User is not a member of this group", http.StatusBadRequest)
+		return
+	}
+
+	// Remove member from group
+	err = db.RemoveGroupMember(groupID, memberID)
+	if err != nil {
+		log.Printf("Error removing group member: %v", err)
+		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		return
+	}
+
+	// Remove member from group chat conversation
+	err = db.RemoveMemberFromGroupConversation(groupID, memberID)
+	if err != nil {
+		log.Printf("Error removing member from group conversation: %v", err)
+		// Don't fail if chat removal fails
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Member removed successfully",
+	})
+}
+
+// BanGroupMember removes a member from a group and bans them from rejoining (admin/creator only).
+// The request body may include an optional reason
+func BanGroupMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["groupId"]
+	memberIDStr := vars["memberId"]
+
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestData struct {
+		Reason string `json:"reason"`
+	}
+
+	// The body is optional
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Get group to check permissions
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if user is the group creator or an admin
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can ban members", http.StatusForbidden)
+		return
+	}
+
+	// Cannot ban the creator or yourself
+	if memberID == group.CreatorID {
+		http.Error(w, "Cannot ban group creator", http.StatusBadRequest)
+		return
+	}
+
+	if memberID == int64(userID) {
+		http.Error(w, "Cannot ban yourself", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := db.GetUserById(int(memberID)); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	wasMember := db.IsGroupMember(groupID, memberID)
+
+	err = db.BanGroupMember(groupID, memberID, int64(userID), strings.TrimSpace(requestData.Reason))
+	if err != nil {
+		log.Printf("Error banning group member: %v", err)
+		http.Error(w, "Failed to ban member", http.StatusInternalServerError)
+		return
+	}
+
+	// Remove member from group chat conversation
+	if wasMember {
+		err = db.RemoveMemberFromGroupConversation(groupID, memberID)
+		if err != nil {
+			log.Printf("Error removing member from group conversation: %v", err)
+			// Don't fail if chat removal fails
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Member banned successfully",
+	})
+}
+
+// UnbanGroupMember lifts a member's ban so they can join the group again (admin/creator only)
+func UnbanGroupMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["groupId"]
+	memberIDStr := vars["memberId"]
+
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid member ID", http.StatusBadRequest)
+		return
+	}
 
-			// Send real-time notification
-			SendGroupNotification(memberID, int64(userID), "group_member_added",
-				notificationContent, groupID)
+	// Get group to check permissions
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
 
-			addedMembers = append(addedMembers, memberID)
-		}
+	// Check if user is the group creator or an admin
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can lift bans", http.StatusForbidden)
+		return
 	}
 
-	// Create appropriate response message
-	var message string
-	if group.Privacy == "private" {
-		if len(sentInvitations) > 0 {
-			message = fmt.Sprintf("Invitations sent to %d user(s)", len(sentInvitations))
-		} else {
-			message = "No invitations were sent"
-		}
-	} else {
-		if len(addedMembers) > 0 {
-			message = fmt.Sprintf("%d member(s) added successfully", len(addedMembers))
-		} else {
-			message = "No members were added"
+	err = db.UnbanGroupMember(groupID, memberID)
+	if err != nil {
+		if err.Error() == "ban not found" {
+			http.Error(w, "User is not banned from this group", http.StatusNotFound)
+			return
 		}
+		log.Printf("Error lifting group ban: %v", err)
+		http.Error(w, "Failed to lift ban", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":          message,
-		"group_privacy":    group.Privacy,
-		"added_members":    addedMembers,
-		"sent_invitations": sentInvitations,
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Ban lifted successfully",
 	})
 }
 
-// RemoveGroupMember removes a member from a group (admin/creator only)
-func RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+// UpdateGroupMemberRole changes a member's role based on the requested role (creator only)
+func UpdateGroupMemberRole(w http.ResponseWriter, r *http.Request) {
+	var requestData struct {
+		Role string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch requestData.Role {
+	case "admin":
+		PromoteGroupMember(w, r)
+	case "member":
+		DemoteGroupMember(w, r)
+	default:
+		http.Error(w, "Role must be either admin or member", http.StatusBadRequest)
+	}
+}
+
+// PromoteGroupMember makes a group member an admin (creator only)
+func PromoteGroupMember(w http.ResponseWriter, r *http.Request) {
+	setGroupMemberRole(w, r, "admin")
+}
+
+// DemoteGroupMember makes a group admin a regular member (creator only)
+func DemoteGroupMember(w http.ResponseWriter, r *http.Request) {
+	setGroupMemberRole(w, r, "member")
+}
+
+// setGroupMemberRole applies a role change for the member in the request path
+func setGroupMemberRole(w http.ResponseWriter, r *http.Request, role string) {
 	userID, err := getUserIDFromSession(r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -1905,42 +4370,35 @@ func RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is the group creator (only creator can remove members)
 	if group.CreatorID != int64(userID) {
-		http.Error(w, "Only group creator can remove members", http.StatusForbidden)
+		http.Error(w, "Only group creator can change member roles", http.StatusForbidden)
 		return
 	}
 
-	// Cannot remove the creator
+	// The creator always stays an admin
 	if memberID == group.CreatorID {
-		http.Error(w, "Cannot remove group creator", http.StatusBadRequest)
+		http.Error(w, "Cannot change the group creator's role", http.StatusBadRequest)
 		return
 	}
 
-	// Check if target user is actually a member
 	if !db.IsGroupMember(groupID, memberID) {
 		http.Error(w, "User is not a member of this group", http.StatusBadRequest)
 		return
 	}
 
-	// Remove member from group
-	err = db.RemoveGroupMember(groupID, memberID)
+	err = db.UpdateGroupMemberRole(groupID, memberID, role)
 	if err != nil {
-		log.Printf("Error removing group member: %v", err)
-		http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+		log.Printf("Error updating group member role: %v", err)
+		http.Error(w, "Failed to update member role", http.StatusInternalServerError)
 		return
 	}
 
-	// Remove member from group chat conversation
-	err = db.RemoveMemberFromGroupConversation(groupID, memberID)
-	if err != nil {
-		log.Printf("Error removing member from group conversation: %v", err)
-		// Don't fail if chat removal fails
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Member removed successfully",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Member role updated successfully",
+		"group_id": groupID,
+		"user_id":  memberID,
+		"role":     role,
 	})
 }
 
@@ -2079,7 +4537,7 @@ func VoteGroupPost(w http.ResponseWriter, r *http.Request) {
 
 	// Check if post exists and user has access
 	post, err := db.GetGroupPost(postID, int64(userID))
-	if err != nil || post == nil {
+	if err != nil || post == nil || (post.IsDraft && post.AuthorID != int64(userID)) {
 		http.Error(w, "Group post not found or access denied", http.StatusNotFound)
 		return
 	}
@@ -2100,6 +4558,12 @@ func VoteGroupPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only a newly cast upvote notifies the author, not a removed vote or one switched
+	// from a downvote. post was loaded before voting, so it holds the previous vote
+	if post.UserVote == 0 && updatedPost.UserVote == 1 {
+		notifyGroupPostAuthor(post, userID, "group_post_vote", "upvoted your group post")
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message":   "Vote recorded successfully",
@@ -2109,6 +4573,56 @@ func VoteGroupPost(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// groupPostVoteNotificationWindow debounces vote notifications so toggling a vote
+// on and off doesn't send the author a notification each time
+const groupPostVoteNotificationWindow = 1 * time.Hour
+
+// notifyGroupPostAuthor tells a group post's author that someone interacted with the post.
+// Self-interactions and authors who muted the group are skipped, and vote notifications
+// are sent at most once per window.
+func notifyGroupPostAuthor(post *sqlite.GroupPost, actorID int, notificationType, action string) {
+	if post.AuthorID == int64(actorID) || db.IsGroupMuted(post.GroupID, post.AuthorID) {
+		return
+	}
+
+	if notificationType == "group_post_vote" {
+		recent, err := db.HasRecentNotification(post.AuthorID, int64(actorID), notificationType, post.ID, groupPostVoteNotificationWindow)
+		if err != nil {
+			log.Printf("Error checking recent vote notifications: %v", err)
+			return
+		}
+		if recent {
+			return
+		}
+	}
+
+	actor, err := db.GetUserById(actorID)
+	if err != nil {
+		log.Printf("Error getting user %d for group post notification: %v", actorID, err)
+		return
+	}
+	actorName := fmt.Sprintf("%s %s", actor["first_name"], actor["last_name"])
+	content := actorName + " " + action
+
+	if db.ShouldNotify(post.AuthorID, sqlite.NotificationCategoryComments) {
+		_, err = db.CreateNotification(&sqlite.Notification{
+			ReceiverID:  post.AuthorID,
+			SenderID:    int64(actorID),
+			Type:        notificationType,
+			Content:     content,
+			ReferenceID: post.ID,
+			IsRead:      false,
+		})
+		if err != nil {
+			log.Printf("Error creating %s notification for user %d: %v", notificationType, post.AuthorID, err)
+			return
+		}
+	}
+
+	// Send real-time notification
+	SendGroupNotification(post.AuthorID, int64(actorID), notificationType, content, post.ID)
+}
+
 // VoteGroupPostComment handles upvotes and downvotes on group post comments
 func VoteGroupPostComment(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserIDFromSession(r)
@@ -2275,8 +4789,8 @@ func DeleteGroupPost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Delete the post
-	err = db.DeleteGroupPost(postID)
+	// Hide the post, it is purged once the restore window has passed
+	err = db.SoftDeleteGroupPost(postID, int64(userID))
 	if err != nil {
 		log.Printf("Error deleting group post: %v", err)
 		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
@@ -2299,12 +4813,103 @@ func DeleteGroupPost(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  "Post deleted successfully",
-		"post_id":  postID,
-		"group_id": post.GroupID,
+		"message":                "Post deleted successfully",
+		"post_id":                postID,
+		"group_id":               post.GroupID,
+		"restore_window_seconds": int(sqlite.DeletedPostGracePeriod.Seconds()),
+	})
+}
+
+// RestoreGroupPost undoes a group post deletion within the restore window (only by whoever deleted it)
+func RestoreGroupPost(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postIDStr := vars["postId"]
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	err = db.RestoreGroupPost(postID, int64(userID))
+	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			http.Error(w, "Post not found", http.StatusNotFound)
+		case "restore window expired":
+			http.Error(w, "The post can no longer be restored", http.StatusGone)
+		default:
+			log.Printf("Error restoring group post: %v", err)
+			http.Error(w, "Failed to restore post", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	post, err := db.GetGroupPost(postID, int64(userID))
+	if err != nil || post == nil {
+		log.Printf("Error fetching restored group post: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// Let group members show the post again
+	go func() {
+		notificationMessage := map[string]interface{}{
+			"type":        "post_restored",
+			"post_id":     postID,
+			"group_id":    post.GroupID,
+			"restored_by": userID,
+		}
+
+		if err := broadcastToGroupMembers(post.GroupID, notificationMessage); err != nil {
+			log.Printf("Error broadcasting post restore: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Post restored successfully",
+		"post":    post,
 	})
 }
 
+// groupHasRoomFor reports whether count more members fit within the group's member limit
+func groupHasRoomFor(group *sqlite.Group, count int) (bool, error) {
+	if group.MaxMembers == nil {
+		return true, nil
+	}
+
+	members, err := db.GetGroupMembers(group.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(members)+count <= *group.MaxMembers, nil
+}
+
+// checkGroupCapacity writes a 409 response and returns false when count more members
+// don't fit in the group
+func checkGroupCapacity(w http.ResponseWriter, group *sqlite.Group, count int) bool {
+	hasRoom, err := groupHasRoomFor(group, count)
+	if err != nil {
+		log.Printf("Error checking group capacity: %v", err)
+		http.Error(w, "Failed to check group capacity", http.StatusInternalServerError)
+		return false
+	}
+
+	if !hasRoom {
+		http.Error(w, "Group is full", http.StatusConflict)
+		return false
+	}
+
+	return true
+}
+
 // broadcastToGroupMembers sends a WebSocket message to all members of a group
 func broadcastToGroupMembers(groupID int64, message map[string]interface{}) error {
 	if chatHub == nil {
@@ -2342,48 +4947,222 @@ func broadcastToGroupMembers(groupID int64, message map[string]interface{}) erro
 	return nil
 }
 
+// UpdateGroupNotificationSettings lets a member mute or unmute notifications from a group
+func UpdateGroupNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is a member of the group
+	if !db.IsGroupMember(groupID, int64(userID)) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var requestData struct {
+		Muted *bool `json:"muted"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.Muted == nil {
+		http.Error(w, "Muted is required", http.StatusBadRequest)
+		return
+	}
+
+	err = db.SetGroupMuted(groupID, int64(userID), *requestData.Muted)
+	if err != nil {
+		log.Printf("Error updating group notification settings: %v", err)
+		http.Error(w, "Failed to update notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Notification settings updated",
+		"group_id": groupID,
+		"muted":    *requestData.Muted,
+	})
+}
+
+// AnnounceToGroup posts an announcement to the group chat (admin/creator only).
+// Announcements are stored as group messages authored by the group creator and flagged
+// so clients can render them differently
+func AnnounceToGroup(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	groupIDStr := vars["id"]
+	groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := db.GetGroup(groupID)
+	if err != nil || group == nil {
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return
+	}
+
+	if group.CreatorID != int64(userID) && !db.IsGroupAdmin(groupID, int64(userID)) {
+		http.Error(w, "Only group admins can post announcements", http.StatusForbidden)
+		return
+	}
+
+	var requestData struct {
+		Content string `json:"content"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	requestData.Content = strings.TrimSpace(requestData.Content)
+	if requestData.Content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	message := &sqlite.GroupMessage{
+		GroupID:        groupID,
+		SenderID:       group.CreatorID,
+		Content:        requestData.Content,
+		IsAnnouncement: true,
+	}
+
+	messageID, err := db.CreateGroupMessage(message)
+	if err != nil {
+		log.Printf("Error creating group announcement: %v", err)
+		http.Error(w, "Failed to post announcement", http.StatusInternalServerError)
+		return
+	}
+
+	createdMessage, err := db.GetGroupMessage(messageID)
+	if err != nil || createdMessage == nil {
+		log.Printf("Error fetching group announcement %d: %v", messageID, err)
+		http.Error(w, "Failed to post announcement", http.StatusInternalServerError)
+		return
+	}
+
+	// Send WebSocket notification to group members about the announcement
+	go func() {
+		notificationMessage := map[string]interface{}{
+			"type":            "group_announcement",
+			"message_id":      createdMessage.ID,
+			"group_id":        groupID,
+			"sender_id":       createdMessage.SenderID,
+			"content":         createdMessage.Content,
+			"is_announcement": true,
+			"created_at":      createdMessage.CreatedAt,
+			"announced_by":    userID,
+		}
+
+		if err := broadcastToGroupMembers(groupID, notificationMessage); err != nil {
+			log.Printf("Error broadcasting group announcement: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdMessage)
+}
+
 // RegisterGroupRoutes registers all group-related routes
 func RegisterGroupRoutes(router *mux.Router) {
 	// Group management
 	router.HandleFunc("/groups", GetGroups).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups", CreateGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/mine", GetMyGroups).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/suggested", GetSuggestedGroups).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/popular", GetPopularGroups).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{id}", GetGroup).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/{id}", UpdateGroup).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/{id}/avatar", UpdateGroupAvatar).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/{id}/activity", GetGroupActivity).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/{id}/status", GetGroupMembershipStatus).Methods("GET", "OPTIONS")
 
 	// Group membership
 	router.HandleFunc("/groups/{id}/join", JoinGroup).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/{id}/leave", LeaveGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/transfer", TransferGroupOwnership).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/notifications", UpdateGroupNotificationSettings).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/{id}/announce", AnnounceToGroup).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/{id}/members", GetGroupMembers).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{id}/members", AddGroupMember).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/members/search", SearchGroupMembers).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{groupId}/members/{memberId}", RemoveGroupMember).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/groups/{groupId}/members/{memberId}/role", UpdateGroupMemberRole).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/{groupId}/ban/{memberId}", BanGroupMember).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{groupId}/ban/{memberId}", UnbanGroupMember).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/groups/{id}", DeleteGroup).Methods("DELETE", "OPTIONS")
 
 	// Group invitations
 	router.HandleFunc("/groups/{id}/invite", InviteToGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/invite/bulk", BulkInviteToGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/invitations", GetGroupInvitations).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/{groupId}/invitations/{invitationId}", CancelGroupInvitation).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/invitations", GetUserInvitations).Methods("GET", "OPTIONS")
 	router.HandleFunc("/invitations/{id}/accept", AcceptInvitation).Methods("POST", "OPTIONS")
 	router.HandleFunc("/invitations/{id}/reject", RejectInvitation).Methods("POST", "OPTIONS")
 
 	// Join requests
 	router.HandleFunc("/groups/{id}/request", RequestToJoinGroup).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/request", CancelJoinRequest).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/groups/{id}/reports", GetGroupReports).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{id}/requests", GetGroupJoinRequests).Methods("GET", "OPTIONS")
 	router.HandleFunc("/requests/{id}/accept", AcceptJoinRequest).Methods("POST", "OPTIONS")
 	router.HandleFunc("/requests/{id}/reject", RejectJoinRequest).Methods("POST", "OPTIONS")
+	router.HandleFunc("/me/join-requests", GetMyJoinRequests).Methods("GET", "OPTIONS")
 
 	// Group posts
 	router.HandleFunc("/groups/{id}/posts", GetGroupPosts).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{id}/posts", CreateGroupPost).Methods("POST", "OPTIONS")
-	router.HandleFunc("/groups/posts/{postId}/like", LikeGroupPost).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/{id}/posts/search", SearchGroupPosts).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/{id}/posts/drafts", GetGroupPostDrafts).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/like", ReactGroupPost).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/react", ReactGroupPost).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}/vote", VoteGroupPost).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/read", MarkGroupPostRead).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/publish", PublishGroupPost).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}/comments", GetGroupPostComments).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}/comments", CreateGroupPostComment).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}/comments/{commentId}/vote", VoteGroupPostComment).Methods("POST", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}/comments/{commentId}", DeleteGroupPostComment).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}", EditGroupPost).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/pin", UpdateGroupPostPin).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/image", RemoveGroupPostImage).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/groups/posts/{postId}", DeleteGroupPost).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/groups/posts/{postId}/restore", RestoreGroupPost).Methods("POST", "OPTIONS")
 
 	// Group events
 	router.HandleFunc("/groups/{id}/events", GetGroupEvents).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/{id}/events", CreateGroupEvent).Methods("POST", "OPTIONS")
+	router.HandleFunc("/me/events/upcoming", GetMyUpcomingEvents).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/events/{eventId}/respond", RespondToGroupEvent).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/events/{eventId}/attendees", GetGroupEventAttendees).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/events/{eventId}/posts", GetGroupEventPosts).Methods("GET", "OPTIONS")
+	router.HandleFunc("/groups/events/{eventId}/posts", CreateGroupPost).Methods("POST", "OPTIONS")
+	router.HandleFunc("/groups/events/{eventId}/feature", UpdateGroupEventFeature).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/groups/events/{eventId}", GetGroupEvent).Methods("GET", "OPTIONS")
 	router.HandleFunc("/groups/events/{eventId}", DeleteGroupEvent).Methods("DELETE", "OPTIONS")
 }
 
@@ -3,9 +3,16 @@ package handlers
 import (
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"s-network/backend/pkg/utils"
+
+	"github.com/google/uuid"
 )
 
 // ValidImageFormats defines the allowed image formats
@@ -13,9 +20,39 @@ var ValidImageFormats = map[string][]byte{
 	"image/jpeg": {0xFF, 0xD8, 0xFF},
 	"image/png":  {0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A},
 	"image/gif":  {0x47, 0x49, 0x46},
+	"image/webp": {0x52, 0x49, 0x46, 0x46}, // "RIFF", followed by the size and "WEBP"
+}
+
+// imageExtensions maps the allowed image MIME types to the extension used for saved files
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// supportedImageFormats is shown to users when an image is rejected
+const supportedImageFormats = "JPEG, PNG, GIF, and WebP"
+
+// imageExtension returns the file extension for an allowed image MIME type, or "" if the type isn't allowed
+func imageExtension(mimeType string) string {
+	return imageExtensions[mimeType]
+}
+
+// isWebP checks for the RIFF container header with the WEBP form type
+func isWebP(buffer []byte) bool {
+	return len(buffer) >= 12 && string(buffer[0:4]) == "RIFF" && string(buffer[8:12]) == "WEBP"
+}
+
+// detectImageType detects the MIME type from the file's first bytes
+func detectImageType(buffer []byte) string {
+	if isWebP(buffer) {
+		return "image/webp"
+	}
+	return http.DetectContentType(buffer)
 }
 
-// ValidateImageFile validates if the uploaded file is a valid JPEG, PNG, or GIF image
+// ValidateImageFile validates if the uploaded file is a valid JPEG, PNG, GIF, or WebP image
 func ValidateImageFile(file multipart.File, header *multipart.FileHeader) error {
 	// Check if filename is provided
 	if header.Filename == "" {
@@ -27,10 +64,11 @@ func ValidateImageFile(file multipart.File, header *multipart.FileHeader) error
 	hasValidExtension := strings.HasSuffix(filename, ".jpg") || 
 	                   strings.HasSuffix(filename, ".jpeg") || 
 	                   strings.HasSuffix(filename, ".png") || 
-	                   strings.HasSuffix(filename, ".gif")
+	                   strings.HasSuffix(filename, ".gif") ||
+	                   strings.HasSuffix(filename, ".webp")
 	
 	if !hasValidExtension {
-		return fmt.Errorf("invalid file extension. Only JPEG (.jpg, .jpeg), PNG (.png), and GIF (.gif) files are allowed. Got: %s", filename)
+		return fmt.Errorf("invalid file extension. Only JPEG (.jpg, .jpeg), PNG (.png), GIF (.gif), and WebP (.webp) files are allowed. Got: %s", filename)
 	}
 
 	// Check file size
@@ -59,20 +97,11 @@ func ValidateImageFile(file multipart.File, header *multipart.FileHeader) error
 	}
 
 	// Detect MIME type
-	contentType := http.DetectContentType(buffer[:n])
+	contentType := detectImageType(buffer[:n])
 	
 	// Check if content type is allowed
-	allowedTypes := []string{"image/jpeg", "image/png", "image/gif"}
-	isValidType := false
-	for _, validType := range allowedTypes {
-		if contentType == validType {
-			isValidType = true
-			break
-		}
-	}
-	
-	if !isValidType {
-		return fmt.Errorf("invalid file type: %s. Only JPEG, PNG, and GIF images are allowed", contentType)
+	if imageExtension(contentType) == "" {
+		return fmt.Errorf("invalid file type: %s. Only %s images are allowed", contentType, supportedImageFormats)
 	}
 
 	// Additional validation: check file signature (magic bytes) only for known types
@@ -108,10 +137,89 @@ func GetImageMimeType(file multipart.File) (string, error) {
 	}
 
 	// Detect MIME type
-	contentType := http.DetectContentType(buffer[:n])
+	contentType := detectImageType(buffer[:n])
 	
 	// Reset file pointer back to beginning
 	file.Seek(0, 0)
 
 	return contentType, nil
-} 
\ No newline at end of file
+}
+
+// uploadedImage is an image stored by saveUploadedImage
+type uploadedImage struct {
+	URL      string
+	Dir      string
+	Filename string
+	MimeType string
+}
+
+// saveUploadedImage validates the image sent in a form field and stores it in the given
+// uploads subdirectory under a random name with the extension of its detected type.
+// It returns nil if no file was sent. On failure the error is meant for the client and
+// comes with the HTTP status to send it with.
+func saveUploadedImage(r *http.Request, field, dir string) (*uploadedImage, int, error) {
+	file, header, err := r.FormFile(field)
+	if err != nil || header.Filename == "" || header.Size == 0 {
+		return nil, 0, nil
+	}
+	defer file.Close()
+
+	// Validate image file format (JPEG, PNG, GIF, WebP only) and size
+	if err := ValidateImageFile(file, header); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid image file: %v", err)
+	}
+	if err := ValidateImageDimensions(file); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid image file: %v", err)
+	}
+
+	mimeType, err := GetImageMimeType(file)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Failed to determine image type")
+	}
+
+	// Use the detected type for the extension rather than the client's filename
+	ext := imageExtension(mimeType)
+	if ext == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("Unsupported image format. Only %s images are allowed", supportedImageFormats)
+	}
+
+	uploadsDir := utils.GetUploadSubdir(dir)
+	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to create upload directory")
+	}
+
+	filename := uuid.New().String() + ext
+	fullPath := filepath.Join(uploadsDir, filename)
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to save image")
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, file); err != nil {
+		os.Remove(fullPath)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to save image")
+	}
+
+	return &uploadedImage{
+		URL:      utils.GetUploadURL(filename, dir),
+		Dir:      uploadsDir,
+		Filename: filename,
+		MimeType: mimeType,
+	}, 0, nil
+}
+
+// thumbnailURL creates a smaller copy of the image for feeds and returns its URL in the
+// given uploads subdirectory, falling back to the original if that isn't possible
+func (img *uploadedImage) thumbnailURL(dir string) string {
+	thumbFilename, err := CreateThumbnail(img.Dir, img.Filename, img.MimeType)
+	if err != nil {
+		log.Printf("Error creating thumbnail: %v", err)
+		return img.URL
+	}
+	if thumbFilename == "" {
+		return img.URL
+	}
+
+	return utils.GetUploadURL(thumbFilename, dir)
+}
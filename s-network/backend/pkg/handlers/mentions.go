@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"s-network/backend/pkg/db/sqlite"
+)
+
+// mentionPattern matches @nickname when it starts the text or follows a non-word character,
+// so email addresses like a@b.com are not treated as mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\p{L}\p{N}_.\-]+)`)
+
+// parseMentions returns the unique nicknames mentioned in content, in order of appearance
+func parseMentions(content string) []string {
+	var nicknames []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// Drop punctuation that ends a sentence, e.g. "thanks @bob."
+		nickname := strings.TrimRight(match[1], ".-")
+		key := strings.ToLower(nickname)
+		if nickname == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		nicknames = append(nicknames, nickname)
+	}
+
+	return nicknames
+}
+
+// Mention notification types. Mentions in group post comments reference the group post,
+// which needs its own type since group post IDs overlap with regular post IDs
+const (
+	mentionNotification      = "mention"
+	groupMentionNotification = "group_mention"
+)
+
+// notifyMentions creates a notification of the given type for every user mentioned in content
+// who can see it. The author, unknown nicknames and users who blocked the author are skipped.
+// It returns the IDs of the users that were notified.
+func notifyMentions(authorID int, content string, canView func(userID int) bool, contentLabel, notificationType string, referenceID int64) []int64 {
+	mentioned := []int64{}
+
+	nicknames := parseMentions(content)
+	if len(nicknames) == 0 {
+		return mentioned
+	}
+
+	author, err := db.GetUserById(authorID)
+	if err != nil {
+		log.Printf("Error getting mention author %d: %v", authorID, err)
+		return mentioned
+	}
+	authorName := fmt.Sprintf("%s %s", author["first_name"], author["last_name"])
+	message := authorName + " mentioned you in a " + contentLabel
+
+	notified := make(map[int]bool)
+	for _, nickname := range nicknames {
+		user, err := db.GetUserByNickname(nickname)
+		if err != nil || user == nil {
+			continue
+		}
+
+		userID := user["id"].(int)
+		if userID == authorID || notified[userID] {
+			continue
+		}
+
+		if blocked, err := db.IsBlocked(int64(userID), int64(authorID)); err != nil || blocked {
+			continue
+		}
+
+		if !canView(userID) {
+			continue
+		}
+
+		if db.ShouldNotify(int64(userID), sqlite.NotificationCategoryMentions) {
+			_, err = db.CreateNotification(&sqlite.Notification{
+				ReceiverID:  int64(userID),
+				SenderID:    int64(authorID),
+				Type:        notificationType,
+				Content:     message,
+				ReferenceID: referenceID,
+				IsRead:      false,
+			})
+			if err != nil {
+				log.Printf("Error creating mention notification for user %d: %v", userID, err)
+				continue
+			}
+		}
+
+		// Send real-time notification
+		if chatHub != nil {
+			chatHub.SendNotificationToUser(int64(userID), map[string]interface{}{
+				"type":          notificationType,
+				"sender_id":     authorID,
+				"sender_name":   authorName,
+				"sender_avatar": author["avatar"],
+				"content":       message,
+				"reference_id":  referenceID,
+				"created_at":    time.Now().Format(time.RFC3339),
+			})
+		}
+
+		notified[userID] = true
+		mentioned = append(mentioned, int64(userID))
+	}
+
+	return mentioned
+}
+
+// canViewPost reports whether a user can see a regular post based on its privacy setting
+func canViewPost(userID int, post map[string]interface{}) bool {
+	ownerID, _ := post["user_id"].(int64)
+	if int64(userID) == ownerID {
+		return true
+	}
+
+	switch post["privacy"] {
+	case "public":
+		return true
+	case "almost_private":
+		following, err := db.IsFollowing(userID, int(ownerID))
+		return err == nil && following
+	case "private":
+		postID, _ := post["id"].(int64)
+		hasAccess, err := db.HasPostAccess(postID, userID)
+		return err == nil && hasAccess
+	}
+
+	return false
+}
@@ -65,10 +65,11 @@ func GetUserNotifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse pagination parameters
+	// Parse pagination and filter parameters
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
 	typeFilter := r.URL.Query().Get("type")
+	unreadOnly := r.URL.Query().Get("unread") == "true"
 
 	limit := 20
 	if limitStr != "" {
@@ -97,9 +98,8 @@ func GetUserNotifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get notifications from database
-
-	notifications, err := db.GetUserNotifications(int64(userID), typeFilter, limit, offset)
+	// Get notifications from database, sender details are joined in
+	notifications, err := db.GetNotifications(userID, limit, offset, unreadOnly, typeFilter)
 	if err != nil {
 		fmt.Printf("Error getting notifications: %v\n", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -110,64 +110,36 @@ func GetUserNotifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Instead of getting follow requests again, since we already get them from GetUserNotifications,
-	// we'll just use the notifications we've already retrieved.
-	// This prevents duplication of follow requests.
-
-	// Track used request IDs to deduplicate follow requests
-	usedRequestIDs := make(map[int64]bool)
-	for _, notification := range notifications {
-		if notification != nil && notification.Type == "follow_request" {
-			usedRequestIDs[notification.ReferenceID] = true
-		}
-	}
-
 	// Process notifications to include sender details
 	result := make([]map[string]interface{}, 0, len(notifications))
-	for i, notification := range notifications {
-		// Skip if notification is nil
-		if notification == nil {
-			continue
-		}
-
-		// Skip duplicate follow request notifications
+	for _, notification := range notifications {
+		// Skip follow requests that were already accepted, rejected or cancelled
 		if notification.Type == "follow_request" {
-			// Only process the first occurrence of each request ID
-			if i > 0 && usedRequestIDs[notification.ReferenceID] {
-				// Skip this duplicate
-				fmt.Printf("Skipping duplicate follow request notification with ID %d\n", notification.ID)
-				continue
-			}
-			usedRequestIDs[notification.ReferenceID] = true
-
-			// Verify that this follow request still exists
 			exists, _ := db.CheckFollowRequestExistsById(notification.ReferenceID)
 			if !exists {
-				fmt.Printf("Skipping follow request %d that no longer exists\n", notification.ReferenceID)
 				continue
 			}
 		}
 
-		// Get sender info
 		var senderInfo map[string]interface{}
 		if notification.SenderID > 0 {
-			sender, err := db.GetUserById(int(notification.SenderID))
-			if err != nil {
-				fmt.Printf("Error getting sender info for notification: %v\n", err)
-				// Use default sender info instead of skipping notification
+			if notification.SenderFirstName == "" && notification.SenderLastName == "" {
+				// Sender no longer exists, use default sender info instead of skipping notification
 				senderInfo = map[string]interface{}{
-					"id":         notification.SenderID,
 					"first_name": "Unknown",
 					"last_name":  "User",
 					"avatar":     nil,
 				}
 			} else {
-				senderInfo = sender
+				senderInfo = map[string]interface{}{
+					"first_name": notification.SenderFirstName,
+					"last_name":  notification.SenderLastName,
+					"avatar":     notification.SenderAvatar,
+				}
 			}
 		} else {
 			// For system notifications without a sender
 			senderInfo = map[string]interface{}{
-				"id":         0,
 				"first_name": "System",
 				"last_name":  "",
 				"avatar":     nil,
@@ -200,8 +172,10 @@ func GetUserNotifications(w http.ResponseWriter, r *http.Request) {
 		case "follow_accepted":
 			// Keep reference_id as is - it contains the user ID of who accepted
 		case "post_like":
-		case "post_comment":
+		case "post_comment", mentionNotification:
 			notificationData["post_id"] = notification.ReferenceID
+		case groupMentionNotification:
+			notificationData["group_post_id"] = notification.ReferenceID
 		case "group":
 			notificationData["group_id"] = notification.ReferenceID
 		}
@@ -229,6 +203,7 @@ func GetUserNotifications(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"notifications": result,
 		"unread_count":  unreadCount,
+		"total_unread":  unreadCount,
 		"total":         len(result),
 		"offset":        offset,
 		"limit":         limit,
@@ -472,8 +447,19 @@ func MarkAllNotificationsAsRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Type is optional, e.g. {"type": "group_invitation"} only clears invitations
+	var requestData struct {
+		Type string `json:"type"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Mark all notifications as read
-	err = db.MarkNotificationsAsRead(userID)
+	count, err := db.MarkAllNotificationsRead(userID, requestData.Type)
 	if err != nil {
 		http.Error(w, "Failed to mark notifications as read", http.StatusInternalServerError)
 		return
@@ -482,17 +468,103 @@ func MarkAllNotificationsAsRead(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
+		"count":   count,
 	})
 }
 
+// GetNotificationPreferences returns which notification categories the current user receives
+func GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	prefs, err := db.GetNotificationPreferences(int64(userID))
+	if err != nil {
+		http.Error(w, "Failed to get notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdateNotificationPreferences changes which notification categories the current user receives.
+// Categories left out of the request body keep their current value
+func UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	var requestData struct {
+		Follows      *bool `json:"follows"`
+		GroupInvites *bool `json:"group_invites"`
+		Events       *bool `json:"events"`
+		Comments     *bool `json:"comments"`
+		Mentions     *bool `json:"mentions"`
+		Messages     *bool `json:"messages"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := db.GetNotificationPreferences(int64(userID))
+	if err != nil {
+		http.Error(w, "Failed to get notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	if requestData.Follows != nil {
+		prefs.Follows = *requestData.Follows
+	}
+	if requestData.GroupInvites != nil {
+		prefs.GroupInvites = *requestData.GroupInvites
+	}
+	if requestData.Events != nil {
+		prefs.Events = *requestData.Events
+	}
+	if requestData.Comments != nil {
+		prefs.Comments = *requestData.Comments
+	}
+	if requestData.Mentions != nil {
+		prefs.Mentions = *requestData.Mentions
+	}
+	if requestData.Messages != nil {
+		prefs.Messages = *requestData.Messages
+	}
+
+	if err := db.UpdateNotificationPreferences(prefs); err != nil {
+		http.Error(w, "Failed to update notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
 // RegisterNotificationRoutes registers notification-related routes
 func RegisterNotificationRoutes(router *mux.Router) {
 	router.HandleFunc("/notifications", GetUserNotifications).Methods("GET", "OPTIONS")
+	router.HandleFunc("/notifications", ClearAllNotifications).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/notifications/{id}/read", MarkNotificationAsRead).Methods("POST", "OPTIONS")
 	router.HandleFunc("/notifications/unread", GetUnreadNotificationCount).Methods("GET", "OPTIONS")
 	router.HandleFunc("/notifications/read-all", MarkAllNotificationsAsRead).Methods("POST", "OPTIONS")
 	router.HandleFunc("/notifications/cleanup-expired", CleanupExpiredNotifications).Methods("POST", "OPTIONS")
 	router.HandleFunc("/notifications/clear-all", ClearAllNotifications).Methods("POST", "OPTIONS")
+	router.HandleFunc("/notifications/{id}", DeleteNotification).Methods("DELETE", "OPTIONS")
 }
 
 // CleanupExpiredNotifications removes group invitation notifications older than 1 minute
@@ -587,7 +659,7 @@ func ClearAllNotifications(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete all notifications for the user
-	err = db.DeleteUserNotifications(userID)
+	deleted, err := db.DeleteAllNotifications(userID)
 	if err != nil {
 		fmt.Printf("Error clearing all notifications for user %d: %v\n", userID, err)
 		http.Error(w, "Failed to clear all notifications", http.StatusInternalServerError)
@@ -598,5 +670,44 @@ func ClearAllNotifications(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"message": "All notifications cleared successfully",
+		"deleted": deleted,
+	})
+}
+
+// DeleteNotification deletes a single notification belonging to the current user
+func DeleteNotification(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Unauthorized",
+		})
+		return
+	}
+
+	vars := mux.Vars(r)
+	notificationIDStr := vars["id"]
+	notificationID, err := strconv.ParseInt(notificationIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	// Only the receiver can delete a notification
+	err = db.DeleteNotification(notificationID, int64(userID))
+	if err != nil {
+		if err.Error() == "notification not found" {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		fmt.Printf("Error deleting notification %d for user %d: %v\n", notificationID, userID, err)
+		http.Error(w, "Failed to delete notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
 	})
 }
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"golang.org/x/crypto/bcrypt"
+
+	"s-network/backend/pkg/db/sqlite"
+)
+
+const (
+	resetTestEmail    = "reset@example.com"
+	resetTestPassword = "OldPassword!1"
+	resetNewPassword  = "NewPassword!2"
+)
+
+type sentResetToken struct {
+	email string
+	token string
+}
+
+// setupPasswordResetTest points the handlers at a fresh database with one user and
+// captures the tokens the reset mailer is asked to send
+func setupPasswordResetTest(t *testing.T) (int, *[]sentResetToken) {
+	t.Helper()
+
+	database, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	previousDB, previousStore, previousMailer := db, store, PasswordResetMailer
+	SetDependencies(database, sessions.NewCookieStore([]byte("test-session-key")))
+
+	var sent []sentResetToken
+	PasswordResetMailer = func(email, token string) error {
+		sent = append(sent, sentResetToken{email: email, token: token})
+		return nil
+	}
+
+	t.Cleanup(func() {
+		SetDependencies(previousDB, previousStore)
+		PasswordResetMailer = previousMailer
+		database.Close()
+	})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(resetTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	userID, err := database.CreateUser(resetTestEmail, string(hash), "Reset", "Test", "2000-01-01", "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	return int(userID), &sent
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)))
+	return w
+}
+
+func countAuthTokens(t *testing.T, userID int) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM auth_tokens WHERE user_id = ?`, userID).Scan(&count); err != nil {
+		t.Fatalf("Failed to count auth tokens: %v", err)
+	}
+	return count
+}
+
+func passwordMatches(t *testing.T, userID int, password string) bool {
+	t.Helper()
+
+	var hash string
+	if err := db.QueryRow(`SELECT password FROM users WHERE id = ?`, userID).Scan(&hash); err != nil {
+		t.Fatalf("Failed to get password: %v", err)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func TestRequestPasswordResetCreatesToken(t *testing.T) {
+	userID, sent := setupPasswordResetTest(t)
+
+	w := postJSON(t, RequestPasswordReset, map[string]string{"email": resetTestEmail})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("Mailer was called %d times, want 1", len(*sent))
+	}
+	if (*sent)[0].email != resetTestEmail {
+		t.Errorf("Token was sent to %q, want %q", (*sent)[0].email, resetTestEmail)
+	}
+
+	token, err := db.GetAuthToken((*sent)[0].token)
+	if err != nil {
+		t.Fatalf("Sent token was not stored: %v", err)
+	}
+	if token["user_id"] != userID || token["token_type"] != "password_reset" {
+		t.Errorf("Got token for user %v of type %v, want user %d of type password_reset", token["user_id"], token["token_type"], userID)
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, token["expires_at"].(string))
+	if err != nil {
+		t.Fatalf("Failed to parse expiry: %v", err)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > time.Hour {
+		t.Errorf("Token expires in %v, want within an hour", until)
+	}
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	userID, sent := setupPasswordResetTest(t)
+
+	w := postJSON(t, RequestPasswordReset, map[string]string{"email": "nobody@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %d, want %d so unknown emails look the same", w.Code, http.StatusOK)
+	}
+	if len(*sent) != 0 {
+		t.Errorf("Mailer was called %d times for an unknown email, want 0", len(*sent))
+	}
+	if n := countAuthTokens(t, userID); n != 0 {
+		t.Errorf("Got %d auth tokens, want 0", n)
+	}
+}
+
+func TestRequestPasswordResetWithoutMailer(t *testing.T) {
+	userID, _ := setupPasswordResetTest(t)
+	PasswordResetMailer = nil
+
+	w := postJSON(t, RequestPasswordReset, map[string]string{"email": resetTestEmail})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if n := countAuthTokens(t, userID); n != 0 {
+		t.Errorf("Got %d auth tokens without a mailer, want 0", n)
+	}
+}
+
+func TestResetPasswordDeletesToken(t *testing.T) {
+	userID, sent := setupPasswordResetTest(t)
+
+	postJSON(t, RequestPasswordReset, map[string]string{"email": resetTestEmail})
+	if len(*sent) != 1 {
+		t.Fatalf("Mailer was called %d times, want 1", len(*sent))
+	}
+	token := (*sent)[0].token
+
+	w := postJSON(t, ResetPassword, map[string]string{"token": token, "new_password": resetNewPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !passwordMatches(t, userID, resetNewPassword) {
+		t.Error("Password was not changed")
+	}
+	if n := countAuthTokens(t, userID); n != 0 {
+		t.Errorf("Got %d auth tokens after reset, want 0", n)
+	}
+
+	// The token can't be used a second time
+	w = postJSON(t, ResetPassword, map[string]string{"token": token, "new_password": "OtherPassword!3"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Reusing the token got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !passwordMatches(t, userID, resetNewPassword) {
+		t.Error("Reused token changed the password")
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	userID, _ := setupPasswordResetTest(t)
+
+	expiresAt := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	if err := db.CreateAuthToken("expired-token", userID, "password_reset", expiresAt); err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	w := postJSON(t, ResetPassword, map[string]string{"token": "expired-token", "new_password": resetNewPassword})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !passwordMatches(t, userID, resetTestPassword) {
+		t.Error("Expired token changed the password")
+	}
+}
+
+func TestResetPasswordRejectsWrongTokenType(t *testing.T) {
+	userID, _ := setupPasswordResetTest(t)
+
+	token, err := createAuthToken(userID, "login")
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	w := postJSON(t, ResetPassword, map[string]string{"token": token, "new_password": resetNewPassword})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if !passwordMatches(t, userID, resetTestPassword) {
+		t.Error("Login token changed the password")
+	}
+	if n := countAuthTokens(t, userID); n != 1 {
+		t.Errorf("Got %d auth tokens, want the login token to be kept", n)
+	}
+}
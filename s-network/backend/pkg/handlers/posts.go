@@ -3,15 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"s-network/backend/pkg/db/sqlite"
 	"s-network/backend/pkg/utils"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
@@ -63,7 +63,14 @@ func CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse allowed followers if privacy is private
 	var allowedFollowers []int
-	if privacy == "private" {
+	if privacy == "private" && r.FormValue("use_close_friends") == "true" {
+		// Share with the close friends list instead of an explicit selection
+		allowedFollowers, err = db.GetCloseFriendFollowerIDs(userID)
+		if err != nil {
+			http.Error(w, "Failed to get close friends", http.StatusInternalServerError)
+			return
+		}
+	} else if privacy == "private" {
 		allowedFollowersStr := r.FormValue("allowedFollowers")
 		if allowedFollowersStr != "" {
 			var followerIDs []int
@@ -76,43 +83,49 @@ func CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Handle file upload
-	var imageURL string
-	file, handler, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-
-		// Create uploads directory if it doesn't exist
-		uploadsDir := utils.GetUploadSubdir("posts")
-		os.MkdirAll(uploadsDir, 0755)
-
-		// Generate a unique filename
-		ext := filepath.Ext(handler.Filename)
-		filename := uuid.New().String() + ext
-		imageURL = utils.GetUploadURL(filename, "posts")
-
-		// Create the file
-		dst, err := os.Create(filepath.Join(uploadsDir, filename))
+	// Parse optional publish time for scheduled posts
+	var scheduledAt *time.Time
+	if scheduledAtStr := r.FormValue("scheduled_at"); scheduledAtStr != "" {
+		parsed, err := time.Parse(time.RFC3339, scheduledAtStr)
 		if err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
+			http.Error(w, "Invalid scheduled_at format. Use RFC3339", http.StatusBadRequest)
 			return
 		}
-		defer dst.Close()
-
-		// Copy the file content
-		if _, err = io.Copy(dst, file); err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
-			return
+		if parsed.After(time.Now()) {
+			scheduledAt = &parsed
 		}
 	}
 
+	// Handle file upload
+	var imageURL, thumbnailURL string
+	image, status, err := saveUploadedImage(r, "image", "posts")
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if image != nil {
+		imageURL = image.URL
+		thumbnailURL = image.thumbnailURL("posts")
+	}
+
 	// Create post in the database
-	postID, err := db.CreatePost(userID, title, content, imageURL, privacy, allowedFollowers)
+	var postID int64
+	if scheduledAt != nil {
+		postID, err = db.CreateScheduledPost(userID, title, content, imageURL, privacy, allowedFollowers, *scheduledAt)
+	} else {
+		postID, err = db.CreatePost(userID, title, content, imageURL, privacy, allowedFollowers)
+	}
 	if err != nil {
 		http.Error(w, "Failed to create post: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if thumbnailURL != "" {
+		if err := db.SetPostThumbnail(postID, thumbnailURL); err != nil {
+			fmt.Printf("Error saving post thumbnail: %v\n", err)
+		}
+	}
+
 	// Get the newly created post
 	post, err := db.GetPost(postID)
 	if err != nil {
@@ -120,13 +133,18 @@ func CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Notify mentioned users. Scheduled posts notify them once PublishScheduledPosts publishes them
+	if scheduledAt == nil {
+		post["mentions"] = notifyPostMentions(post)
+	}
+
 	// Return post data
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(post)
 }
 
-// GetPostsHandler retrieves posts for the authenticated user
-func GetPostsHandler(w http.ResponseWriter, r *http.Request) {
+// EditPostHandler updates a post owned by the current user
+func EditPostHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -140,54 +158,166 @@ func GetPostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
 
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
-		pageNum, err := strconv.Atoi(pageStr)
-		if err == nil && pageNum > 0 {
-			page = pageNum
-		}
+	// Get post to check if the user is the owner
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	if limitStr != "" {
-		limitNum, err := strconv.Atoi(limitStr)
-		if err == nil && limitNum > 0 && limitNum <= 50 {
-			limit = limitNum
+	postUserID, ok := post["user_id"].(int64)
+	if !ok || int64(userID) != postUserID {
+		http.Error(w, "Unauthorized to edit this post", http.StatusForbidden)
+		return
+	}
+
+	// Parse multipart form for file uploads
+	err = r.ParseMultipartForm(10 << 20) // 10 MB max
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	// Fields that are not sent keep their current values
+	title := post["title"].(string)
+	if _, ok := r.PostForm["title"]; ok {
+		title = r.FormValue("title")
+	}
+
+	content := post["content"].(string)
+	if _, ok := r.PostForm["content"]; ok {
+		content = r.FormValue("content")
+	}
+
+	privacy := r.FormValue("privacy")
+	if privacy == "" {
+		privacy = post["privacy"].(string)
+	}
+
+	if privacy != "public" && privacy != "almost_private" && privacy != "private" {
+		http.Error(w, "Invalid privacy setting", http.StatusBadRequest)
+		return
+	}
+
+	// The audience of a private post is only replaced when it is sent or the
+	// post becomes private; nil keeps the current access rows
+	var allowedFollowers []int
+	_, audienceSent := r.PostForm["allowedFollowers"]
+	useCloseFriends := r.FormValue("use_close_friends") == "true"
+	if privacy == "private" && (audienceSent || useCloseFriends || post["privacy"] != "private") {
+		if useCloseFriends {
+			// Share with the close friends list instead of an explicit selection
+			allowedFollowers, err = db.GetCloseFriendFollowerIDs(userID)
+			if err != nil {
+				http.Error(w, "Failed to get close friends", http.StatusInternalServerError)
+				return
+			}
+		} else if allowedFollowersStr := r.FormValue("allowedFollowers"); allowedFollowersStr != "" {
+			err = json.Unmarshal([]byte(allowedFollowersStr), &allowedFollowers)
+			if err != nil {
+				http.Error(w, "Invalid allowed followers format", http.StatusBadRequest)
+				return
+			}
+		}
+		if allowedFollowers == nil {
+			allowedFollowers = []int{}
 		}
 	}
 
-	// Get posts from the database
-	posts, err := db.GetPosts(userID, page, limit)
+	oldImageURL, _ := post["image_url"].(string)
+	imageURL := oldImageURL
+
+	// Allow removing the existing image without uploading a new one
+	if r.FormValue("remove_image") == "true" {
+		imageURL = ""
+	}
+
+	// Handle file upload
+	image, status, err := saveUploadedImage(r, "image", "posts")
 	if err != nil {
-		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), status)
 		return
 	}
+	if image != nil {
+		imageURL = image.URL
+	}
 
-	// Set is_author flag for each post
-	for i := range posts {
-		postUserID, ok := posts[i]["user_id"].(int64)
-		if ok && int64(userID) == postUserID {
-			posts[i]["is_author"] = true
-		} else {
-			posts[i]["is_author"] = false
+	err = db.UpdatePost(postID, title, content, privacy, imageURL, allowedFollowers)
+	if err != nil {
+		// Don't leave the newly uploaded image behind
+		if imageURL != "" && imageURL != oldImageURL {
+			removePostUpload(imageURL)
+		}
+		http.Error(w, "Failed to update post: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Clean up the previous image if it was replaced or removed
+	if oldImageURL != "" && oldImageURL != imageURL {
+		removePostUpload(oldImageURL)
+	}
+
+	// UpdatePost drops the old thumbnail, so a new image needs its own
+	if image != nil {
+		if err := db.SetPostThumbnail(postID, image.thumbnailURL("posts")); err != nil {
+			fmt.Printf("Error saving post thumbnail: %v\n", err)
 		}
 	}
 
+	// Get the updated post
+	updatedPost, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
+		return
+	}
+
 	// Return post data
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"posts": posts,
-		"page":  page,
-		"limit": limit,
-	})
+	json.NewEncoder(w).Encode(updatedPost)
 }
 
-// GetExplorePostsHandler retrieves all public posts for the explore page
-func GetExplorePostsHandler(w http.ResponseWriter, r *http.Request) {
+// notifyPostMentions notifies the users mentioned in a post's title or content who can see it
+func notifyPostMentions(post map[string]interface{}) []int64 {
+	authorID, _ := post["user_id"].(int64)
+	postID, _ := post["id"].(int64)
+	title, _ := post["title"].(string)
+	content, _ := post["content"].(string)
+
+	return notifyMentions(int(authorID), title+"\n"+content, func(viewerID int) bool {
+		return canViewPost(viewerID, post)
+	}, "post", mentionNotification, postID)
+}
+
+// PublishScheduledPosts publishes scheduled posts whose time has passed and sends the
+// mention notifications that were held back while they were hidden.
+// It returns the number of posts published.
+func PublishScheduledPosts() (int, error) {
+	postIDs, err := db.PublishScheduledPosts()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, postID := range postIDs {
+		post, err := db.GetPost(postID)
+		if err != nil {
+			fmt.Printf("Error getting published post %d: %v\n", postID, err)
+			continue
+		}
+		notifyPostMentions(post)
+	}
+
+	return len(postIDs), nil
+}
+
+// GetScheduledPostsHandler lists the current user's posts waiting to be published
+func GetScheduledPostsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -201,54 +331,20 @@ func GetExplorePostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
-
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
-		pageNum, err := strconv.Atoi(pageStr)
-		if err == nil && pageNum > 0 {
-			page = pageNum
-		}
-	}
-
-	limitStr := r.URL.Query().Get("limit")
-	if limitStr != "" {
-		limitNum, err := strconv.Atoi(limitStr)
-		if err == nil && limitNum > 0 && limitNum <= 50 {
-			limit = limitNum
-		}
-	}
-
-	// Get public posts from the database
-	posts, err := db.GetExplorePosts(userID, page, limit)
+	posts, err := db.GetScheduledPosts(userID)
 	if err != nil {
-		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve scheduled posts: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Set is_author flag for each post
-	for i := range posts {
-		postUserID, ok := posts[i]["user_id"].(int64)
-		if ok && int64(userID) == postUserID {
-			posts[i]["is_author"] = true
-		} else {
-			posts[i]["is_author"] = false
-		}
-	}
-
-	// Return post data
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"posts": posts,
-		"page":  page,
-		"limit": limit,
 	})
 }
 
-// GetPostHandler retrieves a specific post by ID
-func GetPostHandler(w http.ResponseWriter, r *http.Request) {
+// CancelScheduledPostHandler deletes one of the current user's posts before it is published
+func CancelScheduledPostHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -262,75 +358,48 @@ func GetPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get post ID from URL
 	vars := mux.Vars(r)
-	postIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Post ID is required", http.StatusBadRequest)
-		return
-	}
-
-	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get post data
 	post, err := db.GetPost(postID)
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			http.Error(w, "Post not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to retrieve post: "+err.Error(), http.StatusInternalServerError)
-		}
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Check if the user is the post owner
 	postUserID, ok := post["user_id"].(int64)
-	if ok && int64(userID) == postUserID {
-		post["is_author"] = true
-	} else {
-		post["is_author"] = false
+	if !ok || int64(userID) != postUserID {
+		http.Error(w, "Unauthorized to cancel this post", http.StatusForbidden)
+		return
 	}
 
-	// Get user's vote on this post
-	userVote, err := db.GetUserVote(userID, postID, "post")
-	if err == nil {
-		post["user_vote"] = userVote
+	if isPublished, _ := post["is_published"].(bool); isPublished {
+		http.Error(w, "Post has already been published", http.StatusConflict)
+		return
 	}
 
-	// Get comments for this post
-	comments, err := db.GetCommentsByPostIDWithUserVotes(postID, userID)
-	if err == nil {
-		// Set is_author flag for each comment
-		for i := range comments {
-			commentUserID, ok := comments[i]["user_id"].(int64)
-			if ok && int64(userID) == commentUserID {
-				comments[i]["is_author"] = true
-			} else {
-				comments[i]["is_author"] = false
-			}
-
-			// Also set is_post_author flag if the user is the post author
-			if int64(userID) == postUserID {
-				comments[i]["is_post_author"] = true
-			} else {
-				comments[i]["is_post_author"] = false
-			}
-		}
+	err = db.DeletePost(postID)
+	if err != nil {
+		http.Error(w, "Failed to cancel post: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-		post["comments"] = comments
+	if imageURL, _ := post["image_url"].(string); imageURL != "" {
+		removePostUpload(imageURL)
 	}
 
-	// Return post data as JSON
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(post)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Scheduled post cancelled",
+	})
 }
 
-// AddCommentHandler adds a comment to a post
-func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
+// GetPostAudienceHandler shows the owner of a post who is able to see it
+func GetPostAudienceHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -344,121 +413,154 @@ func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get post ID from URL
 	vars := mux.Vars(r)
-	postIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Post ID is required", http.StatusBadRequest)
-		return
-	}
-
-	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
 		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get post to check ownership
 	post, err := db.GetPost(postID)
 	if err != nil {
 		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Get post user ID
 	postUserID, ok := post["user_id"].(int64)
-	if !ok {
-		http.Error(w, "Failed to determine post ownership", http.StatusInternalServerError)
+	if !ok || int64(userID) != postUserID {
+		http.Error(w, "Only the post owner can view its audience", http.StatusForbidden)
 		return
 	}
 
-	// Parse multipart form for file uploads
-	err = r.ParseMultipartForm(10 << 20) // 10 MB max
-	if err != nil {
-		http.Error(w, "Unable to parse form", http.StatusBadRequest)
-		return
+	privacy := post["privacy"].(string)
+	response := map[string]interface{}{
+		"post_id": postID,
+		"privacy": privacy,
 	}
 
-	// Get form values
-	content := r.FormValue("content")
-
-	// Handle file upload
-	var imageURL string
-	file, handler, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-
-		// Create uploads directory if it doesn't exist
-		uploadsDir := utils.GetUploadSubdir("comments")
-		os.MkdirAll(uploadsDir, 0755)
-
-		// Generate a unique filename
-		ext := filepath.Ext(handler.Filename)
-		filename := uuid.New().String() + ext
-		imageURL = utils.GetUploadURL(filename, "comments")
-
-		// Create the file
-		dst, err := os.Create(filepath.Join(uploadsDir, filename))
+	switch privacy {
+	case "private":
+		audience, err := db.GetPostAudience(postID)
 		if err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
-			return
-		}
-		defer dst.Close()
-
-		// Copy the file content
-		if _, err = io.Copy(dst, file); err != nil {
-			http.Error(w, "Failed to save image", http.StatusInternalServerError)
+			http.Error(w, "Failed to get post audience: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		response["users"] = audience
+		response["note"] = "Visible only to the users listed"
+	case "almost_private":
+		response["note"] = "Visible to all of your followers"
+	default:
+		response["note"] = "Visible to everyone"
 	}
 
-	// Validate that we have either content or an image
-	if content == "" && imageURL == "" {
-		http.Error(w, "Either content or image is required", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// removePostUpload deletes a previously uploaded post image from disk
+func removePostUpload(imageURL string) {
+	prefix := utils.GetUploadURL("", "posts")
+	if !strings.HasPrefix(imageURL, prefix) {
 		return
 	}
 
-	// Add comment to the database
-	commentID, err := db.AddComment(postID, int64(userID), content, imageURL)
-	if err != nil {
-		http.Error(w, "Failed to add comment: "+err.Error(), http.StatusInternalServerError)
+	fullPath := filepath.Join(utils.GetUploadSubdir("posts"), filepath.Base(imageURL))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error removing post image %s: %v\n", fullPath, err)
+	}
+
+	for _, thumb := range thumbnailFilenames(filepath.Base(imageURL)) {
+		thumbPath := filepath.Join(utils.GetUploadSubdir("posts"), thumb)
+		if err := os.Remove(thumbPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Error removing post thumbnail %s: %v\n", thumbPath, err)
+		}
+	}
+}
+
+// removeCommentUpload deletes a previously uploaded comment image from disk
+func removeCommentUpload(imageURL string) {
+	prefix := utils.GetUploadURL("", "comments")
+	if !strings.HasPrefix(imageURL, prefix) {
 		return
 	}
 
-	// Get all comments for the post
-	comments, err := db.GetCommentsByPostID(postID)
+	fullPath := filepath.Join(utils.GetUploadSubdir("comments"), filepath.Base(imageURL))
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error removing comment image %s: %v\n", fullPath, err)
+	}
+}
+
+// GetPostsHandler retrieves posts for the authenticated user
+func GetPostsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
-		http.Error(w, "Failed to retrieve comments: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Set is_author flag for each comment
-	for i := range comments {
-		commentUserID, ok := comments[i]["user_id"].(int64)
-		if ok && int64(userID) == commentUserID {
-			comments[i]["is_author"] = true
-		} else {
-			comments[i]["is_author"] = false
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
 		}
+	}
 
-		// Also set is_post_author flag if the user is the post author
-		if int64(userID) == postUserID {
-			comments[i]["is_post_author"] = true
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	// Get posts from the database
+	posts, err := db.GetPosts(userID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Set is_author flag for each post
+	for i := range posts {
+		postUserID, ok := posts[i]["user_id"].(int64)
+		if ok && int64(userID) == postUserID {
+			posts[i]["is_author"] = true
 		} else {
-			comments[i]["is_post_author"] = false
+			posts[i]["is_author"] = false
 		}
 	}
 
-	// Return comments data
+	total, err := db.CountVisiblePosts(userID)
+	if err != nil {
+		http.Error(w, "Failed to count posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return post data
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"id":       commentID,
-		"comments": comments,
+		"posts":    posts,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+		"has_more": page*limit < total,
 	})
 }
 
-// GetUserFollowersHandler retrieves followers for the authenticated user
-func GetUserFollowersHandler(w http.ResponseWriter, r *http.Request) {
+// GetExplorePostsHandler retrieves all public posts for the explore page
+func GetExplorePostsHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -472,67 +574,122 @@ func GetUserFollowersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get followers from the database
-	followers, err := db.GetUserFollowers(userID)
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	// Get public posts from the database
+	posts, err := db.GetExplorePosts(userID, page, limit)
 	if err != nil {
-		http.Error(w, "Failed to retrieve followers: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Return followers data
+	// Set is_author flag for each post
+	for i := range posts {
+		postUserID, ok := posts[i]["user_id"].(int64)
+		if ok && int64(userID) == postUserID {
+			posts[i]["is_author"] = true
+		} else {
+			posts[i]["is_author"] = false
+		}
+	}
+
+	total, err := db.CountExplorePosts()
+	if err != nil {
+		http.Error(w, "Failed to count posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return post data
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"followers": followers,
+		"posts":    posts,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+		"has_more": page*limit < total,
 	})
 }
 
-// //////////////////////////////////////////////////////////////////////////////////////////////////////////
-// GetUserFollowersHandler retrieves followers for the authenticated user
-func GetUserFollowingHandler(w http.ResponseWriter, r *http.Request) {
-	// Try reading userId from query string
-	queryID := r.URL.Query().Get("userId")
+// GetVotedContentHandler lists the posts and group posts the current user voted on.
+// ?type=1 (default) returns upvoted content and ?type=-1 downvoted content
+func GetVotedContentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	var userID int
-	var err error
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	if queryID != "" {
-		userID, err = strconv.Atoi(queryID)
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	voteType := 1
+	if typeStr := r.URL.Query().Get("type"); typeStr != "" {
+		voteType, err = strconv.Atoi(typeStr)
+		if err != nil || (voteType != 1 && voteType != -1) {
+			http.Error(w, "Vote type must be 1 (upvote) or -1 (downvote)", http.StatusBadRequest)
 			return
 		}
-	} else {
-		// fallback to session-based authenticated user
-		session, err := store.Get(r, SessionCookieName)
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+	}
+
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
 		}
+	}
 
-		var ok bool
-		userID, ok = session.Values["user_id"].(int)
-		if !ok {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
 		}
 	}
 
-	following, err := db.GetUserFollowing(userID)
+	items, err := db.GetUserVotedContent(userID, voteType, limit, (page-1)*limit)
 	if err != nil {
-		http.Error(w, "Failed to retrieve following: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve voted content: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"followings": following,
+		"items": items,
+		"type":  voteType,
+		"page":  page,
+		"limit": limit,
 	})
 }
 
-////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-
-// FollowUserHandler allows a user to follow another user
-func FollowUserHandler(w http.ResponseWriter, r *http.Request) {
+// BookmarkPostHandler saves a post the user can see for later
+func BookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -540,69 +697,767 @@ func FollowUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	followerID, ok := session.Values["user_id"].(int)
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get user ID to follow from request
 	vars := mux.Vars(r)
-	userIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	followingID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	post, err := db.GetPost(postID)
+	if err != nil || !canViewBookmarkedPost(userID, post) {
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Can't follow yourself
-	if followerID == followingID {
-		http.Error(w, "You cannot follow yourself", http.StatusBadRequest)
+	if err := db.AddBookmark(userID, postID); err != nil {
+		http.Error(w, "Failed to bookmark post: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Get user to check if account is public or private
-	userToFollow, err := db.GetUserById(followingID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Post bookmarked",
+		"post_id":       postID,
+		"is_bookmarked": true,
+	})
+}
+
+// RemoveBookmarkHandler removes a post from the user's saved posts
+func RemoveBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	isPublic, ok := userToFollow["is_public"].(bool)
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
-		isPublic = true // Default to public if field is missing
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	if isPublic {
-		// Direct follow for public accounts
-		err = db.FollowUser(followerID, followingID)
-		if err != nil {
-			http.Error(w, "Failed to follow user: "+err.Error(), http.StatusInternalServerError)
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	// Bookmarks of deleted or hidden posts can still be removed
+	if err := db.RemoveBookmark(userID, postID); err != nil {
+		if err.Error() == "bookmark not found" {
+			http.Error(w, "Bookmark not found", http.StatusNotFound)
 			return
 		}
+		http.Error(w, "Failed to remove bookmark: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-		// Create notification for the user being followed
-		followerUser, err := db.GetUserById(followerID)
-		if err == nil {
-			followerName := followerUser["first_name"].(string) + " " + followerUser["last_name"].(string)
-			db.CreateNotification(&sqlite.Notification{
-				ReceiverID:  int64(followingID),
-				SenderID:    int64(followerID),
-				Type:        "follow",
-				Content:     followerName + " started following you",
-				ReferenceID: int64(followerID),
-				IsRead:      false,
-			})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Bookmark removed",
+		"post_id":       postID,
+		"is_bookmarked": false,
+	})
+}
 
-			// Send real-time notification
-			SendFollowNotification(int64(followingID), int64(followerID), "follow",
-				followerName+" started following you", int64(followerID))
-		}
+// GetBookmarksHandler lists the current user's saved posts, newest first. Posts that were
+// deleted or that the user can no longer see are returned as unavailable placeholders
+func GetBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	bookmarks, err := db.GetBookmarks(userID, limit, (page-1)*limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve bookmarks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, bookmark := range bookmarks {
+		postID := bookmark["post_id"].(int64)
+
+		post, err := db.GetPost(postID)
+		if err != nil || !canViewBookmarkedPost(userID, post) {
+			bookmark["available"] = false
+			bookmark["message"] = "This post is no longer available"
+			continue
+		}
+
+		userVote, err := db.GetUserVote(userID, postID, "post")
+		if err == nil {
+			post["user_vote"] = userVote
+		}
+		post["is_author"] = post["user_id"].(int64) == int64(userID)
+		post["is_bookmarked"] = true
+
+		bookmark["available"] = true
+		bookmark["post"] = post
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bookmarks": bookmarks,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// canViewBookmarkedPost checks that a post is published and visible to the user
+func canViewBookmarkedPost(userID int, post map[string]interface{}) bool {
+	if isPublished, _ := post["is_published"].(bool); !isPublished {
+		return false
+	}
+	return canViewPost(userID, post)
+}
+
+// exploreGroupInterval is how many posts are shown between suggested groups in the explore feed
+const exploreGroupInterval = 3
+
+// GetExploreFeedHandler returns trending public posts interleaved with suggested groups.
+// Each item is tagged with its type so posts and groups can be rendered in one stream
+func GetExploreFeedHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	posts, err := db.GetTrendingPosts(userID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range posts {
+		postUserID, ok := posts[i]["user_id"].(int64)
+		posts[i]["is_author"] = ok && int64(userID) == postUserID
+	}
+
+	// Every page gets its own slice of the suggestions so groups aren't repeated
+	groupsPerPage := (limit + exploreGroupInterval - 1) / exploreGroupInterval
+	groups, err := db.GetSuggestedGroups(int64(userID), page*groupsPerPage)
+	if err != nil {
+		fmt.Printf("Error getting suggested groups: %v\n", err)
+		groups = nil
+	}
+	if skip := (page - 1) * groupsPerPage; skip < len(groups) {
+		groups = groups[skip:]
+	} else {
+		groups = nil
+	}
+
+	// Insert a group after every few posts, leftover groups go at the end
+	items := []map[string]interface{}{}
+	for i, post := range posts {
+		items = append(items, map[string]interface{}{"type": "post", "post": post})
+		if (i+1)%exploreGroupInterval == 0 && len(groups) > 0 {
+			items = append(items, map[string]interface{}{"type": "group", "group": groups[0]})
+			groups = groups[1:]
+		}
+	}
+	for _, group := range groups {
+		items = append(items, map[string]interface{}{"type": "group", "group": group})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetUserPostsHandler retrieves another user's posts that the authenticated user is allowed to see
+func GetUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get target user ID from URL
+	vars := mux.Vars(r)
+	targetID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	targetUser, err := db.GetUserById(targetID)
+	if err != nil || targetUser == nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if targetID != userID {
+		// Users who blocked the viewer don't expose their posts
+		blocked, err := db.IsBlocked(int64(targetID), int64(userID))
+		if err != nil {
+			http.Error(w, "Failed to check block status", http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		// Private accounts only show posts to their followers
+		if isPublic, ok := targetUser["is_public"].(bool); ok && !isPublic {
+			following, err := db.IsFollowing(userID, targetID)
+			if err != nil {
+				http.Error(w, "Failed to check follow status", http.StatusInternalServerError)
+				return
+			}
+			if !following {
+				http.Error(w, "This account is private", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	// Parse pagination parameters
+	page := 1
+	limit := 10
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		pageNum, err := strconv.Atoi(pageStr)
+		if err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limitNum, err := strconv.Atoi(limitStr)
+		if err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	// Get the visible posts from the database
+	posts, err := db.GetUserVisiblePosts(int64(targetID), int64(userID), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to retrieve posts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Set is_author flag for each post
+	for i := range posts {
+		posts[i]["is_author"] = targetID == userID
+	}
+
+	// Return post data
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"posts": posts,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetPostHandler retrieves a specific post by ID
+func GetPostHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get post ID from URL
+	vars := mux.Vars(r)
+	postIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Post ID is required", http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get post data
+	post, err := db.GetPost(postID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			http.Error(w, "Post not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to retrieve post: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Check if the user is the post owner
+	postUserID, ok := post["user_id"].(int64)
+	if ok && int64(userID) == postUserID {
+		post["is_author"] = true
+	} else {
+		post["is_author"] = false
+	}
+
+	// Scheduled posts are only visible to their author until published
+	if isPublished, _ := post["is_published"].(bool); !isPublished && !post["is_author"].(bool) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Get user's vote on this post
+	userVote, err := db.GetUserVote(userID, postID, "post")
+	if err == nil {
+		post["user_vote"] = userVote
+	}
+
+	post["is_bookmarked"] = db.IsBookmarked(userID, postID)
+
+	// Get comments for this post
+	comments, err := db.GetCommentsByPostIDWithUserVotes(postID, userID)
+	if err == nil {
+		// Set is_author flag for each comment
+		for i := range comments {
+			commentUserID, ok := comments[i]["user_id"].(int64)
+			if ok && int64(userID) == commentUserID {
+				comments[i]["is_author"] = true
+			} else {
+				comments[i]["is_author"] = false
+			}
+
+			// Also set is_post_author flag if the user is the post author
+			if int64(userID) == postUserID {
+				comments[i]["is_post_author"] = true
+			} else {
+				comments[i]["is_post_author"] = false
+			}
+		}
+
+		post["comments"] = comments
+	}
+
+	// Return post data as JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// GetCommentCountHandler returns a post's comment count without loading the comments
+func GetCommentCountHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get post ID from URL
+	vars := mux.Vars(r)
+	postIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Post ID is required", http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Scheduled posts are only visible to their author until published
+	postUserID, _ := post["user_id"].(int64)
+	if isPublished, _ := post["is_published"].(bool); !isPublished && postUserID != int64(userID) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	if !canViewPost(userID, post) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	count, err := db.GetCommentCount(postID)
+	if err != nil {
+		fmt.Printf("Error counting comments for post %d: %v\n", postID, err)
+		http.Error(w, "Failed to count comments", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"post_id":        postID,
+		"comments_count": count,
+	})
+}
+
+// AddCommentHandler adds a comment to a post
+func AddCommentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get post ID from URL
+	vars := mux.Vars(r)
+	postIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Post ID is required", http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get post to check ownership
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Get post user ID
+	postUserID, ok := post["user_id"].(int64)
+	if !ok {
+		http.Error(w, "Failed to determine post ownership", http.StatusInternalServerError)
+		return
+	}
+
+	// Parse multipart form for file uploads
+	err = r.ParseMultipartForm(10 << 20) // 10 MB max
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	// Get form values
+	content := r.FormValue("content")
+
+	// Handle file upload
+	var imageURL string
+	image, status, err := saveUploadedImage(r, "image", "comments")
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if image != nil {
+		imageURL = image.URL
+	}
+
+	// Validate that we have either content or an image
+	if content == "" && imageURL == "" {
+		http.Error(w, "Either content or image is required", http.StatusBadRequest)
+		return
+	}
+
+	// Add comment to the database
+	commentID, err := db.AddComment(postID, int64(userID), content, imageURL)
+	if err != nil {
+		http.Error(w, "Failed to add comment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Notify users mentioned in the comment who can see the post
+	mentions := notifyMentions(userID, content, func(viewerID int) bool {
+		return canViewPost(viewerID, post)
+	}, "comment", mentionNotification, postID)
+
+	// Get all comments for the post
+	comments, err := db.GetCommentsByPostID(postID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve comments: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Set is_author flag for each comment
+	for i := range comments {
+		commentUserID, ok := comments[i]["user_id"].(int64)
+		if ok && int64(userID) == commentUserID {
+			comments[i]["is_author"] = true
+		} else {
+			comments[i]["is_author"] = false
+		}
+
+		// Also set is_post_author flag if the user is the post author
+		if int64(userID) == postUserID {
+			comments[i]["is_post_author"] = true
+		} else {
+			comments[i]["is_post_author"] = false
+		}
+	}
+
+	// Return comments data
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":       commentID,
+		"comments": comments,
+		"mentions": mentions,
+	})
+}
+
+// GetUserFollowersHandler retrieves followers for the authenticated user
+func GetUserFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get followers from the database
+	followers, err := db.GetUserFollowers(userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve followers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return followers data
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"followers": followers,
+	})
+}
+
+// //////////////////////////////////////////////////////////////////////////////////////////////////////////
+// GetUserFollowingHandler retrieves the users the authenticated user follows.
+// Each entry includes is_public and is_mutual so the UI can render follow-back state
+func GetUserFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	// Try reading userId from query string
+	queryID := r.URL.Query().Get("userId")
+
+	var userID int
+	var err error
+
+	if queryID != "" {
+		userID, err = strconv.Atoi(queryID)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		if !checkFollowListAccess(w, r, userID) {
+			return
+		}
+	} else {
+		// fallback to session-based authenticated user
+		session, err := store.Get(r, SessionCookieName)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var ok bool
+		userID, ok = session.Values["user_id"].(int)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	following, err := db.GetUserFollowing(userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve following: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"followings": following,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FollowUserHandler allows a user to follow another user
+func FollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followerID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user ID to follow from request
+	vars := mux.Vars(r)
+	userIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	followingID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Can't follow yourself
+	if followerID == followingID {
+		http.Error(w, "You cannot follow yourself", http.StatusBadRequest)
+		return
+	}
+
+	// Users who blocked the follower can't be followed or sent requests
+	blocked, err := db.IsBlocked(int64(followingID), int64(followerID))
+	if err != nil {
+		http.Error(w, "Failed to check block status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, "You cannot follow this user", http.StatusForbidden)
+		return
+	}
+
+	// Get user to check if account is public or private
+	userToFollow, err := db.GetUserById(followingID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	isPublic, ok := userToFollow["is_public"].(bool)
+	if !ok {
+		isPublic = true // Default to public if field is missing
+	}
+
+	if isPublic {
+		// Direct follow for public accounts
+		err = db.FollowUser(followerID, followingID)
+		if err != nil {
+			http.Error(w, "Failed to follow user: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Create notification for the user being followed
+		followerUser, err := db.GetUserById(followerID)
+		if err == nil {
+			followerName := followerUser["first_name"].(string) + " " + followerUser["last_name"].(string)
+			if db.ShouldNotify(int64(followingID), sqlite.NotificationCategoryFollows) {
+				db.CreateNotification(&sqlite.Notification{
+					ReceiverID:  int64(followingID),
+					SenderID:    int64(followerID),
+					Type:        "follow",
+					Content:     followerName + " started following you",
+					ReferenceID: int64(followerID),
+					IsRead:      false,
+				})
+			}
+
+			// Send real-time notification
+			SendFollowNotification(int64(followingID), int64(followerID), "follow",
+				followerName+" started following you", int64(followerID))
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -636,14 +1491,16 @@ func FollowUserHandler(w http.ResponseWriter, r *http.Request) {
 		followerUser, err := db.GetUserById(followerID)
 		if err == nil {
 			followerName := followerUser["first_name"].(string) + " " + followerUser["last_name"].(string)
-			db.CreateNotification(&sqlite.Notification{
-				ReceiverID:  int64(followingID),
-				SenderID:    int64(followerID),
-				Type:        "follow_request",
-				Content:     followerName + " wants to follow you",
-				ReferenceID: requestID,
-				IsRead:      false,
-			})
+			if db.ShouldNotify(int64(followingID), sqlite.NotificationCategoryFollows) {
+				db.CreateNotification(&sqlite.Notification{
+					ReceiverID:  int64(followingID),
+					SenderID:    int64(followerID),
+					Type:        "follow_request",
+					Content:     followerName + " wants to follow you",
+					ReferenceID: requestID,
+					IsRead:      false,
+				})
+			}
 
 			// Send real-time notification
 			SendFollowNotification(int64(followingID), int64(followerID), "follow_request",
@@ -659,8 +1516,272 @@ func FollowUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetFollowStatusHandler checks if a user is following another user or has a pending follow request
-func GetFollowStatusHandler(w http.ResponseWriter, r *http.Request) {
+// GetFollowStatusHandler checks if a user is following another user or has a pending follow request
+func GetFollowStatusHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followerID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user ID to check from request
+	vars := mux.Vars(r)
+	userIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	followingID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if the user is following
+	isFollowing, err := db.IsFollowing(followerID, followingID)
+	if err != nil {
+		http.Error(w, "Failed to check follow status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Check if a follow request exists
+	followRequestSent, err := db.CheckFollowRequestExists(int64(followerID), int64(followingID))
+	if err != nil {
+		http.Error(w, "Failed to check follow request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"isFollowing":       isFollowing,
+		"followRequestSent": followRequestSent,
+	})
+}
+
+// UnfollowUserHandler allows a user to unfollow another user
+func UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followerID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user ID to unfollow from request
+	vars := mux.Vars(r)
+	userIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	followingID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Remove follow relationship
+	err = db.UnfollowUser(followerID, followingID)
+	if err != nil {
+		http.Error(w, "Failed to unfollow user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "User unfollowed successfully",
+	})
+}
+
+// AcceptFollowRequestHandler allows a user to accept a follow request
+func AcceptFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get request ID from URL
+	vars := mux.Vars(r)
+	requestIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get follow request to verify it's for this user
+	request, err := db.GetFollowRequest(requestID)
+	if err != nil {
+		http.Error(w, "Follow request not found", http.StatusNotFound)
+		return
+	}
+
+	if request.FollowingID != int64(userID) {
+		http.Error(w, "Unauthorized to accept this request", http.StatusForbidden)
+		return
+	}
+
+	// Accept the follow request
+	err = db.AcceptFollowRequest(requestID)
+	if err != nil {
+		http.Error(w, "Failed to accept follow request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Create notification for accepted request
+	// Get follower user info for the notification
+	followingUser, err := db.GetUserById(userID)
+	if err == nil {
+		followerID := request.FollowerID
+		followingName := followingUser["first_name"].(string) + " " + followingUser["last_name"].(string)
+		if db.ShouldNotify(followerID, sqlite.NotificationCategoryFollows) {
+			db.CreateNotification(&sqlite.Notification{
+				ReceiverID:  followerID,
+				SenderID:    int64(userID),
+				Type:        "follow_accepted",
+				Content:     followingName + " accepted your follow request",
+				ReferenceID: int64(userID),
+				IsRead:      false,
+			})
+		}
+
+		// Send real-time notification
+		SendFollowNotification(followerID, int64(userID), "follow_accepted",
+			followingName+" accepted your follow request", int64(userID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Follow request accepted successfully",
+	})
+}
+
+// RejectFollowRequestHandler allows a user to reject a follow request
+func RejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get request ID from URL
+	vars := mux.Vars(r)
+	requestIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if the follow request still exists
+	exists, err := db.CheckFollowRequestExistsById(requestID)
+	if err != nil {
+		fmt.Printf("Error checking if follow request exists: %v\n", err)
+		// Continue to try get the request
+	} else if !exists {
+		// The request doesn't exist anymore, it might have been deleted by another operation
+		fmt.Printf("Follow request %d doesn't exist in the database\n", requestID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Follow request already processed",
+		})
+		return
+	}
+
+	// Get follow request to verify it's for this user
+	request, err := db.GetFollowRequest(requestID)
+	if err != nil {
+		if err.Error() == "follow request not found" {
+			// Special case: request not found but might have been deleted by a race condition
+			fmt.Printf("Follow request %d not found\n", requestID)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Follow request already processed",
+			})
+			return
+		}
+		http.Error(w, "Failed to get follow request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if request == nil {
+		fmt.Printf("Follow request %d is nil\n", requestID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Follow request already processed",
+		})
+		return
+	}
+
+	if request.FollowingID != int64(userID) {
+		http.Error(w, "Unauthorized to reject this request", http.StatusForbidden)
+		return
+	}
+
+	// Reject the follow request
+	err = db.RejectFollowRequest(requestID)
+	if err != nil {
+		if err.Error() == "follow request not found" {
+			// Special case: request not found but might have been deleted by a race condition
+			fmt.Printf("Follow request %d not found during rejection\n", requestID)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Follow request already processed",
+			})
+			return
+		}
+		http.Error(w, "Failed to reject follow request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Follow request rejected successfully",
+	})
+}
+
+// DeletePostHandler removes a post by ID
+func DeletePostHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -668,49 +1789,58 @@ func GetFollowStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	followerID, ok := session.Values["user_id"].(int)
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get user ID to check from request
+	// Get post ID from URL
 	vars := mux.Vars(r)
-	userIDStr, ok := vars["id"]
+	postIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		http.Error(w, "Post ID is required", http.StatusBadRequest)
 		return
 	}
 
-	followingID, err := strconv.Atoi(userIDStr)
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the user is following
-	isFollowing, err := db.IsFollowing(followerID, followingID)
+	// Get post to check if the user is the owner
+	post, err := db.GetPost(postID)
 	if err != nil {
-		http.Error(w, "Failed to check follow status: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Check if a follow request exists
-	followRequestSent, err := db.CheckFollowRequestExists(int64(followerID), int64(followingID))
+	// Check if the user is the owner of the post
+	postUserID, ok := post["user_id"].(int64)
+	if !ok || int64(userID) != postUserID {
+		http.Error(w, "Unauthorized to delete this post", http.StatusForbidden)
+		return
+	}
+
+	// Hide the post, it is purged with its comments and votes once the restore window has passed
+	err = db.SoftDeletePost(postID, int64(userID))
 	if err != nil {
-		http.Error(w, "Failed to check follow request: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to delete post: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Return success response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"isFollowing":       isFollowing,
-		"followRequestSent": followRequestSent,
+		"message":                "Post deleted successfully",
+		"post_id":                postID,
+		"restore_window_seconds": int(sqlite.DeletedPostGracePeriod.Seconds()),
 	})
 }
 
-// UnfollowUserHandler allows a user to unfollow another user
-func UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+// RestorePostHandler undoes a post deletion within the restore window
+func RestorePostHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -718,41 +1848,47 @@ func UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	followerID, ok := session.Values["user_id"].(int)
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get user ID to unfollow from request
 	vars := mux.Vars(r)
-	userIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	followingID, err := strconv.Atoi(userIDStr)
+	err = db.RestorePost(postID, int64(userID))
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		switch err.Error() {
+		case "post not found":
+			http.Error(w, "Post not found", http.StatusNotFound)
+		case "restore window expired":
+			http.Error(w, "The post can no longer be restored", http.StatusGone)
+		default:
+			http.Error(w, "Failed to restore post: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	// Remove follow relationship
-	err = db.UnfollowUser(followerID, followingID)
+	post, err := db.GetPost(postID)
 	if err != nil {
-		http.Error(w, "Failed to unfollow user: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to get restored post", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User unfollowed successfully",
+		"message": "Post restored successfully",
+		"post":    post,
 	})
 }
 
-// AcceptFollowRequestHandler allows a user to accept a follow request
-func AcceptFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+// GetCommentHandler retrieves a single comment with the caller's vote, for deep links to a comment
+func GetCommentHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -766,67 +1902,199 @@ func AcceptFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get request ID from URL
 	vars := mux.Vars(r)
-	requestIDStr, ok := vars["id"]
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	commentID, err := strconv.ParseInt(vars["commentId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	// Scheduled posts are only visible to their author until published
+	postUserID, _ := post["user_id"].(int64)
+	if isPublished, _ := post["is_published"].(bool); !isPublished && postUserID != int64(userID) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	if !canViewPost(userID, post) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	commentPostID, _ := comment["post_id"].(int64)
+	if commentPostID != postID {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	userVote, err := db.GetUserVote(userID, commentID, "comment")
+	if err != nil {
+		userVote = 0 // Default if there's an error
+	}
+
+	commentUserID, _ := comment["user_id"].(int64)
+	comment["user_vote"] = userVote
+	comment["is_author"] = int64(userID) == commentUserID
+	comment["is_post_author"] = int64(userID) == postUserID
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// EditCommentHandler lets a comment's author change its content and replace or remove its image
+func EditCommentHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
-		http.Error(w, "Request ID is required", http.StatusBadRequest)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get follow request to verify it's for this user
-	request, err := db.GetFollowRequest(requestID)
+	commentID, err := strconv.ParseInt(vars["commentId"], 10, 64)
 	if err != nil {
-		http.Error(w, "Follow request not found", http.StatusNotFound)
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
 		return
 	}
 
-	if request.FollowingID != int64(userID) {
-		http.Error(w, "Unauthorized to accept this request", http.StatusForbidden)
+	// Get the comment to check if the user is the author
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil {
+		http.Error(w, "Comment not found", http.StatusNotFound)
 		return
 	}
 
-	// Accept the follow request
-	err = db.AcceptFollowRequest(requestID)
+	commentPostID, _ := comment["post_id"].(int64)
+	if commentPostID != postID {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	commentUserID, ok := comment["user_id"].(int64)
+	if !ok || int64(userID) != commentUserID {
+		http.Error(w, "Unauthorized to edit this comment", http.StatusForbidden)
+		return
+	}
+
+	post, err := db.GetPost(postID)
 	if err != nil {
-		http.Error(w, "Failed to accept follow request: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Create notification for accepted request
-	// Get follower user info for the notification
-	followingUser, err := db.GetUserById(userID)
-	if err == nil {
-		followerID := request.FollowerID
-		followingName := followingUser["first_name"].(string) + " " + followingUser["last_name"].(string)
-		db.CreateNotification(&sqlite.Notification{
-			ReceiverID:  followerID,
-			SenderID:    int64(userID),
-			Type:        "follow_accepted",
-			Content:     followingName + " accepted your follow request",
-			ReferenceID: int64(userID),
-			IsRead:      false,
-		})
+	postUserID, ok := post["user_id"].(int64)
+	if !ok {
+		http.Error(w, "Failed to determine post ownership", http.StatusInternalServerError)
+		return
+	}
 
-		// Send real-time notification
-		SendFollowNotification(followerID, int64(userID), "follow_accepted",
-			followingName+" accepted your follow request", int64(userID))
+	// Parse multipart form for file uploads
+	err = r.ParseMultipartForm(10 << 20) // 10 MB max
+	if err != nil {
+		http.Error(w, "Unable to parse form", http.StatusBadRequest)
+		return
+	}
+
+	// Fields that are not sent keep their current values
+	content := comment["content"].(string)
+	if _, ok := r.PostForm["content"]; ok {
+		content = r.FormValue("content")
+	}
+
+	oldImageURL, _ := comment["image_url"].(string)
+	imageURL := oldImageURL
+
+	// Allow removing the existing image without uploading a new one
+	if r.FormValue("remove_image") == "true" {
+		imageURL = ""
+	}
+
+	// Handle file upload
+	image, status, err := saveUploadedImage(r, "image", "comments")
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if image != nil {
+		imageURL = image.URL
+	}
+
+	// Validate that we still have either content or an image
+	if strings.TrimSpace(content) == "" && imageURL == "" {
+		http.Error(w, "Either content or image is required", http.StatusBadRequest)
+		return
+	}
+
+	err = db.UpdateComment(commentID, content, imageURL)
+	if err != nil {
+		// Don't leave the newly uploaded image behind
+		if image != nil {
+			removeCommentUpload(image.URL)
+		}
+		http.Error(w, "Failed to update comment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Clean up the previous image if it was replaced or removed
+	if oldImageURL != "" && oldImageURL != imageURL {
+		removeCommentUpload(oldImageURL)
+	}
+
+	// Return updated comments for the post
+	comments, err := db.GetCommentsByPostID(postID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated comments", http.StatusInternalServerError)
+		return
+	}
+
+	// Set is_author flag for each comment
+	for i := range comments {
+		commentUserID, ok := comments[i]["user_id"].(int64)
+		comments[i]["is_author"] = ok && int64(userID) == commentUserID
+
+		// Also set is_post_author flag if the user is the post author
+		comments[i]["is_post_author"] = int64(userID) == postUserID
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Follow request accepted successfully",
+		"id":       commentID,
+		"comments": comments,
 	})
 }
 
-// RejectFollowRequestHandler allows a user to reject a follow request
-func RejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+// DeleteCommentHandler removes a comment by ID
+func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -840,89 +2108,113 @@ func RejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get request ID from URL
+	// Get post ID and comment ID from URL
 	vars := mux.Vars(r)
-	requestIDStr, ok := vars["id"]
+
+	postIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Request ID is required", http.StatusBadRequest)
+		http.Error(w, "Post ID is required", http.StatusBadRequest)
 		return
 	}
 
-	requestID, err := strconv.ParseInt(requestIDStr, 10, 64)
+	commentIDStr, ok := vars["commentId"]
+	if !ok {
+		http.Error(w, "Comment ID is required", http.StatusBadRequest)
+		return
+	}
+
+	postID, err := strconv.ParseInt(postIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the follow request still exists
-	exists, err := db.CheckFollowRequestExistsById(requestID)
+	commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
 	if err != nil {
-		fmt.Printf("Error checking if follow request exists: %v\n", err)
-		// Continue to try get the request
-	} else if !exists {
-		// The request doesn't exist anymore, it might have been deleted by another operation
-		fmt.Printf("Follow request %d doesn't exist in the database\n", requestID)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Follow request already processed",
-		})
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	// Get the comment to check if the user is the owner
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+
+	// Check if the user is the owner of the comment
+	commentUserID, ok := comment["user_id"].(int64)
+	if !ok {
+		http.Error(w, "Failed to determine comment ownership", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the post to check if the user is the post owner (post owners can delete any comment on their post)
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Post not found", http.StatusNotFound)
 		return
 	}
 
-	// Get follow request to verify it's for this user
-	request, err := db.GetFollowRequest(requestID)
-	if err != nil {
-		if err.Error() == "follow request not found" {
-			// Special case: request not found but might have been deleted by a race condition
-			fmt.Printf("Follow request %d not found\n", requestID)
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"message": "Follow request already processed",
-			})
-			return
-		}
-		http.Error(w, "Failed to get follow request: "+err.Error(), http.StatusInternalServerError)
+	postUserID, ok := post["user_id"].(int64)
+	if !ok {
+		http.Error(w, "Failed to determine post ownership", http.StatusInternalServerError)
 		return
 	}
 
-	if request == nil {
-		fmt.Printf("Follow request %d is nil\n", requestID)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "Follow request already processed",
-		})
+	// Allow deletion only if the user is the comment owner or the post owner
+	if int64(userID) != commentUserID && int64(userID) != postUserID {
+		http.Error(w, "Unauthorized to delete this comment", http.StatusForbidden)
 		return
 	}
 
-	if request.FollowingID != int64(userID) {
-		http.Error(w, "Unauthorized to reject this request", http.StatusForbidden)
+	// Delete the comment
+	err = db.DeleteComment(commentID)
+	if err != nil {
+		http.Error(w, "Failed to delete comment: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Reject the follow request
-	err = db.RejectFollowRequest(requestID)
+	// Return updated comments for the post
+	comments, err := db.GetCommentsByPostID(postID)
 	if err != nil {
-		if err.Error() == "follow request not found" {
-			// Special case: request not found but might have been deleted by a race condition
-			fmt.Printf("Follow request %d not found during rejection\n", requestID)
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"message": "Follow request already processed",
-			})
-			return
-		}
-		http.Error(w, "Failed to reject follow request: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to retrieve updated comments", http.StatusInternalServerError)
 		return
 	}
 
+	// Set is_author flag for each comment
+	for i := range comments {
+		commentUserID, ok := comments[i]["user_id"].(int64)
+		if ok && int64(userID) == commentUserID {
+			comments[i]["is_author"] = true
+		} else {
+			comments[i]["is_author"] = false
+		}
+
+		// Also set is_post_author flag if the user is the post author
+		if int64(userID) == postUserID {
+			comments[i]["is_post_author"] = true
+		} else {
+			comments[i]["is_post_author"] = false
+		}
+	}
+
+	// Return success response with updated comments
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Follow request rejected successfully",
+		"message":  "Comment deleted successfully",
+		"comments": comments,
 	})
 }
 
-// DeletePostHandler removes a post by ID
-func DeletePostHandler(w http.ResponseWriter, r *http.Request) {
+// VotePostHandler handles upvotes and downvotes on posts
+func VotePostHandler(w http.ResponseWriter, r *http.Request) {
+	// Handle CORS preflight request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -950,36 +2242,50 @@ func DeletePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get post to check if the user is the owner
-	post, err := db.GetPost(postID)
+	// Parse request body
+	var voteRequest struct {
+		VoteType int `json:"vote_type"` // 1 for upvote, -1 for downvote
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&voteRequest)
 	if err != nil {
-		http.Error(w, "Post not found", http.StatusNotFound)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the user is the owner of the post
-	postUserID, ok := post["user_id"].(int64)
-	if !ok || int64(userID) != postUserID {
-		http.Error(w, "Unauthorized to delete this post", http.StatusForbidden)
+	// Validate vote type
+	if voteRequest.VoteType != 1 && voteRequest.VoteType != -1 {
+		http.Error(w, "Invalid vote type. Must be 1 (upvote) or -1 (downvote)", http.StatusBadRequest)
 		return
 	}
 
-	// Delete the post
-	err = db.DeletePost(postID)
+	// Apply the vote
+	err = db.Vote(userID, postID, "post", voteRequest.VoteType)
 	if err != nil {
-		http.Error(w, "Failed to delete post: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to vote on post: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Return success response
+	// Get updated post
+	post, err := db.GetPost(postID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
+		return
+	}
+
+	// Return updated post data
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Post deleted successfully along with all associated comments and votes",
-	})
+	json.NewEncoder(w).Encode(post)
 }
 
-// DeleteCommentHandler removes a comment by ID
-func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+// VoteCommentHandler handles upvotes and downvotes on comments
+func VoteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	// Handle CORS preflight request
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -1020,86 +2326,142 @@ func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the comment to check if the user is the owner
-	comment, err := db.GetCommentByID(commentID)
+	// Parse request body
+	var voteRequest struct {
+		VoteType int `json:"vote_type"` // 1 for upvote, -1 for downvote
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&voteRequest)
 	if err != nil {
-		http.Error(w, "Comment not found", http.StatusNotFound)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the user is the owner of the comment
-	commentUserID, ok := comment["user_id"].(int64)
-	if !ok {
-		http.Error(w, "Failed to determine comment ownership", http.StatusInternalServerError)
+	// Validate vote type
+	if voteRequest.VoteType != 1 && voteRequest.VoteType != -1 {
+		http.Error(w, "Invalid vote type. Must be 1 (upvote) or -1 (downvote)", http.StatusBadRequest)
 		return
 	}
 
-	// Get the post to check if the user is the post owner (post owners can delete any comment on their post)
-	post, err := db.GetPost(postID)
+	// Apply the vote
+	err = db.Vote(userID, commentID, "comment", voteRequest.VoteType)
 	if err != nil {
-		http.Error(w, "Post not found", http.StatusNotFound)
+		http.Error(w, "Failed to vote on comment: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	postUserID, ok := post["user_id"].(int64)
+	// Get the comment
+	comment, err := db.GetCommentByID(commentID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated comment", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the user's vote
+	userVote, err := db.GetUserVote(userID, commentID, "comment")
+	if err != nil {
+		userVote = 0 // Default if there's an error
+	}
+
+	// Add vote information to the response
+	response := map[string]interface{}{
+		"comment":    comment,
+		"user_vote":  userVote,
+		"vote_count": comment["vote_count"],
+		"post_id":    postID,
+	}
+
+	// Return updated comment data
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CancelFollowRequestHandler allows a user to cancel their follow request
+func CancelFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	followerID, ok := session.Values["user_id"].(int)
 	if !ok {
-		http.Error(w, "Failed to determine post ownership", http.StatusInternalServerError)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Allow deletion only if the user is the comment owner or the post owner
-	if int64(userID) != commentUserID && int64(userID) != postUserID {
-		http.Error(w, "Unauthorized to delete this comment", http.StatusForbidden)
+	// Get user ID to unfollow from request
+	vars := mux.Vars(r)
+	userIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Delete the comment
-	err = db.DeleteComment(commentID)
+	followingID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		http.Error(w, "Failed to delete comment: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	// Return updated comments for the post
-	comments, err := db.GetCommentsByPostID(postID)
+	// Cancel the follow request
+	err = db.CancelFollowRequest(int64(followerID), int64(followingID))
 	if err != nil {
-		http.Error(w, "Failed to retrieve updated comments", http.StatusInternalServerError)
+		http.Error(w, "Failed to cancel follow request: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Set is_author flag for each comment
-	for i := range comments {
-		commentUserID, ok := comments[i]["user_id"].(int64)
-		if ok && int64(userID) == commentUserID {
-			comments[i]["is_author"] = true
-		} else {
-			comments[i]["is_author"] = false
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Follow request canceled successfully",
+	})
+}
 
-		// Also set is_post_author flag if the user is the post author
-		if int64(userID) == postUserID {
-			comments[i]["is_post_author"] = true
-		} else {
-			comments[i]["is_post_author"] = false
-		}
+// RemoveFollowerHandler allows a user to remove someone from their followers list
+func RemoveFollowerHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session (the user who wants to remove a follower)
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get follower ID to remove from request
+	vars := mux.Vars(r)
+	followerIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "Follower ID is required", http.StatusBadRequest)
+		return
+	}
+
+	followerID, err := strconv.Atoi(followerIDStr)
+	if err != nil {
+		http.Error(w, "Invalid follower ID", http.StatusBadRequest)
+		return
+	}
+
+	// Remove the follow relationship (the followerID follows userID, so we unfollow)
+	err = db.UnfollowUser(followerID, userID)
+	if err != nil {
+		http.Error(w, "Failed to remove follower: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Return success response with updated comments
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  "Comment deleted successfully",
-		"comments": comments,
+		"message": "Follower removed successfully",
 	})
 }
 
-// VotePostHandler handles upvotes and downvotes on posts
-func VotePostHandler(w http.ResponseWriter, r *http.Request) {
-	// Handle CORS preflight request
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
+// BlockUserHandler blocks another user and removes any follow relationship between them
+func BlockUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -1113,64 +2475,92 @@ func VotePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get post ID from URL
+	// Get user ID to block from request
 	vars := mux.Vars(r)
-	postIDStr, ok := vars["id"]
+	blockedIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Post ID is required", http.StatusBadRequest)
+		http.Error(w, "User ID is required", http.StatusBadRequest)
 		return
 	}
 
-	postID, err := strconv.ParseInt(postIDStr, 10, 64)
+	blockedID, err := strconv.Atoi(blockedIDStr)
 	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	// Parse request body
-	var voteRequest struct {
-		VoteType int `json:"vote_type"` // 1 for upvote, -1 for downvote
+	if userID == blockedID {
+		http.Error(w, "You cannot block yourself", http.StatusBadRequest)
+		return
 	}
 
-	err = json.NewDecoder(r.Body).Decode(&voteRequest)
+	if _, err := db.GetUserById(blockedID); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Also removes follows, follow requests and close friends in both directions
+	err = db.BlockUser(int64(userID), int64(blockedID))
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, "Failed to block user: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Validate vote type
-	if voteRequest.VoteType != 1 && voteRequest.VoteType != -1 {
-		http.Error(w, "Invalid vote type. Must be 1 (upvote) or -1 (downvote)", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "User blocked successfully",
+		"status":  "blocked",
+	})
+}
+
+// UnblockUserHandler removes a block on another user
+func UnblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Apply the vote
-	err = db.Vote(userID, postID, "post", voteRequest.VoteType)
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Get user ID to unblock from request
+	vars := mux.Vars(r)
+	blockedIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	blockedID, err := strconv.Atoi(blockedIDStr)
 	if err != nil {
-		http.Error(w, "Failed to vote on post: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get updated post
-	post, err := db.GetPost(postID)
+	err = db.UnblockUser(int64(userID), int64(blockedID))
 	if err != nil {
-		http.Error(w, "Failed to retrieve updated post", http.StatusInternalServerError)
+		if err.Error() == "user is not blocked" {
+			http.Error(w, "User is not blocked", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to unblock user: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Return updated post data
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(post)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "User unblocked successfully",
+		"status":  "unblocked",
+	})
 }
 
-// VoteCommentHandler handles upvotes and downvotes on comments
-func VoteCommentHandler(w http.ResponseWriter, r *http.Request) {
-	// Handle CORS preflight request
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
+// CreateReportHandler reports a post or comment for moderation
+func CreateReportHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -1184,85 +2574,149 @@ func VoteCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get post ID and comment ID from URL
-	vars := mux.Vars(r)
-
-	postIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Post ID is required", http.StatusBadRequest)
+	var req struct {
+		ContentID   int64  `json:"content_id"`
+		ContentType string `json:"content_type"`
+		Reason      string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	commentIDStr, ok := vars["commentId"]
-	if !ok {
-		http.Error(w, "Comment ID is required", http.StatusBadRequest)
+	if _, ok := sqlite.ReportContentTables[req.ContentType]; !ok {
+		http.Error(w, "Invalid content type. Must be one of: post, comment, group_post, group_post_comment", http.StatusBadRequest)
 		return
 	}
 
-	postID, err := strconv.ParseInt(postIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
 		return
 	}
 
-	commentID, err := strconv.ParseInt(commentIDStr, 10, 64)
+	exists, err := db.ReportedContentExists(req.ContentID, req.ContentType)
 	if err != nil {
-		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		http.Error(w, "Failed to check content", http.StatusInternalServerError)
 		return
 	}
-
-	// Parse request body
-	var voteRequest struct {
-		VoteType int `json:"vote_type"` // 1 for upvote, -1 for downvote
+	if !exists {
+		http.Error(w, "Content not found", http.StatusNotFound)
+		return
 	}
 
-	err = json.NewDecoder(r.Body).Decode(&voteRequest)
+	// Content the user can't see is treated as missing so reports don't reveal it exists
+	canView, err := canViewReportedContent(userID, req.ContentID, req.ContentType)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, "Failed to check content", http.StatusInternalServerError)
 		return
 	}
-
-	// Validate vote type
-	if voteRequest.VoteType != 1 && voteRequest.VoteType != -1 {
-		http.Error(w, "Invalid vote type. Must be 1 (upvote) or -1 (downvote)", http.StatusBadRequest)
+	if !canView {
+		http.Error(w, "Content not found", http.StatusNotFound)
 		return
 	}
 
-	// Apply the vote
-	err = db.Vote(userID, commentID, "comment", voteRequest.VoteType)
+	reportID, err := db.CreateReport(int64(userID), req.ContentID, req.ContentType, reason)
 	if err != nil {
-		http.Error(w, "Failed to vote on comment: "+err.Error(), http.StatusInternalServerError)
+		if err.Error() == "content already reported" {
+			http.Error(w, "You have already reported this content", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create report", http.StatusInternalServerError)
 		return
 	}
 
-	// Get the comment
-	comment, err := db.GetCommentByID(commentID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"report_id": reportID,
+		"message":   "Report submitted",
+	})
+}
+
+// canViewReportedContent checks that the user can see the content they're reporting.
+// Comments follow the visibility of their post, group content requires membership
+func canViewReportedContent(userID int, contentID int64, contentType string) (bool, error) {
+	switch contentType {
+	case "post", "comment":
+		postID := contentID
+		if contentType == "comment" {
+			comment, err := db.GetCommentByID(contentID)
+			if err != nil {
+				return false, err
+			}
+			postID, _ = comment["post_id"].(int64)
+		}
+
+		post, err := db.GetPost(postID)
+		if err != nil {
+			return false, err
+		}
+
+		// Scheduled posts are only visible to their author until published
+		postUserID, _ := post["user_id"].(int64)
+		if isPublished, _ := post["is_published"].(bool); !isPublished && postUserID != int64(userID) {
+			return false, nil
+		}
+
+		return canViewPost(userID, post), nil
+
+	case "group_post", "group_post_comment":
+		postID := contentID
+		if contentType == "group_post_comment" {
+			comment, err := db.GetGroupPostComment(contentID, int64(userID))
+			if err != nil || comment == nil {
+				return false, err
+			}
+			postID = comment.PostID
+		}
+
+		post, err := db.GetGroupPost(postID, int64(userID))
+		if err != nil || post == nil {
+			return false, err
+		}
+
+		// Drafts are only visible to their author
+		if post.IsDraft && post.AuthorID != int64(userID) {
+			return false, nil
+		}
+
+		return db.IsGroupMember(post.GroupID, int64(userID)), nil
+	}
+
+	return false, nil
+}
+
+// GetCloseFriendsHandler returns the current user's close friends list
+func GetCloseFriendsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
+	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
-		http.Error(w, "Failed to retrieve updated comment", http.StatusInternalServerError)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get the user's vote
-	userVote, err := db.GetUserVote(userID, commentID, "comment")
-	if err != nil {
-		userVote = 0 // Default if there's an error
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Add vote information to the response
-	response := map[string]interface{}{
-		"comment":    comment,
-		"user_vote":  userVote,
-		"vote_count": comment["vote_count"],
-		"post_id":    postID,
+	friends, err := db.GetCloseFriends(userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve close friends: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Return updated comment data
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"close_friends": friends,
+	})
 }
 
-// CancelFollowRequestHandler allows a user to cancel their follow request
-func CancelFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+// AddCloseFriendHandler adds one of the current user's followers to their close friends
+func AddCloseFriendHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
@@ -1270,42 +2724,55 @@ func CancelFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	followerID, ok := session.Values["user_id"].(int)
+	userID, ok := session.Values["user_id"].(int)
 	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Get user ID to unfollow from request
 	vars := mux.Vars(r)
-	userIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	friendID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	followingID, err := strconv.Atoi(userIDStr)
+	if userID == friendID {
+		http.Error(w, "You cannot add yourself to close friends", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := db.GetUserById(friendID); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Private posts are only shared with followers, so close friends must follow the user
+	isFollower, err := db.IsFollowing(friendID, userID)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, "Failed to check follow status", http.StatusInternalServerError)
+		return
+	}
+	if !isFollower {
+		http.Error(w, "Only your followers can be added to close friends", http.StatusBadRequest)
 		return
 	}
 
-	// Cancel the follow request
-	err = db.CancelFollowRequest(int64(followerID), int64(followingID))
+	err = db.AddCloseFriend(userID, friendID)
 	if err != nil {
-		http.Error(w, "Failed to cancel follow request: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to add close friend: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Follow request canceled successfully",
+		"message": "Added to close friends",
 	})
 }
 
-// RemoveFollowerHandler allows a user to remove someone from their followers list
-func RemoveFollowerHandler(w http.ResponseWriter, r *http.Request) {
-	// Get user ID from session (the user who wants to remove a follower)
+// RemoveCloseFriendHandler removes a user from the current user's close friends
+func RemoveCloseFriendHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from session
 	session, err := store.Get(r, SessionCookieName)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -1318,30 +2785,26 @@ func RemoveFollowerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get follower ID to remove from request
 	vars := mux.Vars(r)
-	followerIDStr, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Follower ID is required", http.StatusBadRequest)
-		return
-	}
-
-	followerID, err := strconv.Atoi(followerIDStr)
+	friendID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid follower ID", http.StatusBadRequest)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	// Remove the follow relationship (the followerID follows userID, so we unfollow)
-	err = db.UnfollowUser(followerID, userID)
+	err = db.RemoveCloseFriend(userID, friendID)
 	if err != nil {
-		http.Error(w, "Failed to remove follower: "+err.Error(), http.StatusInternalServerError)
+		if err.Error() == "user is not a close friend" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to remove close friend: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Follower removed successfully",
+		"message": "Removed from close friends",
 	})
 }
 
@@ -1354,9 +2817,15 @@ func RegisterFollowRoutes(router *mux.Router) {
 	router.HandleFunc("/follow/request/{id}/reject", RejectFollowRequestHandler).Methods("POST", "OPTIONS")
 	router.HandleFunc("/follow/request/{id}/cancel", CancelFollowRequestHandler).Methods("POST", "OPTIONS")
 	router.HandleFunc("/followers/remove/{id}", RemoveFollowerHandler).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/users/{id}/block", BlockUserHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/users/{id}/block", UnblockUserHandler).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/close-friends", GetCloseFriendsHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/close-friends/{id}", AddCloseFriendHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/close-friends/{id}", RemoveCloseFriendHandler).Methods("DELETE", "OPTIONS")
 }
 
-// GetUserFollowingByIDHandler retrieves following list for a specific user
+// GetUserFollowingByIDHandler retrieves following list for a specific user,
+// including is_public and is_mutual for each followed account
 func GetUserFollowingByIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from URL path
 	vars := mux.Vars(r)
@@ -1372,6 +2841,10 @@ func GetUserFollowingByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkFollowListAccess(w, r, userID) {
+		return
+	}
+
 	// Get following list
 	following, err := db.GetUserFollowing(userID)
 	if err != nil {
@@ -1384,3 +2857,79 @@ func GetUserFollowingByIDHandler(w http.ResponseWriter, r *http.Request) {
 		"following": following,
 	})
 }
+
+// GetUserFollowersByIDHandler retrieves the followers of a specific user
+func GetUserFollowersByIDHandler(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from URL path
+	vars := mux.Vars(r)
+	userIDStr, ok := vars["id"]
+	if !ok {
+		http.Error(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if !checkFollowListAccess(w, r, userID) {
+		return
+	}
+
+	followers, err := db.GetUserFollowers(userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve followers: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"followers": followers,
+	})
+}
+
+// checkFollowListAccess reports whether the caller may see the target user's follower and
+// following lists, writing the error response if not. Public accounts are visible to everyone;
+// private accounts only to the user themselves and their followers
+func checkFollowListAccess(w http.ResponseWriter, r *http.Request, targetID int) bool {
+	targetUser, err := db.GetUserById(targetID)
+	if err != nil || targetUser == nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return false
+	}
+
+	if isPublic, ok := targetUser["is_public"].(bool); !ok || isPublic {
+		return true
+	}
+
+	// Anonymous callers can't follow anyone, so private lists stay hidden from them
+	session, err := store.Get(r, SessionCookieName)
+	if err != nil {
+		http.Error(w, "This account is private", http.StatusForbidden)
+		return false
+	}
+
+	viewerID, ok := session.Values["user_id"].(int)
+	if !ok {
+		http.Error(w, "This account is private", http.StatusForbidden)
+		return false
+	}
+
+	if viewerID == targetID {
+		return true
+	}
+
+	following, err := db.IsFollowing(viewerID, targetID)
+	if err != nil {
+		http.Error(w, "Failed to check follow status", http.StatusInternalServerError)
+		return false
+	}
+	if !following {
+		http.Error(w, "This account is private", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
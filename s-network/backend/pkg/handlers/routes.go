@@ -12,6 +12,8 @@ func RegisterAuthRoutes(router *mux.Router) {
 	router.HandleFunc("/logout", Logout).Methods("POST", "OPTIONS")
 	router.HandleFunc("/me", GetCurrentUser).Methods("GET", "OPTIONS")
 	router.HandleFunc("/check-nickname", CheckNicknameAvailability).Methods("GET", "OPTIONS")
+	router.HandleFunc("/forgot-password", RequestPasswordReset).Methods("POST", "OPTIONS")
+	router.HandleFunc("/reset-password", ResetPassword).Methods("POST", "OPTIONS")
 }
 
 // RegisterPostRoutes registers all post-related routes
@@ -19,13 +21,29 @@ func RegisterPostRoutes(router *mux.Router) {
 	// Posts routes
 	router.HandleFunc("/posts", GetPostsHandler).Methods("GET", "OPTIONS")
 	router.HandleFunc("/posts/explore", GetExplorePostsHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/explore/feed", GetExploreFeedHandler).Methods("GET", "OPTIONS")
 	router.HandleFunc("/posts", CreatePostHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/posts/scheduled", GetScheduledPostsHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/posts/scheduled/{id}", CancelScheduledPostHandler).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/posts/{id}", GetPostHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/posts/{id}", EditPostHandler).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/posts/{id}", DeletePostHandler).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/posts/{id}/restore", RestorePostHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/posts/{id}/audience", GetPostAudienceHandler).Methods("GET", "OPTIONS")
 	router.HandleFunc("/posts/{id}/comments", AddCommentHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/posts/{id}/comments/count", GetCommentCountHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/posts/{id}/comments/{commentId}", GetCommentHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/posts/{id}/comments/{commentId}", EditCommentHandler).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/posts/{id}/comments/{commentId}", DeleteCommentHandler).Methods("DELETE", "OPTIONS")
 	router.HandleFunc("/posts/{id}/vote", VotePostHandler).Methods("POST", "OPTIONS")
 	router.HandleFunc("/posts/{id}/comments/{commentId}/vote", VoteCommentHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/posts/{id}/bookmark", BookmarkPostHandler).Methods("POST", "OPTIONS")
+	router.HandleFunc("/posts/{id}/bookmark", RemoveBookmarkHandler).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/me/votes", GetVotedContentHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/bookmarks", GetBookmarksHandler).Methods("GET", "OPTIONS")
+
+	// Moderation routes
+	router.HandleFunc("/reports", CreateReportHandler).Methods("POST", "OPTIONS")
 }
 
 // RegisterProfileRoutes registers all profile-related routes
@@ -33,12 +51,25 @@ func RegisterProfileRoutes(router *mux.Router) {
 	// User profile routes
 	router.HandleFunc("/profile", GetProfile).Methods("GET", "OPTIONS")
 	router.HandleFunc("/profile/update", UpdateProfile).Methods("POST", "OPTIONS")
+	router.HandleFunc("/profile/avatar", DeleteAvatar).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/profile/banner", DeleteBanner).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/profile", DeleteAccount).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/profile/password", ChangePassword).Methods("POST", "OPTIONS")
+	router.HandleFunc("/profile/sessions", GetUserSessions).Methods("GET", "OPTIONS")
+	router.HandleFunc("/profile/sessions/{id}", RevokeSession).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/profile/notification-preferences", GetNotificationPreferences).Methods("GET", "OPTIONS")
+	router.HandleFunc("/profile/notification-preferences", UpdateNotificationPreferences).Methods("PUT", "OPTIONS")
 
 	// User data endpoints
 	router.HandleFunc("/users/me", GetCurrentUser).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/export", ExportAccountData).Methods("GET", "OPTIONS")
+	router.HandleFunc("/me/groups/leave", LeaveAllGroups).Methods("POST", "OPTIONS")
 	router.HandleFunc("/users/search", UserSearchHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/users/suggested", GetSuggestedUsersHandler).Methods("GET", "OPTIONS")
 	router.HandleFunc("/users/{id}", GetUsersProfile).Methods("GET", "OPTIONS")
+	router.HandleFunc("/users/{id}/followers", GetUserFollowersByIDHandler).Methods("GET", "OPTIONS")
 	router.HandleFunc("/users/{id}/following", GetUserFollowingByIDHandler).Methods("GET", "OPTIONS")
+	router.HandleFunc("/users/{id}/posts", GetUserPostsHandler).Methods("GET", "OPTIONS")
 
 	// Follow-related routes
 	router.HandleFunc("/followers", GetUserFollowersHandler).Methods("GET", "OPTIONS")
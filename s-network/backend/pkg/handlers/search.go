@@ -9,6 +9,12 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Number of user search results returned when no limit is given, and the most that can be requested
+const (
+	defaultUserSearchLimit = 20
+	maxUserSearchLimit     = 50
+)
+
 // UserSearchHandler handles search requests for users
 func UserSearchHandler(w http.ResponseWriter, r *http.Request) {
 	// Allow CORS preflight
@@ -35,11 +41,27 @@ func UserSearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Format the search term for SQL LIKE pattern
-	searchTerm := "%" + strings.ToLower(query) + "%"
+	limit := defaultUserSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxUserSearchLimit {
+		limit = maxUserSearchLimit
+	}
+
+	// Hide the viewer and users blocked in either direction; anonymous searches have no blocks to apply
+	var viewerID int64
+	if userID, err := getUserIDFromSession(r); err == nil {
+		viewerID = int64(userID)
+	}
 
 	// Search for users matching the query
-	users, err := db.SearchUsers(searchTerm)
+	users, err := db.SearchUsers(query, viewerID, limit)
 	if err != nil {
 		http.Error(w, "Error searching for users: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -79,3 +101,42 @@ func GetUsersProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// Number of suggestions returned when no limit is given, and the most that can be requested
+const (
+	defaultSuggestedUsersLimit = 10
+	maxSuggestedUsersLimit     = 50
+)
+
+// GetSuggestedUsersHandler returns people the current user might want to follow
+func GetSuggestedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromSession(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := defaultSuggestedUsersLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxSuggestedUsersLimit {
+		limit = maxSuggestedUsersLimit
+	}
+
+	users, err := db.GetSuggestedUsers(int64(userID), limit)
+	if err != nil {
+		http.Error(w, "Failed to get suggested users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users": users,
+	})
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxImageDimension is the largest width or height accepted for an uploaded image.
+// Anything bigger is rejected before decoding to avoid decompression bombs.
+const maxImageDimension = 8000
+
+// thumbnailMaxEdge is the size of the long edge of generated thumbnails
+const thumbnailMaxEdge = 400
+
+// ValidateImageDimensions reads the image header and rejects images that are too large to decode safely
+func ValidateImageDimensions(file multipart.File) error {
+	width, height, err := imageDimensions(file)
+	if err != nil {
+		return err
+	}
+
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid image dimensions")
+	}
+
+	if width > maxImageDimension || height > maxImageDimension {
+		return fmt.Errorf("image too large. Maximum dimensions are %dx%d, got %dx%d", maxImageDimension, maxImageDimension, width, height)
+	}
+
+	return nil
+}
+
+// imageDimensions returns the width and height of an image without decoding the pixel data
+func imageDimensions(file multipart.File) (int, int, error) {
+	file.Seek(0, 0)
+	defer file.Seek(0, 0)
+
+	header := make([]byte, 30)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, 0, fmt.Errorf("failed to read image header: %v", err)
+	}
+
+	// The standard library has no WebP decoder, so read the size from the bitstream header
+	if isWebP(header[:n]) {
+		return webpDimensions(header[:n])
+	}
+
+	file.Seek(0, 0)
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image dimensions: %v", err)
+	}
+
+	return config.Width, config.Height, nil
+}
+
+// webpDimensions parses the canvas size from the first chunk of a WebP file
+func webpDimensions(header []byte) (int, int, error) {
+	if len(header) < 30 {
+		return 0, 0, fmt.Errorf("webp header too short")
+	}
+
+	data := header[20:]
+	switch string(header[12:16]) {
+	case "VP8 ":
+		// Lossy: 3-byte frame tag, 3-byte start code, then 14-bit width and height
+		if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return 0, 0, fmt.Errorf("invalid webp frame header")
+		}
+		width := int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		// Lossless: signature byte, then 14-bit width-1 and height-1
+		if data[0] != 0x2f {
+			return 0, 0, fmt.Errorf("invalid webp lossless header")
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, nil
+	case "VP8X":
+		// Extended: 4 bytes of flags, then 24-bit canvas width-1 and height-1
+		width := int(data[4]) | int(data[5])<<8 | int(data[6])<<16
+		height := int(data[7]) | int(data[8])<<8 | int(data[9])<<16
+		return width + 1, height + 1, nil
+	}
+
+	return 0, 0, fmt.Errorf("unsupported webp format")
+}
+
+// CreateThumbnail writes a downscaled copy of a saved image next to the original, using a _thumb suffix.
+// It returns the thumbnail's filename. WebP images can't be decoded with the standard library,
+// so an empty filename is returned for them and callers should fall back to the original image.
+func CreateThumbnail(uploadsDir, filename, mimeType string) (string, error) {
+	if mimeType == "image/webp" {
+		return "", nil
+	}
+
+	src, err := os.Open(filepath.Join(uploadsDir, filename))
+	if err != nil {
+		return "", fmt.Errorf("failed to open image: %v", err)
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	thumb := resizeImage(img, thumbnailMaxEdge)
+
+	// JPEGs stay JPEG; PNG and GIF thumbnails are saved as PNG to keep transparency
+	ext := ".png"
+	if mimeType == "image/jpeg" {
+		ext = ".jpg"
+	}
+	thumbFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_thumb" + ext
+
+	dst, err := os.Create(filepath.Join(uploadsDir, thumbFilename))
+	if err != nil {
+		return "", fmt.Errorf("failed to create thumbnail: %v", err)
+	}
+	defer dst.Close()
+
+	if ext == ".jpg" {
+		err = jpeg.Encode(dst, thumb, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(dst, thumb)
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to encode thumbnail: %v", err)
+	}
+
+	return thumbFilename, nil
+}
+
+// thumbnailFilenames lists the thumbnail names that CreateThumbnail may have written for an image
+func thumbnailFilenames(filename string) []string {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_thumb"
+	return []string{base + ".jpg", base + ".png"}
+}
+
+// resizeImage scales an image down so its long edge is at most maxEdge, averaging the
+// source pixels that fall into each destination pixel. Smaller images are returned unchanged.
+func resizeImage(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxEdge && srcH <= maxEdge {
+		return img
+	}
+
+	dstW, dstH := maxEdge, maxEdge
+	if srcW > srcH {
+		dstH = srcH * maxEdge / srcW
+	} else {
+		dstW = srcW * maxEdge / srcH
+	}
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := bounds.Min.Y + (y+1)*srcH/dstH
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := bounds.Min.X + (x+1)*srcW/dstW
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					count++
+				}
+			}
+			if count == 0 {
+				continue
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// orphanedUploadGracePeriod keeps recently written files, since an upload is saved
+// to disk before the row that references it is inserted
+const orphanedUploadGracePeriod = 1 * time.Hour
+
+// CleanupOrphanedUploads deletes files under uploadsRoot that are no longer referenced
+// by any post, comment, group, avatar, banner or chat attachment. Generated thumbnails
+// are kept as long as their original image is referenced. In dry-run mode nothing is
+// deleted and the files that would be removed are only logged.
+// It returns the number of orphaned files found.
+func CleanupOrphanedUploads(uploadsRoot string, dryRun bool) (int, error) {
+	paths, err := db.GetAllReferencedImagePaths()
+	if err != nil {
+		return 0, err
+	}
+
+	referenced := make(map[string]bool)
+	for p := range paths {
+		rel := uploadRelativePath(p)
+		referenced[rel] = true
+
+		dir := path.Dir(rel)
+		for _, thumb := range thumbnailFilenames(path.Base(rel)) {
+			referenced[path.Join(dir, thumb)] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-orphanedUploadGracePeriod)
+	orphaned := 0
+
+	err = filepath.WalkDir(uploadsRoot, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		// Leave hidden files and bundled defaults such as avatars/default.jpg alone
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "default") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(uploadsRoot, fullPath)
+		if err != nil {
+			return err
+		}
+		if referenced[filepath.ToSlash(rel)] {
+			return nil
+		}
+
+		orphaned++
+		if dryRun {
+			log.Printf("Upload cleanup (dry run): would delete %s", fullPath)
+			return nil
+		}
+
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing orphaned upload %s: %v", fullPath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return orphaned, fmt.Errorf("failed to walk uploads directory: %v", err)
+	}
+
+	return orphaned, nil
+}
+
+// uploadRelativePath converts a stored upload URL such as "/uploads/posts/a.jpg"
+// or "http://host/uploads/posts/a.jpg" into a path relative to the uploads root
+func uploadRelativePath(storedPath string) string {
+	if i := strings.LastIndex(storedPath, "/uploads/"); i != -1 {
+		return storedPath[i+len("/uploads/"):]
+	}
+	return strings.TrimPrefix(storedPath, "/")
+}
+
+// PurgeDeletedPosts permanently removes posts and group posts whose restore window has
+// passed, together with the images of the posts and their comments.
+// It returns the number of images removed.
+func PurgeDeletedPosts() (int, error) {
+	purged, err := db.PurgeDeletedPosts()
+	if err != nil {
+		return 0, err
+	}
+	for _, image := range purged.Posts {
+		removePostUpload(image)
+	}
+	for _, image := range purged.Comments {
+		removeCommentUpload(image)
+	}
+	removed := len(purged.Posts) + len(purged.Comments)
+
+	purgedGroup, err := db.PurgeDeletedGroupPosts()
+	if err != nil {
+		return removed, err
+	}
+	for _, image := range purgedGroup.Posts {
+		removeGroupUpload(image)
+	}
+	// Group post comments share the comments upload directory
+	for _, image := range purgedGroup.Comments {
+		removeCommentUpload(image)
+	}
+
+	return removed + len(purgedGroup.Posts) + len(purgedGroup.Comments), nil
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// SMTPConfig holds the settings for sending mail through an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv reads the SMTP settings from the environment. It returns false
+// when SMTP_HOST or SMTP_FROM is not set, meaning mail delivery is not configured
+func SMTPConfigFromEnv() (SMTPConfig, bool) {
+	config := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+
+	return config, config.Host != "" && config.From != ""
+}
+
+// SendMail sends a plain text email. Authentication is only used when a username is set
+func (c SMTPConfig) SendMail(to, subject, body string) error {
+	// Header values must not contain line breaks or they could inject extra headers
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid recipient or subject")
+	}
+
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+
+	message := "From: " + c.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	return smtp.SendMail(net.JoinHostPort(c.Host, c.Port), auth, c.From, []string{to}, []byte(message))
+}
@@ -14,6 +14,7 @@ import (
 	"s-network/backend/pkg/db/sqlite"
 	"s-network/backend/pkg/handlers"
 	"s-network/backend/pkg/logger"
+	"s-network/backend/pkg/utils"
 )
 
 var (
@@ -269,6 +270,19 @@ func init() {
 	handlersStartTime := time.Now()
 	logger.Println("Setting up handlers...")
 	handlers.SetDependencies(db, store)
+
+	// Reset tokens are emailed when SMTP is configured, and only printed when explicitly
+	// enabled in development
+	if smtpConfig, ok := utils.SMTPConfigFromEnv(); ok {
+		logger.Printf("Password reset emails will be sent through %s", smtpConfig.Host)
+		handlers.PasswordResetMailer = handlers.NewSMTPPasswordResetMailer(smtpConfig, os.Getenv("PASSWORD_RESET_URL"))
+	} else if isDev && os.Getenv("DEV_LOG_PASSWORD_RESET_TOKENS") == "true" {
+		logger.Println("WARNING: Password reset tokens will be written to the log")
+		handlers.PasswordResetMailer = handlers.LogPasswordResetMailer
+	} else {
+		logger.Println("WARNING: SMTP_HOST and SMTP_FROM are not set, password resets are disabled")
+	}
+
 	logger.Printf("Handlers setup completed in %v", time.Since(handlersStartTime))
 
 	// Clean up expired sessions and tokens on startup
@@ -293,6 +307,54 @@ func init() {
 		}
 	}()
 
+	// Start background routine that publishes scheduled posts once their time has passed
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			published, err := handlers.PublishScheduledPosts()
+			if err != nil {
+				logger.Printf("Warning: Failed to publish scheduled posts: %v", err)
+			} else if published > 0 {
+				logger.Printf("Published %d scheduled posts", published)
+			}
+		}
+	}()
+
+	// Start background routine that purges deleted posts once they can no longer be restored
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := handlers.PurgeDeletedPosts(); err != nil {
+				logger.Printf("Warning: Failed to purge deleted posts: %v", err)
+			}
+		}
+	}()
+
+	// Start background routine that removes uploaded files no longer referenced by any row.
+	// Set UPLOAD_CLEANUP_DRY_RUN=true to only log what would be deleted.
+	go func() {
+		dryRun := os.Getenv("UPLOAD_CLEANUP_DRY_RUN") == "true"
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			orphaned, err := handlers.CleanupOrphanedUploads(uploadsDir, dryRun)
+			if err != nil {
+				logger.Printf("Warning: Failed to cleanup orphaned uploads: %v", err)
+			} else if orphaned > 0 {
+				if dryRun {
+					logger.Printf("Found %d orphaned uploads (dry run, nothing deleted)", orphaned)
+				} else {
+					logger.Printf("Removed %d orphaned uploads", orphaned)
+				}
+			}
+		}
+	}()
+
 	logger.Printf("Total initialization completed in %v", time.Since(startTime))
 }
 
@@ -349,8 +411,7 @@ func main() {
 			uploadsPath = "./uploads"
 		}
 	}
-	uploadsFS := http.FileServer(http.Dir(uploadsPath))
-	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", uploadsFS))
+	r.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", handlers.UploadsHandler(uploadsPath)))
 
 	// Add a health check endpoint
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
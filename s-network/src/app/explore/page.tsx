@@ -19,7 +19,7 @@ interface Post {
   updated_at: string;
   upvotes?: number;
   downvotes?: number;
-  comment_count?: number;
+  comments_count?: number;
   user_vote?: number;
   author: {
     id?: number;
@@ -411,7 +411,7 @@ export default function Explore() {
                                 d="M8 12h.01M12 12h.01M16 12h.01M21 12c0 4.418-4.03 8-9 8a9.863 9.863 0 01-4.255-.949L3 20l1.395-3.72C3.512 15.042 3 13.574 3 12c0-4.418 4.03-8 9-8s9 3.582 9 8z"
                               />
                             </svg>
-                            <span className="text-xs">{post.comment_count || 0} Comments</span>
+                            <span className="text-xs">{post.comments_count || 0} Comments</span>
                           </div>
                           <div
                             className="flex items-center py-1.5 px-2.5 rounded-full hover:bg-gray-100 transition-colors cursor-pointer flex-shrink-0"
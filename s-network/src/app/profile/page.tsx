@@ -42,7 +42,7 @@ interface Post {
   user_vote?: number;
   upvotes?: number;
   downvotes?: number;
-  comment_count?: number;
+  comments_count?: number;
 }
 
 export default function Profile() {
@@ -1448,7 +1448,7 @@ export default function Profile() {
                               d="M8 12h.01M12 12h.01M16 12h.01M21 12c0 4.418-4.03 8-9 8a9.863 9.863 0 01-4.255-.949L3 20l1.395-3.72C3.512 15.042 3 13.574 3 12c0-4.418 4.03-8 9-8s9 3.582 9 8z"
                             />
                           </svg>
-                          <span className="text-xs">{post.comment_count || 0} Comments</span>
+                          <span className="text-xs">{post.comments_count || 0} Comments</span>
                         </div>
                         <div
                           className="flex items-center py-1.5 px-2.5 rounded-full hover:bg-gray-100 transition-colors cursor-pointer flex-shrink-0"